@@ -0,0 +1,175 @@
+package main
+
+import (
+	"aliyun-pipelines-tui/internal/credentials"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/term"
+)
+
+// runCredential implements `flowt credential`, which replaces the secrets in
+// config.yml with references to the environment, a credential command or the
+// encrypted credential store
+func runCredential(profileName string, args []string, stdin *os.File, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: flowt [-profile NAME] credential set [-env VAR | -command CMD] <field>\n       flowt credential migrate")
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	config := &Config{}
+	if _, err := os.Stat(filepath.Join(dir, "config.yml")); err == nil {
+		if config, err = loadConfig(); err != nil {
+			return err
+		}
+	}
+	store := credentials.NewStore(dir)
+
+	switch args[0] {
+	case "set":
+		err = credentialSet(config, store, profileName, args[1:], stdin, stderr)
+	case "migrate":
+		err = credentialMigrate(config, store, stderr)
+	default:
+		return fmt.Errorf("unknown credential command %q, use set or migrate", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	return saveConfig(config)
+}
+
+// credentialSet sets one secret of a profile to a reference
+func credentialSet(config *Config, store *credentials.Store, profileName string, args []string, stdin *os.File, stderr io.Writer) error {
+	fs := flag.NewFlagSet("credential set", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	envName := fs.String("env", "", "read the secret from this environment variable")
+	command := fs.String("command", "", "read the secret from the first line printed by this command")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: flowt [-profile NAME] credential set [-env VAR | -command CMD] <field>")
+	}
+	if *envName != "" && *command != "" {
+		return errors.New("-env and -command cannot be used together")
+	}
+	field := fs.Arg(0)
+
+	name, err := credentialProfileName(config, profileName)
+	if err != nil {
+		return err
+	}
+	return editProfile(config, name, func(profile *Profile) error {
+		value, ok := profile.secretFields()[field]
+		if !ok {
+			return fmt.Errorf("unknown field %q, use personal_access_token, access_key_id or access_key_secret", field)
+		}
+
+		switch {
+		case *envName != "":
+			*value = credentials.EnvPrefix + *envName
+		case *command != "":
+			*value = credentials.CommandPrefix + *command
+		default:
+			secret, err := readSecret(stdin, stderr, fmt.Sprintf("%s for profile %s: ", field, name))
+			if err != nil {
+				return err
+			}
+			secretName := name + "." + field
+			if err := store.Set(secretName, secret); err != nil {
+				return err
+			}
+			*value = credentials.EncryptedPrefix + secretName
+		}
+		fmt.Fprintf(stderr, "Set %s of profile %s to %s\n", field, name, *value)
+		return nil
+	})
+}
+
+// credentialMigrate moves the plaintext secrets of every profile into the
+// encrypted credential store
+func credentialMigrate(config *Config, store *credentials.Store, stderr io.Writer) error {
+	moved := 0
+	for _, name := range config.ProfileNames() {
+		err := editProfile(config, name, func(profile *Profile) error {
+			fields := profile.secretFields()
+			names := make([]string, 0, len(fields))
+			for field := range fields {
+				names = append(names, field)
+			}
+			sort.Strings(names)
+
+			for _, field := range names {
+				value := fields[field]
+				if *value == "" || credentials.IsReference(*value) {
+					continue
+				}
+				secretName := name + "." + field
+				if err := store.Set(secretName, *value); err != nil {
+					return err
+				}
+				*value = credentials.EncryptedPrefix + secretName
+				fmt.Fprintf(stderr, "Encrypted %s of profile %s\n", field, name)
+				moved++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if moved == 0 {
+		fmt.Fprintln(stderr, "No plaintext credentials found")
+	}
+	return nil
+}
+
+// credentialProfileName returns the profile that `credential set` changes
+func credentialProfileName(config *Config, profileName string) (string, error) {
+	if profileName == "" && len(config.ProfileNames()) == 0 {
+		// A new config file: the secret goes at the top level
+		return defaultProfileName, nil
+	}
+	name, _, err := config.ResolveProfile(profileName)
+	return name, err
+}
+
+// editProfile calls edit with the named profile and stores the changes
+func editProfile(config *Config, name string, edit func(profile *Profile) error) error {
+	if profile, ok := config.Profiles[name]; ok {
+		if err := edit(&profile); err != nil {
+			return err
+		}
+		config.Profiles[name] = profile
+		return nil
+	}
+	if name == defaultProfileName {
+		return edit(&config.Profile)
+	}
+	return fmt.Errorf("profile %q not found", name)
+}
+
+// readSecret prompts for a secret without echoing it, or reads it from stdin
+// when that is not a terminal
+func readSecret(stdin *os.File, stderr io.Writer, prompt string) (string, error) {
+	if !term.IsTerminal(int(stdin.Fd())) {
+		return credentials.ReadSecret(stdin)
+	}
+	fmt.Fprint(stderr, prompt)
+	data, err := term.ReadPassword(int(stdin.Fd()))
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return credentials.ReadSecret(bytes.NewReader(data))
+}
@@ -1,44 +1,111 @@
 package main
 
 import (
-	"aliyun-pipelines-tui/internal/api" // Import the api package
-	"aliyun-pipelines-tui/internal/ui"  // Local package for UI components
+	"aliyun-pipelines-tui/internal/api"         // Import the api package
+	"aliyun-pipelines-tui/internal/api/fake"    // In-memory backend for -demo
+	"aliyun-pipelines-tui/internal/cli"         // Non-interactive subcommands
+	"aliyun-pipelines-tui/internal/credentials" // Secrets referenced from the config
+	"aliyun-pipelines-tui/internal/fileutil"    // Atomic writes of the config
+	"aliyun-pipelines-tui/internal/plan"        // Run plans given with -plan
+	"aliyun-pipelines-tui/internal/preset"      // Saved run parameter sets
+	"aliyun-pipelines-tui/internal/ui"          // Local package for UI components
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"gopkg.in/yaml.v3"
 )
 
-// Config represents the application configuration
-type Config struct {
+// defaultProfileName names the connection settings at the top level of the config
+const defaultProfileName = "default"
+
+// Profile holds the connection settings for one Yunxiao organization
+type Profile struct {
 	// 云效服务接入点域名
-	Endpoint string `yaml:"endpoint"`
+	Endpoint string `yaml:"endpoint,omitempty"`
 	// 个人访问令牌 (推荐的认证方式)
-	PersonalAccessToken string `yaml:"personal_access_token"`
+	PersonalAccessToken string `yaml:"personal_access_token,omitempty"`
 	// 企业 ID（组织 ID）
-	OrganizationID string `yaml:"organization_id"`
+	OrganizationID string `yaml:"organization_id,omitempty"`
 	// AccessKey 认证方式 (备用方式)
-	AccessKeyID     string `yaml:"access_key_id"`
-	AccessKeySecret string `yaml:"access_key_secret"`
-	RegionID        string `yaml:"region_id"`
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	AccessKeySecret string `yaml:"access_key_secret,omitempty"`
+	RegionID        string `yaml:"region_id,omitempty"`
+}
+
+// isSet reports whether any connection setting is present
+func (p Profile) isSet() bool {
+	return p != Profile{}
+}
+
+// Config represents the application configuration
+type Config struct {
+	// 顶层的连接配置，即名为 default 的 profile
+	Profile `yaml:",inline"`
+	// 命名的连接配置，用于多个组织（如 staging、production）
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// 未指定 --profile 时使用的 profile
+	DefaultProfile string `yaml:"default_profile,omitempty"`
 	// 编辑器和分页器配置
 	Editor string `yaml:"editor,omitempty"`
 	Pager  string `yaml:"pager,omitempty"`
 	// 书签配置
 	Bookmarks []string `yaml:"bookmarks,omitempty"`
+	// 运行预设：按流水线名称保存的常用运行参数（分支、变量）
+	Presets preset.Presets `yaml:"presets,omitempty"`
+}
+
+// secretFields returns the settings of the profile that may hold a secret or a
+// reference to one, by their names in the config file
+func (p *Profile) secretFields() map[string]*string {
+	return map[string]*string{
+		"personal_access_token": &p.PersonalAccessToken,
+		"access_key_id":         &p.AccessKeyID,
+		"access_key_secret":     &p.AccessKeySecret,
+	}
+}
+
+// resolveSecrets replaces the env:, cmd: and enc: references in the profile
+// with the secrets they refer to
+func (p *Profile) resolveSecrets(store *credentials.Store) error {
+	for field, value := range p.secretFields() {
+		if *value == "" {
+			continue
+		}
+		secret, err := credentials.Resolve(*value, store)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", field, err)
+		}
+		*value = secret
+	}
+	return nil
+}
+
+// configDir returns the directory of the config file and the credential store
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".flowt"), nil
 }
 
 // loadConfig loads configuration from ~/.flowt/config.yml
 func loadConfig() (*Config, error) {
-	homeDir, err := os.UserHomeDir()
+	dir, err := configDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
 
-	configPath := filepath.Join(homeDir, ".flowt", "config.yml")
+	configPath := filepath.Join(dir, "config.yml")
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -60,19 +127,12 @@ func loadConfig() (*Config, error) {
 	return &config, nil
 }
 
-// saveConfig saves configuration to ~/.flowt/config.yml
+// saveConfig saves configuration to ~/.flowt/config.yml. The file is only
+// readable by the user as it may hold credentials.
 func saveConfig(config *Config) error {
-	homeDir, err := os.UserHomeDir()
+	dir, err := configDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
-	}
-
-	configPath := filepath.Join(homeDir, ".flowt", "config.yml")
-
-	// Ensure config directory exists
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+		return err
 	}
 
 	// Marshal config to YAML
@@ -82,22 +142,71 @@ func saveConfig(config *Config) error {
 	}
 
 	// Write config file
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := fileutil.WritePrivateFile(filepath.Join(dir, "config.yml"), data); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
-// validateConfig validates the configuration
-func validateConfig(config *Config) error {
-	if config.OrganizationID == "" {
+// ProfileNames returns the names of the configured profiles, the top-level
+// default profile first
+func (config *Config) ProfileNames() []string {
+	var names []string
+	if _, ok := config.Profiles[defaultProfileName]; !ok && config.Profile.isSet() {
+		names = append(names, defaultProfileName)
+	}
+	var named []string
+	for name := range config.Profiles {
+		named = append(named, name)
+	}
+	sort.Strings(named)
+	return append(names, named...)
+}
+
+// ResolveProfile returns the profile to use and its name. An empty name
+// selects $FLOWT_PROFILE, then default_profile, then the top-level settings,
+// then the only named profile.
+func (config *Config) ResolveProfile(name string) (string, *Profile, error) {
+	if name == "" {
+		name = os.Getenv("FLOWT_PROFILE")
+	}
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		switch names := config.ProfileNames(); len(names) {
+		case 0:
+			return "", nil, fmt.Errorf("organization_id is required in configuration")
+		case 1:
+			name = names[0]
+		default:
+			if !config.Profile.isSet() {
+				return "", nil, fmt.Errorf("several profiles are configured (%s); choose one with --profile or default_profile", strings.Join(names, ", "))
+			}
+			name = defaultProfileName
+		}
+	}
+
+	if profile, ok := config.Profiles[name]; ok {
+		return name, &profile, nil
+	}
+	if name == defaultProfileName && config.Profile.isSet() {
+		profile := config.Profile
+		return name, &profile, nil
+	}
+	return "", nil, fmt.Errorf("profile %q not found; configured profiles: %s", name, strings.Join(config.ProfileNames(), ", "))
+}
+
+// validateProfile validates the connection settings of a profile
+func validateProfile(profile *Profile) error {
+	if profile.OrganizationID == "" {
 		return fmt.Errorf("organization_id is required in configuration")
 	}
 
 	// 检查认证方式：优先使用个人访问令牌，其次使用AccessKey
-	hasPersonalToken := config.PersonalAccessToken != ""
-	hasAccessKey := config.AccessKeyID != "" && config.AccessKeySecret != ""
+	hasPersonalToken := profile.PersonalAccessToken != ""
+	hasAccessKey := profile.AccessKeyID != "" && profile.AccessKeySecret != ""
 
 	if !hasPersonalToken && !hasAccessKey {
 		return fmt.Errorf("either personal_access_token or both access_key_id and access_key_secret are required")
@@ -191,62 +300,244 @@ func IsBookmarked(config *Config, pipelineName string) bool {
 	return false
 }
 
-func main() {
-	// Load configuration from file
-	config, err := loadConfig()
+// newAPIClient creates the API client for the profile's authentication method
+func newAPIClient(profile *Profile) (*api.Client, error) {
+	// 优先使用个人访问令牌认证
+	if profile.PersonalAccessToken != "" {
+		endpoint := profile.Endpoint
+		if endpoint == "" {
+			endpoint = "openapi-rdc.aliyuncs.com" // 默认端点
+		}
+		client, err := api.NewClientWithToken(endpoint, profile.PersonalAccessToken)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing API client with personal access token: %w", err)
+		}
+		return client, nil
+	}
+
+	// 使用AccessKey认证作为备用方式
+	regionID := profile.RegionID
+	if regionID == "" {
+		regionID = "cn-hangzhou" // 默认区域
+	}
+	client, err := api.NewClient(profile.AccessKeyID, profile.AccessKeySecret, regionID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		fmt.Fprintln(os.Stderr, "\nPlease create a configuration file at ~/.flowt/config.yml with the following format:")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "# 企业 ID（组织 ID）- 必填")
-		fmt.Fprintln(os.Stderr, "organization_id: your_organization_id")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "# 推荐：使用个人访问令牌认证")
-		fmt.Fprintln(os.Stderr, "personal_access_token: your_personal_access_token")
-		fmt.Fprintln(os.Stderr, "endpoint: openapi-rdc.aliyuncs.com  # 可选，默认值")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "# 或者：使用AccessKey认证（备用方式）")
-		fmt.Fprintln(os.Stderr, "# access_key_id: your_access_key_id")
-		fmt.Fprintln(os.Stderr, "# access_key_secret: your_access_key_secret")
-		fmt.Fprintln(os.Stderr, "# region_id: cn-hangzhou  # 可选，默认值")
-		os.Exit(1)
+		return nil, fmt.Errorf("error initializing API client with access key: %w", err)
+	}
+	return client, nil
+}
+
+// printConfigHelp prints the expected configuration file format
+func printConfigHelp() {
+	fmt.Fprintln(os.Stderr, "\nPlease create a configuration file at ~/.flowt/config.yml with the following format:")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "# 企业 ID（组织 ID）- 必填")
+	fmt.Fprintln(os.Stderr, "organization_id: your_organization_id")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "# 推荐：使用个人访问令牌认证")
+	fmt.Fprintln(os.Stderr, "personal_access_token: your_personal_access_token")
+	fmt.Fprintln(os.Stderr, "# 或引用凭证：env:环境变量名、cmd:命令、enc:加密存储中的名称")
+	fmt.Fprintln(os.Stderr, "# personal_access_token: env:FLOWT_TOKEN")
+	fmt.Fprintln(os.Stderr, "endpoint: openapi-rdc.aliyuncs.com  # 可选，默认值")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "# 或者：使用AccessKey认证（备用方式）")
+	fmt.Fprintln(os.Stderr, "# access_key_id: your_access_key_id")
+	fmt.Fprintln(os.Stderr, "# access_key_secret: your_access_key_secret")
+	fmt.Fprintln(os.Stderr, "# region_id: cn-hangzhou  # 可选，默认值")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "# 多个组织：在 profiles 下配置，并用 --profile 选择")
+	fmt.Fprintln(os.Stderr, "# profiles:")
+	fmt.Fprintln(os.Stderr, "#   production:")
+	fmt.Fprintln(os.Stderr, "#     organization_id: your_production_organization_id")
+	fmt.Fprintln(os.Stderr, "#     personal_access_token: your_personal_access_token")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Or try flowt without credentials: flowt -demo")
+}
+
+// connection is a pipeline service and the organization it is used with
+type connection struct {
+	profile        string
+	organizationID string
+	service        api.PipelineService
+}
+
+// connect creates the connection for the named profile of config
+func connect(config *Config, profileName string) (*connection, error) {
+	name, profile, err := config.ResolveProfile(profileName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	// Validate configuration
-	if err := validateConfig(config); err != nil {
-		fmt.Fprintf(os.Stderr, "Configuration validation error: %v\n", err)
-		os.Exit(1)
+	if err := validateProfile(profile); err != nil {
+		return nil, fmt.Errorf("invalid configuration for profile %s: %w", name, err)
+	}
+
+	// Look up the credentials the profile refers to
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := profile.resolveSecrets(credentials.NewStore(dir)); err != nil {
+		return nil, fmt.Errorf("invalid configuration for profile %s: %w", name, err)
 	}
 
 	// Initialize API client with configuration
-	var apiClient *api.Client
+	apiClient, err := newAPIClient(profile)
+	if err != nil {
+		return nil, err
+	}
+	return &connection{profile: name, organizationID: profile.OrganizationID, service: apiClient}, nil
+}
 
-	// 优先使用个人访问令牌认证
-	if config.PersonalAccessToken != "" {
-		endpoint := config.Endpoint
-		if endpoint == "" {
-			endpoint = "openapi-rdc.aliyuncs.com" // 默认端点
+// loadService returns the configuration and the connection to use: the demo
+// data, or a client for the selected profile's Yunxiao organization
+func loadService(demo bool, profileName string) (*Config, *connection, error) {
+	if demo {
+		// Demo mode needs no configuration; editor, pager and bookmarks are
+		// taken from the config file if there is one but never written back
+		config := &Config{}
+		if loaded, err := loadConfig(); err == nil {
+			config.Editor = loaded.Editor
+			config.Pager = loaded.Pager
+			config.Bookmarks = loaded.Bookmarks
+			config.Presets = loaded.Presets
 		}
-		var err error
-		apiClient, err = api.NewClientWithToken(endpoint, config.PersonalAccessToken)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing API client with personal access token: %v\n", err)
-			os.Exit(1)
+		return config, &connection{profile: "demo", organizationID: fake.DemoOrganizationID, service: fake.NewDemo()}, nil
+	}
+
+	// Load configuration from file
+	config, err := loadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	conn, err := connect(config, profileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, conn, nil
+}
+
+// schedulesPath returns the file `flowt schedule` keeps scheduled runs in.
+// Demo mode keeps them in the temporary directory rather than ~/.flowt.
+func schedulesPath(demo bool) string {
+	if demo {
+		return filepath.Join(os.TempDir(), "flowt-demo-schedules.yml")
+	}
+	dir, err := configDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "schedules.yml")
+}
+
+// runCommand runs a non-interactive subcommand and returns the exit code
+func runCommand(demo bool, profileName string, args []string) int {
+	if args[0] == "mock-server" {
+		if err := runMockServer(args[1:]); err != nil && err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "mock-server: %v\n", err)
+			return 1
 		}
-	} else {
-		// 使用AccessKey认证作为备用方式
-		regionID := config.RegionID
-		if regionID == "" {
-			regionID = "cn-hangzhou" // 默认区域
+		return 0
+	}
+
+	if args[0] == "credential" {
+		if err := runCredential(profileName, args[1:], os.Stdin, os.Stderr); err != nil && err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "credential: %v\n", err)
+			return 1
 		}
+		return 0
+	}
+
+	if !cli.IsCommand(args[0]) {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		flag.Usage()
+		return 2
+	}
+
+	if args[0] == "help" {
+		flag.CommandLine.SetOutput(os.Stdout)
+		flag.Usage()
+		return 0
+	}
+
+	config, conn, err := loadService(demo, profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := &cli.Runner{
+		Service:        conn.service,
+		OrganizationID: conn.organizationID,
+		Presets:        config.Presets,
+		Schedules:      schedulesPath(demo),
+		Stdout:         os.Stdout,
+		Stderr:         os.Stderr,
+	}
+	err = runner.Run(ctx, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", cli.DescribeError(err))
+	}
+	return cli.ExitCode(err)
+}
+
+// usage prints the command line help
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: flowt [-demo] [-profile NAME] [-plan FILE]     start the terminal UI")
+	fmt.Fprintln(out, "       flowt [-demo] [-profile NAME] <command> ...   run a command without the UI")
+	fmt.Fprintln(out, "       flowt mock-server [-addr ADDR] [-token TOKEN]")
+	fmt.Fprintln(out, "       flowt [-profile NAME] credential set [-env VAR | -command CMD] <field>")
+	fmt.Fprintln(out, "       flowt credential migrate")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Options:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "")
+	cli.Usage(out)
+}
+
+func main() {
+	demo := flag.Bool("demo", false, "use built-in demo data instead of connecting to Yunxiao")
+	profileName := flag.String("profile", "", "configuration profile (organization) to use (default: $FLOWT_PROFILE or default_profile)")
+	planPath := flag.String("plan", "", "run the plan in `FILE` and show its progress (resumes a plan interrupted earlier)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(*demo, *profileName, flag.Args()))
+	}
+
+	var runPlan *plan.Plan
+	var planState *plan.State
+	if *planPath != "" {
 		var err error
-		apiClient, err = api.NewClient(config.AccessKeyID, config.AccessKeySecret, regionID)
+		if runPlan, err = plan.Load(*planPath); err == nil {
+			planState, err = plan.LoadState(plan.StatePath(*planPath))
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing API client with access key: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
+	config, conn, err := loadService(*demo, *profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !*demo {
+			printConfigHelp()
+		}
+		os.Exit(1)
+	}
+	saveConfigFunc := func() error { return saveConfig(config) }
+	if *demo {
+		saveConfigFunc = func() error { return nil }
+	}
+
 	// Set transparent background style
 	tcell.StyleDefault = tcell.StyleDefault.Background(tcell.ColorDefault).Foreground(tcell.ColorDefault)
 
@@ -260,12 +551,34 @@ func main() {
 	ui.SetBookmarkFunctions(
 		func(pipelineName string) bool { return ToggleBookmark(config, pipelineName) },
 		func(pipelineName string) bool { return IsBookmarked(config, pipelineName) },
-		func() error { return saveConfig(config) },
+		saveConfigFunc,
 		config.Bookmarks,
 	)
 
+	// Offer the run presets in the run dialog; presets saved there go to the config
+	if config.Presets == nil {
+		config.Presets = preset.Presets{}
+	}
+	ui.SetPresets(config.Presets)
+
+	// Let the UI switch between the organizations of the configured profiles
+	if !*demo {
+		ui.SetProfileFunctions(config.ProfileNames(), conn.profile, func(name string) (api.PipelineService, string, error) {
+			switched, err := connect(config, name)
+			if err != nil {
+				return nil, "", err
+			}
+			return switched.service, switched.organizationID, nil
+		})
+	}
+
 	// Create the main view (Pages) using ui.NewMainView()
-	mainPages := ui.NewMainView(app, apiClient, config.OrganizationID) // Pass apiClient and orgId
+	mainPages := ui.NewMainView(app, conn.service, conn.organizationID) // Pass the service and orgId
+
+	// Run the plan given with -plan in the background, starting on its view
+	if runPlan != nil {
+		ui.StartPlan(app, conn.service, conn.organizationID, *planPath, runPlan, planState)
+	}
 
 	// Set up global input capture for 'q' and Ctrl+C to stop the application
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"aliyun-pipelines-tui/internal/credentials"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const profilesConfig = `
+organization_id: org-default
+personal_access_token: pt-default
+default_profile: staging
+profiles:
+  staging:
+    organization_id: org-staging
+    personal_access_token: pt-staging
+  production:
+    organization_id: org-production
+    access_key_id: ak
+    access_key_secret: sk-prod
+bookmarks:
+  - svc
+presets:
+  svc:
+    - name: hotfix
+      branch: hotfix/1.8
+      variables:
+        HOTFIX: "true"
+`
+
+func parseConfig(t *testing.T, text string) *Config {
+	t.Helper()
+	var config Config
+	if err := yaml.Unmarshal([]byte(text), &config); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	return &config
+}
+
+func TestResolveProfile(t *testing.T) {
+	t.Setenv("FLOWT_PROFILE", "")
+	config := parseConfig(t, profilesConfig)
+
+	if got, want := config.ProfileNames(), []string{"default", "production", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileNames() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name, env, want, wantOrg string
+	}{
+		{name: "", want: "staging", wantOrg: "org-staging"},
+		{name: "production", want: "production", wantOrg: "org-production"},
+		{name: "default", want: "default", wantOrg: "org-default"},
+		{env: "production", want: "production", wantOrg: "org-production"},
+		{name: "staging", env: "production", want: "staging", wantOrg: "org-staging"},
+	}
+	for _, tt := range tests {
+		t.Setenv("FLOWT_PROFILE", tt.env)
+		name, profile, err := config.ResolveProfile(tt.name)
+		if err != nil {
+			t.Errorf("ResolveProfile(%q) with FLOWT_PROFILE=%q: %v", tt.name, tt.env, err)
+			continue
+		}
+		if name != tt.want || profile.OrganizationID != tt.wantOrg {
+			t.Errorf("ResolveProfile(%q) with FLOWT_PROFILE=%q = %s (%s), want %s (%s)", tt.name, tt.env, name, profile.OrganizationID, tt.want, tt.wantOrg)
+		}
+	}
+
+	t.Setenv("FLOWT_PROFILE", "")
+	if _, _, err := config.ResolveProfile("missing"); err == nil || !strings.Contains(err.Error(), "default, production, staging") {
+		t.Errorf("expected an unknown profile error listing the profiles, got %v", err)
+	}
+}
+
+func TestResolveProfileWithoutDefault(t *testing.T) {
+	t.Setenv("FLOWT_PROFILE", "")
+
+	// A config without profiles keeps working as before
+	name, profile, err := parseConfig(t, "organization_id: org\npersonal_access_token: pt\n").ResolveProfile("")
+	if err != nil || name != "default" || profile.OrganizationID != "org" {
+		t.Errorf("top-level config resolved to %s, %+v, %v", name, profile, err)
+	}
+
+	// A single named profile is used without asking
+	name, profile, err = parseConfig(t, "profiles:\n  only:\n    organization_id: org-only\n").ResolveProfile("")
+	if err != nil || name != "only" || profile.OrganizationID != "org-only" {
+		t.Errorf("single profile resolved to %s, %+v, %v", name, profile, err)
+	}
+
+	// Several named profiles and no default need --profile
+	config := parseConfig(t, "profiles:\n  a:\n    organization_id: a\n  b:\n    organization_id: b\n")
+	if _, _, err := config.ResolveProfile(""); err == nil || !strings.Contains(err.Error(), "--profile") {
+		t.Errorf("expected an error asking for --profile, got %v", err)
+	}
+
+	if _, _, err := parseConfig(t, "editor: vim\n").ResolveProfile(""); err == nil {
+		t.Error("expected an error for a config without an organization")
+	}
+}
+
+func TestSaveConfigKeepsProfiles(t *testing.T) {
+	config := parseConfig(t, profilesConfig)
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := config.Presets.Find("svc", "hotfix"); !ok || p.Variables["HOTFIX"] != "true" {
+		t.Errorf("presets = %+v", config.Presets)
+	}
+	reloaded := parseConfig(t, string(data))
+	if !reflect.DeepEqual(reloaded, config) {
+		t.Errorf("config changed when saved and reloaded:\n%s", data)
+	}
+}
+
+func TestCredentialMigrate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("FLOWT_PROFILE", "")
+	configPath := filepath.Join(home, ".flowt", "config.yml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(profilesConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	if err := runCredential("", []string{"migrate"}, os.Stdin, &stderr); err != nil {
+		t.Fatalf("migrate: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"pt-default", "pt-staging", "sk-prod"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("config.yml still holds %s:\n%s", secret, data)
+		}
+	}
+	if info, _ := os.Stat(configPath); info.Mode().Perm() != 0600 {
+		t.Errorf("config.yml has mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// The references resolve to the original secrets
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, profile, err := config.ResolveProfile("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.AccessKeySecret != "enc:production.access_key_secret" {
+		t.Errorf("access_key_secret = %q, want a reference", profile.AccessKeySecret)
+	}
+	store := credentials.NewStore(filepath.Join(home, ".flowt"))
+	if err := profile.resolveSecrets(store); err != nil {
+		t.Fatal(err)
+	}
+	if profile.AccessKeyID != "ak" || profile.AccessKeySecret != "sk-prod" {
+		t.Errorf("resolved to %q/%q, want ak/sk-prod", profile.AccessKeyID, profile.AccessKeySecret)
+	}
+
+	// Setting an environment reference replaces the stored secret's reference
+	if err := runCredential("staging", []string{"set", "-env", "STAGING_TOKEN", "personal_access_token"}, os.Stdin, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STAGING_TOKEN", "pt-env")
+	if config, err = loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	_, profile, _ = config.ResolveProfile("staging")
+	if err := profile.resolveSecrets(store); err != nil || profile.PersonalAccessToken != "pt-env" {
+		t.Errorf("staging token resolved to %q, %v, want pt-env", profile.PersonalAccessToken, err)
+	}
+}
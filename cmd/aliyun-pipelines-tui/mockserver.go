@@ -0,0 +1,42 @@
+package main
+
+import (
+	"aliyun-pipelines-tui/internal/api/fake"
+	"aliyun-pipelines-tui/internal/mockserver"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// runMockServer implements `flowt mock-server`: it serves the demo data over
+// the Yunxiao OpenAPI so that the TUI and scripts can be pointed at it
+func runMockServer(args []string) error {
+	fs := flag.NewFlagSet("mock-server", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	token := fs.String("token", "", "personal access token to require (default: accept any token)")
+	quiet := fs.Bool("quiet", false, "do not log requests")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	server := mockserver.New(fake.NewDemo(), *token)
+	if !*quiet {
+		server.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	shownToken := *token
+	if shownToken == "" {
+		shownToken = "any-non-empty-token"
+	}
+	fmt.Fprintf(os.Stderr, "Serving the Yunxiao OpenAPI with demo data on http://%s\n", *addr)
+	fmt.Fprintln(os.Stderr, "Point flowt at it with this ~/.flowt/config.yml:")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintf(os.Stderr, "endpoint: http://%s\n", *addr)
+	fmt.Fprintf(os.Stderr, "organization_id: %s\n", fake.DemoOrganizationID)
+	fmt.Fprintf(os.Stderr, "personal_access_token: %s\n", shownToken)
+	fmt.Fprintln(os.Stderr, "")
+
+	return http.ListenAndServe(*addr, server)
+}
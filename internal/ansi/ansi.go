@@ -0,0 +1,235 @@
+// Package ansi translates the ANSI escape sequences that build tools write to
+// their logs into tview style tags.
+package ansi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Style is the style of a run of text. Empty colors are the default colors,
+// other colors are tview color names or #rrggbb. Attributes holds the tview
+// attribute letters that are set, such as "b" for bold.
+type Style struct {
+	Foreground string
+	Background string
+	Attributes string
+}
+
+// Span is a run of text in one style.
+type Span struct {
+	Text  string
+	Style Style
+}
+
+// Tag returns the tview style tags that switch to the style from any other.
+func (s Style) Tag() string {
+	foreground, background := s.Foreground, s.Background
+	if foreground == "" {
+		foreground = "-"
+	}
+	if background == "" {
+		background = "-"
+	}
+	tag := fmt.Sprintf("[%s:%s:-]", foreground, background)
+	if s.Attributes != "" {
+		tag += "[::" + s.Attributes + "]"
+	}
+	return tag
+}
+
+// SetAttribute sets (on) or clears an attribute letter, keeping the letters in
+// a stable order so that equal styles compare equal.
+func (s *Style) SetAttribute(letter byte, on bool) {
+	var attributes strings.Builder
+	for _, a := range []byte("bdilrsu") {
+		if a == letter && on || a != letter && strings.IndexByte(s.Attributes, a) >= 0 {
+			attributes.WriteByte(a)
+		}
+	}
+	s.Attributes = attributes.String()
+}
+
+// colorNames are the tview names of the 16 standard terminal colors.
+var colorNames = []string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+// color256 returns the tview color of an entry of the 256 color palette.
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return colorNames[n]
+	case n < 232:
+		// A 6x6x6 color cube with the levels xterm uses
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		grey := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", grey, grey, grey)
+	}
+}
+
+// sgrAttributes maps the SGR parameters that set attributes to tview letters.
+var sgrAttributes = map[int]byte{1: 'b', 2: 'd', 3: 'i', 4: 'u', 5: 'l', 7: 'r', 9: 's'}
+
+// apply updates the style with the parameters of an SGR sequence ("ESC[...m").
+func (s *Style) apply(params string) {
+	if params == "" {
+		*s = Style{}
+		return
+	}
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			if fields[i] != "" {
+				continue
+			}
+			n = 0
+		}
+		switch {
+		case n == 0:
+			*s = Style{}
+		case sgrAttributes[n] != 0:
+			s.SetAttribute(sgrAttributes[n], true)
+		case n == 22:
+			s.SetAttribute('b', false)
+			s.SetAttribute('d', false)
+		case n == 23 || n == 24 || n == 25 || n == 27 || n == 29:
+			s.SetAttribute(sgrAttributes[n-20], false)
+		case n >= 30 && n <= 37:
+			s.Foreground = colorNames[n-30]
+		case n == 39:
+			s.Foreground = ""
+		case n >= 40 && n <= 47:
+			s.Background = colorNames[n-40]
+		case n == 49:
+			s.Background = ""
+		case n >= 90 && n <= 97:
+			s.Foreground = colorNames[n-90+8]
+		case n >= 100 && n <= 107:
+			s.Background = colorNames[n-100+8]
+		case n == 38 || n == 48:
+			// Extended colors: 5;n from the 256 color palette or 2;r;g;b
+			var color string
+			if i+2 < len(fields) && fields[i+1] == "5" {
+				index, _ := strconv.Atoi(fields[i+2])
+				color = color256(index)
+				i += 2
+			} else if i+4 < len(fields) && fields[i+1] == "2" {
+				r, _ := strconv.Atoi(fields[i+2])
+				g, _ := strconv.Atoi(fields[i+3])
+				b, _ := strconv.Atoi(fields[i+4])
+				color = fmt.Sprintf("#%02x%02x%02x", r&0xff, g&0xff, b&0xff)
+				i += 4
+			} else {
+				return
+			}
+			if n == 38 {
+				s.Foreground = color
+			} else {
+				s.Background = color
+			}
+		}
+	}
+}
+
+// Parse splits text into spans of one style each, following the SGR (color
+// and attribute) sequences in it. Other escape sequences, such as cursor
+// movements, are dropped.
+func Parse(text string) []Span {
+	var (
+		spans []Span
+		style Style
+		run   strings.Builder
+	)
+	flush := func() {
+		if run.Len() > 0 {
+			spans = append(spans, Span{Text: run.String(), Style: style})
+			run.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != 0x1b {
+			run.WriteByte(text[i])
+			continue
+		}
+		if i+1 >= len(text) {
+			break
+		}
+		switch text[i+1] {
+		case '[':
+			// Control sequence: parameters, intermediates and a final byte
+			end := i + 2
+			for end < len(text) && (text[end] < 0x40 || text[end] > 0x7e) {
+				end++
+			}
+			if end >= len(text) {
+				i = len(text)
+				break
+			}
+			if text[end] == 'm' {
+				flush()
+				style.apply(text[i+2 : end])
+			}
+			i = end
+		case ']', 'P', 'X', '^', '_':
+			// String sequences end with BEL or ESC \
+			end := i + 2
+			for end < len(text) && text[end] != 0x07 && !(text[end] == 0x1b && end+1 < len(text) && text[end+1] == '\\') {
+				end++
+			}
+			if end < len(text) && text[end] == 0x1b {
+				end++
+			}
+			i = end
+		default:
+			// Two character sequences such as ESC c
+			i++
+		}
+	}
+	flush()
+	return spans
+}
+
+// Strip returns text without its escape sequences.
+func Strip(text string) string {
+	var plain strings.Builder
+	for _, span := range Parse(text) {
+		plain.WriteString(span.Text)
+	}
+	return plain.String()
+}
+
+// Tview renders spans as text for a tview text view with dynamic colors: the
+// text is escaped so that brackets in it are not taken for tags, and the
+// style is reset at the end so that it does not leak into what follows.
+func Tview(spans []Span) string {
+	var text strings.Builder
+	current := Style{}
+	for _, span := range spans {
+		if span.Style != current {
+			text.WriteString(span.Style.Tag())
+			current = span.Style
+		}
+		text.WriteString(tview.Escape(span.Text))
+	}
+	if current != (Style{}) {
+		text.WriteString(Style{}.Tag())
+	}
+	return text.String()
+}
+
+// ToTview translates the ANSI colors of text into tview style tags.
+func ToTview(text string) string {
+	return Tview(Parse(text))
+}
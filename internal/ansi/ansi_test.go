@@ -0,0 +1,74 @@
+package ansi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Span
+	}{
+		{
+			name: "plain",
+			text: "hello\n",
+			want: []Span{{Text: "hello\n"}},
+		},
+		{
+			name: "colors and reset",
+			text: "\x1b[32mok\x1b[0m done \x1b[1;31mFAIL\x1b[m",
+			want: []Span{
+				{Text: "ok", Style: Style{Foreground: "green"}},
+				{Text: " done "},
+				{Text: "FAIL", Style: Style{Foreground: "maroon", Attributes: "b"}},
+			},
+		},
+		{
+			name: "bright and extended colors",
+			text: "\x1b[91ma\x1b[38;5;208;48;2;0;0;255mb\x1b[39;49mc",
+			want: []Span{
+				{Text: "a", Style: Style{Foreground: "red"}},
+				{Text: "b", Style: Style{Foreground: "#ff8700", Background: "#0000ff"}},
+				{Text: "c"},
+			},
+		},
+		{
+			name: "attributes on and off",
+			text: "\x1b[4;1mx\x1b[22my\x1b[24mz",
+			want: []Span{
+				{Text: "x", Style: Style{Attributes: "bu"}},
+				{Text: "y", Style: Style{Attributes: "u"}},
+				{Text: "z"},
+			},
+		},
+		{
+			name: "other sequences are dropped",
+			text: "\x1b[2K\x1b[1Gline\x1b]0;title\x07 end\x1b[",
+			want: []Span{{Text: "line end"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Parse(%q) = %+v, want %+v", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestToTview(t *testing.T) {
+	got := ToTview("\x1b[1;32m[INFO]\x1b[0m build [ok]\n")
+	want := "[green:-:-][::b][INFO[][-:-:-] build [ok[]\n"
+	if got != want {
+		t.Errorf("ToTview = %q, want %q", got, want)
+	}
+
+	// A style left on at the end of the text is reset
+	if got := ToTview("\x1b[31mred"); got != "[maroon:-:-]red[-:-:-]" {
+		t.Errorf("ToTview of unterminated color = %q", got)
+	}
+
+	if got := Strip("\x1b[31mred\x1b[0m text"); got != "red text" {
+		t.Errorf("Strip = %q, want %q", got, "red text")
+	}
+}
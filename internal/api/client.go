@@ -1,7 +1,7 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json" // Added for dynamic parsing
 	"fmt"
 	"io"
@@ -73,6 +73,44 @@ type Job struct {
 	Result    string      `json:"result"`
 }
 
+// ValidationGate is the manual validation (人工卡点) a job waits on: the run
+// goes on once a validator passes it and fails if one refuses it
+type ValidationGate struct {
+	Description string   // What is to be checked, from the pass action's title
+	Validators  []string // Users who may pass or refuse the gate
+	Allowed     bool     // Whether the caller is one of them
+}
+
+// ValidationGate returns the manual validation the job waits on, if it is a
+// validation job that has not been passed or refused yet.
+func (j Job) ValidationGate() (*ValidationGate, bool) {
+	for _, action := range j.Actions {
+		if action.Type != "PassPipelineValidate" {
+			continue
+		}
+		gate := &ValidationGate{Description: action.Title, Allowed: !action.Disable}
+		if validators, ok := action.Params["validators"].([]interface{}); ok {
+			for _, v := range validators {
+				if name, ok := v.(string); ok {
+					gate.Validators = append(gate.Validators, name)
+				}
+			}
+		}
+		return gate, true
+	}
+	return nil, false
+}
+
+// JobStep represents a step of a job in a pipeline run, such as checking out
+// the code or running the build command
+type JobStep struct {
+	Index     string    `json:"stepIndex"` // Identifies the step in GetPipelineJobStepLog
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
 // Stage represents a stage in a pipeline run
 type Stage struct {
 	Index string `json:"index"`
@@ -137,11 +175,23 @@ type Client struct {
 	endpoint            string             // API endpoint for token-based requests
 	personalAccessToken string             // Personal access token
 	useToken            bool               // Whether to use token-based authentication
+	requestTimeout      time.Duration      // Deadline applied to each request whose context has none
+	retryPolicy         RetryPolicy        // Retry behaviour of token-authenticated requests
 }
 
+// defaultRequestTimeout is the per-request deadline used when the caller's context has none.
+const defaultRequestTimeout = 30 * time.Second
+
 var debugLogger *log.Logger
 
 func init() {
+	// The debug log is only written with FLOWT_DEBUG=1, so leave no logs
+	// directory behind otherwise, e.g. in the package directories under test
+	if os.Getenv("FLOWT_DEBUG") != "1" {
+		debugLogger = log.New(io.Discard, "[DEBUG] ", log.LstdFlags)
+		return
+	}
+
 	// Create logs directory if it doesn't exist
 	if err := os.MkdirAll("logs", 0755); err != nil {
 		fmt.Printf("Warning: failed to create logs directory: %v\n", err)
@@ -202,9 +252,10 @@ func createHTTPClientWithProxy() *http.Client {
 		transport.Proxy = http.ProxyFromEnvironment
 	}
 
+	// No client-wide Timeout here: deadlines are applied per request from the
+	// caller's context (see requestContext) so that cancellation is possible.
 	return &http.Client{
 		Transport: transport,
-		Timeout:   30 * time.Second,
 	}
 }
 
@@ -240,8 +291,10 @@ func NewClient(accessKeyId, accessKeySecret, regionId string) (*Client, error) {
 	}
 
 	return &Client{
-		sdkClient: sdkClient,
-		useToken:  false,
+		sdkClient:      sdkClient,
+		useToken:       false,
+		requestTimeout: defaultRequestTimeout,
+		retryPolicy:    DefaultRetryPolicy,
 	}, nil
 }
 
@@ -267,24 +320,51 @@ func NewClientWithToken(endpoint, personalAccessToken string) (*Client, error) {
 		endpoint:            endpoint,
 		personalAccessToken: personalAccessToken,
 		useToken:            true,
+		requestTimeout:      defaultRequestTimeout,
+		retryPolicy:         DefaultRetryPolicy,
 	}, nil
 }
 
+// SetRequestTimeout sets the deadline applied to each API request whose context
+// does not already carry one. A zero or negative value disables the default deadline.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// requestContext derives the context for a single HTTP request. A deadline already
+// set by the caller wins; otherwise the client's request timeout is applied.
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
 // ListPipelines retrieves a list of pipelines for a given organization.
 func (c *Client) ListPipelines(organizationId string) ([]Pipeline, error) {
-	return c.ListPipelinesWithStatus(organizationId, nil)
+	return c.ListPipelinesContext(context.Background(), organizationId)
+}
+
+// ListPipelinesContext is like ListPipelines but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelinesContext(ctx context.Context, organizationId string) ([]Pipeline, error) {
+	return c.ListPipelinesWithStatusContext(ctx, organizationId, nil)
 }
 
 // ListPipelinesWithStatus lists pipelines with optional status filtering
 // statusList can be nil for all pipelines, or contain statuses like "RUNNING", "WAITING", etc.
 func (c *Client) ListPipelinesWithStatus(organizationId string, statusList []string) ([]Pipeline, error) {
+	return c.ListPipelinesWithStatusContext(context.Background(), organizationId, statusList)
+}
+
+// ListPipelinesWithStatusContext is like ListPipelinesWithStatus but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelinesWithStatusContext(ctx context.Context, organizationId string, statusList []string) ([]Pipeline, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required for ListPipelines")
 	}
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.listPipelinesWithTokenAndStatus(organizationId, statusList)
+		return c.listPipelinesWithTokenAndStatus(ctx, organizationId, statusList)
 	}
 
 	// Use SDK for AccessKey authentication
@@ -295,6 +375,11 @@ func (c *Client) ListPipelinesWithStatus(organizationId string, statusList []str
 	// TODO: Add pagination handling if the API supports it.
 	// request.NextToken / request.MaxResults might be relevant for pagination.
 
+	// The SDK has no context support; at least honor cancellation before calling it
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	response, err := c.sdkClient.ListPipelines(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pipelines: %w", err)
@@ -384,7 +469,7 @@ func (c *Client) ListPipelinesWithStatus(organizationId string, statusList []str
 
 // listPipelineGroupsWithToken retrieves pipeline groups using personal access token authentication
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/listpipelinegroups
-func (c *Client) listPipelineGroupsWithToken(organizationId string) ([]PipelineGroup, error) {
+func (c *Client) listPipelineGroupsWithToken(ctx context.Context, organizationId string) ([]PipelineGroup, error) {
 	var allGroups []PipelineGroup
 	page := 1
 	perPage := 30 // Maximum per page according to API docs
@@ -393,44 +478,15 @@ func (c *Client) listPipelineGroupsWithToken(organizationId string) ([]PipelineG
 		// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelineGroups
 		path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelineGroups?page=%d&perPage=%d", organizationId, page, perPage)
 
-		// Make the request
-		url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-		req, err := http.NewRequest("GET", url, nil)
+		resp, err := c.do(ctx, getRequest("ListPipelineGroups", path))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to make request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if os.Getenv("FLOWT_DEBUG") == "1" {
-			debugLogger.Printf("ListPipelineGroups URL: %s", url)
-			debugLogger.Printf("Response Status: %d", resp.StatusCode)
-			debugLogger.Printf("Response Headers: %v", resp.Header)
-			debugLogger.Printf("Response Body: %.1000s", string(respBody))
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+			return nil, err
 		}
 
 		// According to API docs, response is a direct array
 		var groupItems []map[string]interface{}
-		if err := json.Unmarshal(respBody, &groupItems); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+		if err := json.Unmarshal(resp.Body, &groupItems); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 		}
 
 		// If no items found, we've reached the end
@@ -489,6 +545,11 @@ func (c *Client) listPipelineGroupsWithToken(organizationId string) ([]PipelineG
 // ListPipelineGroupPipelines retrieves pipelines within a specific pipeline group
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/listpipelinegrouppipelines
 func (c *Client) ListPipelineGroupPipelines(organizationId string, groupId int, options map[string]interface{}) ([]Pipeline, error) {
+	return c.ListPipelineGroupPipelinesContext(context.Background(), organizationId, groupId, options)
+}
+
+// ListPipelineGroupPipelinesContext is like ListPipelineGroupPipelines but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelineGroupPipelinesContext(ctx context.Context, organizationId string, groupId int, options map[string]interface{}) ([]Pipeline, error) {
 	if !c.useToken {
 		return nil, fmt.Errorf("ListPipelineGroupPipelines only supports token-based authentication")
 	}
@@ -527,44 +588,15 @@ func (c *Client) ListPipelineGroupPipelines(organizationId string, groupId int,
 			}
 		}
 
-		// Make the request
-		url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to make request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
+		resp, err := c.do(ctx, getRequest("ListPipelineGroupPipelines", path))
 		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if os.Getenv("FLOWT_DEBUG") == "1" {
-			debugLogger.Printf("ListPipelineGroupPipelines URL: %s", url)
-			debugLogger.Printf("Response Status: %d", resp.StatusCode)
-			debugLogger.Printf("Response Headers: %v", resp.Header)
-			debugLogger.Printf("Response Body: %.1000s", string(respBody))
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+			return nil, err
 		}
 
 		// According to API docs, response is a direct array
 		var pipelineItems []map[string]interface{}
-		if err := json.Unmarshal(respBody, &pipelineItems); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+		if err := json.Unmarshal(resp.Body, &pipelineItems); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 		}
 
 		// If no items found, we've reached the end
@@ -629,85 +661,31 @@ func (c *Client) ListPipelineGroupPipelines(organizationId string, groupId int,
 
 // runPipelineWithToken triggers a pipeline run using personal access token authentication
 // Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/createpipelinerun
-func (c *Client) runPipelineWithToken(organizationId, pipelineIdStr string, params map[string]string) (*PipelineRun, error) {
+func (c *Client) runPipelineWithToken(ctx context.Context, organizationId, pipelineIdStr string, params map[string]string) (*PipelineRun, error) {
 	// Correct API endpoint according to official documentation
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs", organizationId, pipelineIdStr)
 
 	// Prepare request body according to official API documentation
 	// The params should be a JSON string containing pipeline parameters
-	var paramsJSON string
-	if params != nil && len(params) > 0 {
-		// The params map already contains JSON strings for each parameter
-		// We need to construct the final JSON object directly
-		if runningBranchsJSON, ok := params["runningBranchs"]; ok {
-			// runningBranchsJSON is already a JSON string, use it directly
-			paramsJSON = fmt.Sprintf("{\"runningBranchs\": %s}", runningBranchsJSON)
-		} else {
-			// Fallback: marshal the entire params map
-			paramsBytes, err := json.Marshal(params)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal params to JSON: %w", err)
-			}
-			paramsJSON = string(paramsBytes)
-		}
-	} else {
-		// Default empty params
-		paramsJSON = "{}"
+	paramsJSON, err := runParamsJSON(params)
+	if err != nil {
+		return nil, err
 	}
 
 	requestBody := map[string]interface{}{
 		"params": paramsJSON,
 	}
 
-	// Make the request directly to handle string response
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	var reqBody io.Reader
-	if requestBody != nil {
-		jsonBody, err := json.Marshal(requestBody)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		reqBody = bytes.NewBuffer(jsonBody)
-	}
-
-	req, err := http.NewRequest("POST", url, reqBody)
+	// The response is not a JSON object, so go through the raw request pipeline.
+	// Creating a run is not idempotent: it is never retried after it may have reached the server.
+	resp, err := c.do(ctx, &apiRequest{name: "RunPipeline", method: http.MethodPost, path: path, body: requestBody})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("RunPipeline Request URL: %s", url)
-		debugLogger.Printf("RunPipeline Request Method: POST")
-		debugLogger.Printf("RunPipeline Request Headers: %v", req.Header)
-		debugLogger.Printf("RunPipeline Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("RunPipeline Response Headers: %v", resp.Header)
-		debugLogger.Printf("RunPipeline Response Body: %s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	// According to user feedback, the response is a bare number (e.g., "21") representing the run ID
 	// First, try to parse as string directly
-	runID := strings.TrimSpace(string(respBody))
+	runID := strings.TrimSpace(string(resp.Body))
 
 	// Remove quotes if the response is a quoted string
 	if len(runID) >= 2 && runID[0] == '"' && runID[len(runID)-1] == '"' {
@@ -729,9 +707,9 @@ func (c *Client) runPipelineWithToken(organizationId, pipelineIdStr string, para
 			}
 
 			// Only try JSON parsing if the response looks like JSON
-			if len(respBody) > 0 && (respBody[0] == '{' || respBody[0] == '[') {
+			if len(resp.Body) > 0 && (resp.Body[0] == '{' || resp.Body[0] == '[') {
 				var response map[string]interface{}
-				if err := json.Unmarshal(respBody, &response); err == nil {
+				if err := json.Unmarshal(resp.Body, &response); err == nil {
 					// Try to extract run ID from JSON response
 					if data, ok := response["data"]; ok {
 						if runIdFloat, ok := data.(float64); ok {
@@ -782,7 +760,7 @@ func (c *Client) runPipelineWithToken(organizationId, pipelineIdStr string, para
 	}
 
 	if runID == "" {
-		return nil, fmt.Errorf("failed to extract run ID from response. Response body: %s", string(respBody))
+		return nil, fmt.Errorf("failed to extract run ID from response. Response body: %s", string(resp.Body))
 	}
 
 	// Return a minimal PipelineRun object
@@ -812,6 +790,11 @@ func getMapKeys(m map[string]interface{}) []string {
 
 // GetPipelineDetails retrieves details for a specific pipeline.
 func (c *Client) GetPipelineDetails(organizationId string, pipelineId string) (*Pipeline, error) {
+	return c.GetPipelineDetailsContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetPipelineDetailsContext is like GetPipelineDetails but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineDetailsContext(ctx context.Context, organizationId string, pipelineId string) (*Pipeline, error) {
 	// request := devops_rdc.CreateGetPipelineRequest() // Or similar
 	// request.OrgId = organizationId
 	// request.PipelineId = pipelineId
@@ -821,6 +804,11 @@ func (c *Client) GetPipelineDetails(organizationId string, pipelineId string) (*
 
 // RunPipeline triggers a pipeline run using the ExecutePipeline SDK method.
 func (c *Client) RunPipeline(organizationId string, pipelineIdStr string, params map[string]string) (*PipelineRun, error) {
+	return c.RunPipelineContext(context.Background(), organizationId, pipelineIdStr, params)
+}
+
+// RunPipelineContext is like RunPipeline but carries ctx for cancellation and deadlines.
+func (c *Client) RunPipelineContext(ctx context.Context, organizationId string, pipelineIdStr string, params map[string]string) (*PipelineRun, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required")
 	}
@@ -830,7 +818,7 @@ func (c *Client) RunPipeline(organizationId string, pipelineIdStr string, params
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.runPipelineWithToken(organizationId, pipelineIdStr, params)
+		return c.runPipelineWithToken(ctx, organizationId, pipelineIdStr, params)
 	}
 
 	// Use SDK for AccessKey authentication
@@ -853,6 +841,10 @@ func (c *Client) RunPipeline(organizationId string, pipelineIdStr string, params
 	}
 	request.Parameters = strings.Join(paramList, ",") // Example format
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	response, err := c.sdkClient.ExecutePipeline(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute pipeline: %w", err)
@@ -879,6 +871,11 @@ func (c *Client) RunPipeline(organizationId string, pipelineIdStr string, params
 
 // StopPipelineRun stops a pipeline run.
 func (c *Client) StopPipelineRun(organizationId string, pipelineId string, runId string) error {
+	return c.StopPipelineRunContext(context.Background(), organizationId, pipelineId, runId)
+}
+
+// StopPipelineRunContext is like StopPipelineRun but carries ctx for cancellation and deadlines.
+func (c *Client) StopPipelineRunContext(ctx context.Context, organizationId string, pipelineId string, runId string) error {
 	if organizationId == "" {
 		return fmt.Errorf("organizationId is required")
 	}
@@ -891,7 +888,7 @@ func (c *Client) StopPipelineRun(organizationId string, pipelineId string, runId
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.stopPipelineRunWithToken(organizationId, pipelineId, runId)
+		return c.stopPipelineRunWithToken(ctx, organizationId, pipelineId, runId)
 	}
 
 	// TODO: Implement SDK-based method for AccessKey authentication
@@ -900,61 +897,210 @@ func (c *Client) StopPipelineRun(organizationId string, pipelineId string, runId
 
 // stopPipelineRunWithToken stops a pipeline run using personal access token authentication
 // Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/updatepipelinerun
-func (c *Client) stopPipelineRunWithToken(organizationId, pipelineId, runId string) error {
+func (c *Client) stopPipelineRunWithToken(ctx context.Context, organizationId, pipelineId, runId string) error {
 	// API endpoint: PUT https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs/{pipelineRunId}
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s", organizationId, pipelineId, runId)
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	req, err := http.NewRequest("PUT", url, nil)
+	resp, err := c.do(ctx, &apiRequest{name: "StopPipelineRun", method: http.MethodPut, path: path})
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	// According to API documentation, response is a boolean indicating success
+	success, err := parseBoolResponse(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return err
 	}
 
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("StopPipelineRun URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %s", string(respBody))
+	if !success {
+		return fmt.Errorf("failed to stop pipeline run: API returned false")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
+	return nil
+}
 
-	// According to API documentation, response is a boolean indicating success
+// parseBoolResponse parses the boolean that operations such as
+// StopPipelineRun return to tell whether they succeeded
+func parseBoolResponse(body []byte) (bool, error) {
 	var success bool
-	if err := json.Unmarshal(respBody, &success); err != nil {
+	if err := json.Unmarshal(body, &success); err != nil {
 		// If response is not a boolean, try to parse as string "true"/"false"
-		responseStr := strings.TrimSpace(string(respBody))
+		responseStr := strings.TrimSpace(string(body))
 		responseStr = strings.Trim(responseStr, "\"") // Remove quotes if present
 		if responseStr == "true" {
-			success = true
+			return true, nil
 		} else if responseStr == "false" {
-			success = false
-		} else {
-			return fmt.Errorf("failed to parse response as boolean: %w. Response: %s", err, string(respBody))
+			return false, nil
 		}
+		return false, fmt.Errorf("failed to parse response as boolean: %w. Response: %s", err, string(body))
 	}
+	return success, nil
+}
+
+// RetryPipelineJobRun runs a failed or stopped job of a pipeline run again.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/retrypipelinejobrun
+func (c *Client) RetryPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.RetryPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// RetryPipelineJobRunContext is like RetryPipelineJobRun but carries ctx for cancellation and deadlines.
+func (c *Client) RetryPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.pipelineJobRunAction(ctx, "RetryPipelineJobRun", "retry", organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// SkipPipelineJobRun skips a failed or stopped job of a pipeline run, so that
+// the run goes on with the next stage.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/skippipelinejobrun
+func (c *Client) SkipPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.SkipPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// SkipPipelineJobRunContext is like SkipPipelineJobRun but carries ctx for cancellation and deadlines.
+func (c *Client) SkipPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.pipelineJobRunAction(ctx, "SkipPipelineJobRun", "skip", organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// StopPipelineJobRun stops a running job of a pipeline run.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/stoppipelinejobrun
+func (c *Client) StopPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.StopPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// StopPipelineJobRunContext is like StopPipelineJobRun but carries ctx for cancellation and deadlines.
+func (c *Client) StopPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return c.pipelineJobRunAction(ctx, "StopPipelineJobRun", "stop", organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// PassPipelineValidate passes the manual validation a job of a pipeline run
+// waits on, letting the run go on. comment, if not empty, is recorded with
+// the decision.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/passpipelinevalidate
+func (c *Client) PassPipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return c.PassPipelineValidateContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
 
+// PassPipelineValidateContext is like PassPipelineValidate but carries ctx for cancellation and deadlines.
+func (c *Client) PassPipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return c.pipelineValidate(ctx, "PassPipelineValidate", "pass", organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
+
+// RefusePipelineValidate refuses the manual validation a job of a pipeline
+// run waits on, failing the run. comment, if not empty, is recorded with the
+// decision.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/refusepipelinevalidate
+func (c *Client) RefusePipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return c.RefusePipelineValidateContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
+
+// RefusePipelineValidateContext is like RefusePipelineValidate but carries ctx for cancellation and deadlines.
+func (c *Client) RefusePipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return c.pipelineValidate(ctx, "RefusePipelineValidate", "refuse", organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
+
+// pipelineValidate passes ("pass") or refuses ("refuse") a manual validation
+func (c *Client) pipelineValidate(ctx context.Context, name, decision, organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	if !c.useToken {
+		return fmt.Errorf("%s only supports token-based authentication", name)
+	}
+
+	if organizationId == "" || pipelineId == "" || pipelineRunId == "" || jobId == "" {
+		return fmt.Errorf("organizationId, pipelineId, pipelineRunId, and jobId are required")
+	}
+
+	// API endpoint: POST https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/pipelineRuns/{pipelineRunId}/jobs/{jobId}/{decision}
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/pipelineRuns/%s/jobs/%s/%s", organizationId, pipelineId, pipelineRunId, jobId, decision)
+	var body interface{}
+	if comment != "" {
+		body = map[string]string{"comment": comment}
+	}
+	resp, err := c.do(ctx, &apiRequest{name: name, method: http.MethodPost, path: path, body: body})
+	if err != nil {
+		return err
+	}
+
+	success, err := parseBoolResponse(resp.Body)
+	if err != nil {
+		return err
+	}
 	if !success {
-		return fmt.Errorf("failed to stop pipeline run: API returned false")
+		return fmt.Errorf("failed to %s the validation of job %s: API returned false", decision, jobId)
+	}
+	return nil
+}
+
+// pipelineJobRunAction performs one of the actions on a job of a pipeline run
+// ("retry", "skip" or "stop"), which all answer with a boolean
+func (c *Client) pipelineJobRunAction(ctx context.Context, name, action, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	if !c.useToken {
+		return fmt.Errorf("%s only supports token-based authentication", name)
+	}
+
+	if organizationId == "" || pipelineId == "" || pipelineRunId == "" || jobId == "" {
+		return fmt.Errorf("organizationId, pipelineId, pipelineRunId, and jobId are required")
+	}
+
+	// API endpoint: PUT https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/pipelineRuns/{pipelineRunId}/jobs/{jobId}/{action}
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/pipelineRuns/%s/jobs/%s/%s", organizationId, pipelineId, pipelineRunId, jobId, action)
+	resp, err := c.do(ctx, &apiRequest{name: name, method: http.MethodPut, path: path})
+	if err != nil {
+		return err
+	}
+
+	success, err := parseBoolResponse(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("failed to %s job %s: API returned false", action, jobId)
+	}
+	return nil
+}
+
+// ExecutePipelineJobAction executes one of the actions a job of a pipeline
+// run offers in its Actions, such as resuming, rolling back or retrying a
+// machine of a VM deployment. The action is sent as the job listed it: its
+// Data, or its Params when it has no Data, goes along with its Type.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/executepipelinejobrunaction
+func (c *Client) ExecutePipelineJobAction(organizationId, pipelineId, pipelineRunId, jobId string, action JobAction) error {
+	return c.ExecutePipelineJobActionContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, action)
+}
+
+// ExecutePipelineJobActionContext is like ExecutePipelineJobAction but carries ctx for cancellation and deadlines.
+func (c *Client) ExecutePipelineJobActionContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string, action JobAction) error {
+	if !c.useToken {
+		return fmt.Errorf("ExecutePipelineJobAction only supports token-based authentication")
+	}
+
+	if organizationId == "" || pipelineId == "" || pipelineRunId == "" || jobId == "" {
+		return fmt.Errorf("organizationId, pipelineId, pipelineRunId, and jobId are required")
+	}
+	if action.Type == "" {
+		return fmt.Errorf("action type is required")
+	}
+
+	data := action.Data
+	if data == "" && len(action.Params) > 0 {
+		params, err := json.Marshal(action.Params)
+		if err != nil {
+			return fmt.Errorf("failed to encode the params of action %s: %w", action.Type, err)
+		}
+		data = string(params)
+	}
+
+	// API endpoint: POST https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/pipelineRuns/{pipelineRunId}/jobs/{jobId}/operation
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/pipelineRuns/%s/jobs/%s/operation", organizationId, pipelineId, pipelineRunId, jobId)
+	body := map[string]string{"action": action.Type, "data": data}
+	resp, err := c.do(ctx, &apiRequest{name: "ExecutePipelineJobAction", method: http.MethodPost, path: path, body: body})
+	if err != nil {
+		return err
 	}
 
+	success, err := parseBoolResponse(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("failed to execute action %s on job %s: API returned false", action.Type, jobId)
+	}
 	return nil
 }
 
@@ -967,6 +1113,11 @@ type PipelineRunInfo struct {
 // GetLatestPipelineRun retrieves the latest pipeline run information
 // Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/getlatestpipelinerun
 func (c *Client) GetLatestPipelineRun(organizationId, pipelineId string) (*PipelineRun, error) {
+	return c.GetLatestPipelineRunContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetLatestPipelineRunContext is like GetLatestPipelineRun but carries ctx for cancellation and deadlines.
+func (c *Client) GetLatestPipelineRunContext(ctx context.Context, organizationId, pipelineId string) (*PipelineRun, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required")
 	}
@@ -981,7 +1132,7 @@ func (c *Client) GetLatestPipelineRun(organizationId, pipelineId string) (*Pipel
 
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/latestPipelineRun", organizationId, pipelineId)
 
-	response, err := c.makeTokenRequest("GET", path, nil)
+	response, err := c.makeTokenRequest(ctx, "GetLatestPipelineRun", "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest pipeline run: %w", err)
 	}
@@ -1048,6 +1199,11 @@ func (c *Client) GetLatestPipelineRun(organizationId, pipelineId string) (*Pipel
 
 // GetLatestPipelineRunInfo retrieves the latest pipeline run information with repository details
 func (c *Client) GetLatestPipelineRunInfo(organizationId, pipelineId string) (*PipelineRunInfo, error) {
+	return c.GetLatestPipelineRunInfoContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetLatestPipelineRunInfoContext is like GetLatestPipelineRunInfo but carries ctx for cancellation and deadlines.
+func (c *Client) GetLatestPipelineRunInfoContext(ctx context.Context, organizationId, pipelineId string) (*PipelineRunInfo, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required")
 	}
@@ -1062,7 +1218,7 @@ func (c *Client) GetLatestPipelineRunInfo(organizationId, pipelineId string) (*P
 
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/latestPipelineRun", organizationId, pipelineId)
 
-	response, err := c.makeTokenRequest("GET", path, nil)
+	response, err := c.makeTokenRequest(ctx, "GetLatestPipelineRunInfo", "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest pipeline run: %w", err)
 	}
@@ -1204,6 +1360,11 @@ func (c *Client) GetLatestPipelineRunInfo(organizationId, pipelineId string) (*P
 
 // GetPipelineRun retrieves details of a specific pipeline run using GetPipelineInstanceInfo SDK method.
 func (c *Client) GetPipelineRun(organizationId string, pipelineIdStr string, runIdStr string) (*PipelineRun, error) {
+	return c.GetPipelineRunContext(context.Background(), organizationId, pipelineIdStr, runIdStr)
+}
+
+// GetPipelineRunContext is like GetPipelineRun but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineRunContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (*PipelineRun, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required")
 	}
@@ -1216,7 +1377,7 @@ func (c *Client) GetPipelineRun(organizationId string, pipelineIdStr string, run
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.getPipelineRunWithToken(organizationId, pipelineIdStr, runIdStr)
+		return c.getPipelineRunWithToken(ctx, organizationId, pipelineIdStr, runIdStr)
 	}
 
 	// Use SDK for AccessKey authentication
@@ -1231,6 +1392,10 @@ func (c *Client) GetPipelineRun(organizationId string, pipelineIdStr string, run
 	request.PipelineId = requests.NewInteger(int(pipelineIdInt))
 	request.FlowInstanceId = runIdStr // FlowInstanceId is the RunId
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	response, err := c.sdkClient.GetPipelineInstanceInfo(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pipeline instance info: %w", err)
@@ -1305,6 +1470,11 @@ func (c *Client) GetPipelineRun(organizationId string, pipelineIdStr string, run
 // GetPipelineRunDetails retrieves detailed information about a pipeline run including job list
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/getpipelinerun
 func (c *Client) GetPipelineRunDetails(organizationId, pipelineId, pipelineRunId string) (*PipelineRunDetails, error) {
+	return c.GetPipelineRunDetailsContext(context.Background(), organizationId, pipelineId, pipelineRunId)
+}
+
+// GetPipelineRunDetailsContext is like GetPipelineRunDetails but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineRunDetailsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId string) (*PipelineRunDetails, error) {
 	if !c.useToken {
 		return nil, fmt.Errorf("GetPipelineRunDetails only supports token-based authentication")
 	}
@@ -1315,41 +1485,13 @@ func (c *Client) GetPipelineRunDetails(organizationId, pipelineId, pipelineRunId
 
 	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs/{pipelineRunId}
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s", organizationId, pipelineId, pipelineRunId)
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, getRequest("GetPipelineRunDetails", path))
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("GetPipelineRunDetails URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %.1000s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	var responseData map[string]interface{}
-	if err := json.Unmarshal(respBody, &responseData); err != nil {
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
@@ -1462,6 +1604,11 @@ func (c *Client) GetPipelineRunDetails(organizationId, pipelineId, pipelineRunId
 // GetPipelineJobRunLog retrieves logs for a specific job within a pipeline run
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/getpipelinejobrunlog
 func (c *Client) GetPipelineJobRunLog(organizationId, pipelineId, pipelineRunId, jobId string) (string, error) {
+	return c.GetPipelineJobRunLogContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// GetPipelineJobRunLogContext is like GetPipelineJobRunLog but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineJobRunLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) (string, error) {
 	if !c.useToken {
 		return "", fmt.Errorf("GetPipelineJobRunLog only supports token-based authentication")
 	}
@@ -1472,45 +1619,109 @@ func (c *Client) GetPipelineJobRunLog(organizationId, pipelineId, pipelineRunId,
 
 	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs/{pipelineRunId}/job/{jobId}/log
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s/job/%s/log", organizationId, pipelineId, pipelineRunId, jobId)
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	req, err := http.NewRequest("GET", url, nil)
+	resp, err := c.do(ctx, getRequest("GetPipelineJobRunLog", path))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
 
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
+	var responseData map[string]interface{}
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+	// Extract log content from response
+	if content, ok := responseData["content"].(string); ok {
+		return content, nil
+	}
+
+	return "", fmt.Errorf("no log content found in response")
+}
+
+// GetPipelineJobSteps retrieves the steps of a job within a pipeline run.
+// The endpoint is not in the official API reference; callers should fall back
+// to GetPipelineJobRunLog when it answers ErrNotFound.
+func (c *Client) GetPipelineJobSteps(organizationId, pipelineId, pipelineRunId, jobId string) ([]JobStep, error) {
+	return c.GetPipelineJobStepsContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// GetPipelineJobStepsContext is like GetPipelineJobSteps but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineJobStepsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) ([]JobStep, error) {
+	if !c.useToken {
+		return nil, fmt.Errorf("GetPipelineJobSteps only supports token-based authentication")
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	if organizationId == "" || pipelineId == "" || pipelineRunId == "" || jobId == "" {
+		return nil, fmt.Errorf("organizationId, pipelineId, pipelineRunId, and jobId are required")
+	}
+
+	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs/{pipelineRunId}/job/{jobId}/steps
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s/job/%s/steps", organizationId, pipelineId, pipelineRunId, jobId)
+	resp, err := c.do(ctx, getRequest("GetPipelineJobSteps", path))
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("GetPipelineJobRunLog URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %.1000s", string(respBody))
+	var responseData []map[string]interface{}
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	steps := make([]JobStep, 0, len(responseData))
+	for _, stepMap := range responseData {
+		step := JobStep{}
+		switch index := stepMap["stepIndex"].(type) {
+		case string:
+			step.Index = index
+		case float64:
+			step.Index = fmt.Sprintf("%.0f", index)
+		}
+		if name, ok := stepMap["name"].(string); ok {
+			step.Name = name
+		}
+		if status, ok := stepMap["status"].(string); ok {
+			step.Status = status
+		}
+		if startTime, ok := stepMap["startTime"].(float64); ok && startTime > 0 {
+			step.StartTime = time.Unix(int64(startTime)/1000, 0)
+		}
+		if endTime, ok := stepMap["endTime"].(float64); ok && endTime > 0 {
+			step.EndTime = time.Unix(int64(endTime)/1000, 0)
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// GetPipelineJobStepLog retrieves the log of one step of a job within a
+// pipeline run. Like GetPipelineJobSteps, the endpoint is not in the official
+// API reference.
+func (c *Client) GetPipelineJobStepLog(organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error) {
+	return c.GetPipelineJobStepLogContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, stepIndex)
+}
+
+// GetPipelineJobStepLogContext is like GetPipelineJobStepLog but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineJobStepLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error) {
+	if !c.useToken {
+		return "", fmt.Errorf("GetPipelineJobStepLog only supports token-based authentication")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	if organizationId == "" || pipelineId == "" || pipelineRunId == "" || jobId == "" || stepIndex == "" {
+		return "", fmt.Errorf("organizationId, pipelineId, pipelineRunId, jobId, and stepIndex are required")
+	}
+
+	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs/{pipelineRunId}/job/{jobId}/steps/{stepIndex}/log
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s/job/%s/steps/%s/log", organizationId, pipelineId, pipelineRunId, jobId, stepIndex)
+	resp, err := c.do(ctx, getRequest("GetPipelineJobStepLog", path))
+	if err != nil {
+		return "", err
 	}
 
 	var responseData map[string]interface{}
-	if err := json.Unmarshal(respBody, &responseData); err != nil {
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	// Extract log content from response
 	if content, ok := responseData["content"].(string); ok {
 		return content, nil
 	}
@@ -1522,6 +1733,11 @@ func (c *Client) GetPipelineJobRunLog(organizationId, pipelineId, pipelineRunId,
 // This method first gets the pipeline run details to obtain the job list,
 // then fetches logs for each job and concatenates them with job headers.
 func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string, runIdStr string) (string, error) {
+	return c.GetPipelineRunLogsContext(context.Background(), organizationId, pipelineIdStr, runIdStr)
+}
+
+// GetPipelineRunLogsContext is like GetPipelineRunLogs but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineRunLogsContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (string, error) {
 	if !c.useToken {
 		return "", fmt.Errorf("GetPipelineRunLogs only supports token-based authentication")
 	}
@@ -1531,7 +1747,7 @@ func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string,
 	}
 
 	// Step 1: Get pipeline run details to obtain job list
-	runDetails, err := c.GetPipelineRunDetails(organizationId, pipelineIdStr, runIdStr)
+	runDetails, err := c.GetPipelineRunDetailsContext(ctx, organizationId, pipelineIdStr, runIdStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to get pipeline run details: %w", err)
 	}
@@ -1551,6 +1767,9 @@ func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string,
 		}
 
 		for _, job := range stage.Jobs {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
 			jobCount++
 
 			// Add job header with yellow color formatting for tview
@@ -1591,7 +1810,7 @@ func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string,
 					// Continue processing other jobs instead of stopping
 				} else {
 					// Get VM deployment order details
-					deployOrder, err := c.GetVMDeployOrder(organizationId, pipelineIdStr, deployOrderId)
+					deployOrder, err := c.GetVMDeployOrderContext(ctx, organizationId, pipelineIdStr, deployOrderId)
 					if err != nil {
 						allLogs.WriteString(fmt.Sprintf("Error fetching VM deploy order %s: %v\n", deployOrderId, err))
 						allLogs.WriteString("Unable to retrieve deployment details at this time.\n")
@@ -1613,7 +1832,7 @@ func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string,
 								allLogs.WriteString("[yellow]" + strings.Repeat(".", 30) + "[-]\n")
 
 								// Get machine deployment log
-								machineLog, err := c.GetVMDeployMachineLog(organizationId, pipelineIdStr, deployOrderId, machine.MachineSn)
+								machineLog, err := c.GetVMDeployMachineLogContext(ctx, organizationId, pipelineIdStr, deployOrderId, machine.MachineSn)
 								if err != nil {
 									allLogs.WriteString(fmt.Sprintf("Error fetching machine log for %s: %v\n", machine.MachineSn, err))
 								} else {
@@ -1647,7 +1866,7 @@ func (c *Client) GetPipelineRunLogs(organizationId string, pipelineIdStr string,
 			} else {
 				// This is a regular job, use standard job log API
 				jobIdStr := fmt.Sprintf("%d", job.ID)
-				jobLogs, err := c.GetPipelineJobRunLog(organizationId, pipelineIdStr, runIdStr, jobIdStr)
+				jobLogs, err := c.GetPipelineJobRunLogContext(ctx, organizationId, pipelineIdStr, runIdStr, jobIdStr)
 				if err != nil {
 					allLogs.WriteString(fmt.Sprintf("Error fetching logs for job %s: %v\n", jobIdStr, err))
 				} else if jobLogs == "" {
@@ -1739,6 +1958,12 @@ func extractDeployOrderId(resultJSON string) (string, error) {
 	return "", fmt.Errorf("deployOrderId not found in result JSON. Available keys: %v", getMapKeys(result))
 }
 
+// DeployOrderID returns the ID of the VM deploy order started by a job, taken
+// from its GetVMDeployOrder action. Jobs that deploy nothing return an error.
+func DeployOrderID(job Job) (string, error) {
+	return extractDeployOrderIdFromActions(job.Actions)
+}
+
 // extractDeployOrderIdFromActions extracts deployOrderId from job actions array
 // Based on the API response structure where deployOrderId is in actions[].data or actions[].params
 func extractDeployOrderIdFromActions(actions []JobAction) (string, error) {
@@ -1804,6 +2029,11 @@ func extractDeployOrderIdFromActions(actions []JobAction) (string, error) {
 
 // ListPipelineRuns retrieves a list of runs for a specific pipeline.
 func (c *Client) ListPipelineRuns(organizationId string, pipelineId string) ([]PipelineRun, error) {
+	return c.ListPipelineRunsContext(context.Background(), organizationId, pipelineId)
+}
+
+// ListPipelineRunsContext is like ListPipelineRuns but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelineRunsContext(ctx context.Context, organizationId string, pipelineId string) ([]PipelineRun, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required for ListPipelineRuns")
 	}
@@ -1813,7 +2043,7 @@ func (c *Client) ListPipelineRuns(organizationId string, pipelineId string) ([]P
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.listPipelineRunsWithToken(organizationId, pipelineId)
+		return c.listPipelineRunsWithToken(ctx, organizationId, pipelineId)
 	}
 
 	// TODO: Implement SDK-based method for AccessKey authentication
@@ -1821,7 +2051,7 @@ func (c *Client) ListPipelineRuns(organizationId string, pipelineId string) ([]P
 }
 
 // listPipelineRunsWithToken retrieves pipeline runs using personal access token authentication
-func (c *Client) listPipelineRunsWithToken(organizationId, pipelineId string) ([]PipelineRun, error) {
+func (c *Client) listPipelineRunsWithToken(ctx context.Context, organizationId, pipelineId string) ([]PipelineRun, error) {
 	// Use the official ListPipelineRuns API endpoint
 	// Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/listpipelineruns
 	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/runs
@@ -1834,7 +2064,7 @@ func (c *Client) listPipelineRunsWithToken(organizationId, pipelineId string) ([
 
 	for {
 		path := fmt.Sprintf("%s?page=%d&perPage=%d", officialPath, page, perPage)
-		runs, hasMore, err := c.fetchPipelineRunsPage(path)
+		runs, hasMore, err := c.fetchPipelineRunsPage(ctx, path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch pipeline runs page %d: %w", page, err)
 		}
@@ -1852,44 +2082,16 @@ func (c *Client) listPipelineRunsWithToken(organizationId, pipelineId string) ([
 }
 
 // fetchPipelineRunsPage fetches a single page of pipeline runs and returns whether there are more pages
-func (c *Client) fetchPipelineRunsPage(path string) ([]PipelineRun, bool, error) {
-	// Make the request and get raw response
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) fetchPipelineRunsPage(ctx context.Context, path string) ([]PipelineRun, bool, error) {
+	resp, err := c.do(ctx, getRequest("ListPipelineRuns", path))
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, false, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, false, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("Trying pipeline runs endpoint: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %.500s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, false, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, false, err
 	}
 
 	// According to API documentation, response is a direct array
 	var runItems []map[string]interface{}
-	if err := json.Unmarshal(respBody, &runItems); err != nil {
-		return nil, false, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+	if err := json.Unmarshal(resp.Body, &runItems); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 	}
 
 	var pipelineRuns []PipelineRun
@@ -1970,13 +2172,18 @@ func (c *Client) fetchPipelineRunsPage(path string) ([]PipelineRun, bool, error)
 
 // ListPipelineGroups retrieves a list of pipeline groups (projects) for an organization.
 func (c *Client) ListPipelineGroups(organizationId string) ([]PipelineGroup, error) {
+	return c.ListPipelineGroupsContext(context.Background(), organizationId)
+}
+
+// ListPipelineGroupsContext is like ListPipelineGroups but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelineGroupsContext(ctx context.Context, organizationId string) ([]PipelineGroup, error) {
 	if organizationId == "" {
 		return nil, fmt.Errorf("organizationId is required for ListPipelineGroups")
 	}
 
 	// Use different methods based on authentication type
 	if c.useToken {
-		return c.listPipelineGroupsWithToken(organizationId)
+		return c.listPipelineGroupsWithToken(ctx, organizationId)
 	}
 
 	// Use SDK for AccessKey authentication
@@ -1985,6 +2192,10 @@ func (c *Client) ListPipelineGroups(organizationId string) ([]PipelineGroup, err
 	request.OrgId = organizationId
 	// request.PageSize = "100" // Example: Add pagination if needed and supported
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	response, err := c.sdkClient.ListDevopsProjects(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list devops projects (pipeline groups): %w", err)
@@ -2181,78 +2392,34 @@ func (c *Client) ListPipelineGroups(organizationId string) ([]PipelineGroup, err
 	return groups, nil
 }
 
-// makeTokenRequest makes an HTTP request using personal access token authentication
-func (c *Client) makeTokenRequest(method, path string, body interface{}) (map[string]interface{}, error) {
-	if !c.useToken {
-		return nil, fmt.Errorf("client not configured for token-based requests")
-	}
-
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	var reqBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		reqBody = bytes.NewBuffer(jsonBody)
-	}
-
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set correct authentication header for Aliyun DevOps API
-	// Based on official documentation: use x-yunxiao-token header
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
+// makeTokenRequest makes an HTTP request using personal access token
+// authentication; name is the operation it is made for, as in errors and logs
+func (c *Client) makeTokenRequest(ctx context.Context, name, method, path string, body interface{}) (map[string]interface{}, error) {
+	resp, err := c.do(ctx, &apiRequest{
+		name:       name,
+		method:     method,
+		path:       path,
+		body:       body,
+		idempotent: method == http.MethodGet,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("Request URL: %s", url)
-		debugLogger.Printf("Request Method: %s", method)
-		debugLogger.Printf("Request Headers: %v", req.Header)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Headers: %v", resp.Header)
-		debugLogger.Printf("Response Body (first 1000 chars): %.1000s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	// Check if response looks like HTML (common when authentication fails)
-	if len(respBody) > 0 && respBody[0] == '<' {
-		return nil, fmt.Errorf("received HTML response instead of JSON (status %d). This usually indicates authentication failure or wrong endpoint. Response preview: %.200s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	var result map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w. Response body: %.500s", err, string(respBody))
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w. Response body: %.500s", err, string(resp.Body))
 	}
 
 	return result, nil
 }
 
 // listPipelinesWithToken retrieves pipelines using personal access token authentication
-func (c *Client) listPipelinesWithToken(organizationId string) ([]Pipeline, error) {
-	return c.listPipelinesWithTokenAndStatus(organizationId, nil)
+func (c *Client) listPipelinesWithToken(ctx context.Context, organizationId string) ([]Pipeline, error) {
+	return c.listPipelinesWithTokenAndStatus(ctx, organizationId, nil)
 }
 
-func (c *Client) listPipelinesWithTokenAndStatus(organizationId string, statusList []string) ([]Pipeline, error) {
+func (c *Client) listPipelinesWithTokenAndStatus(ctx context.Context, organizationId string, statusList []string) ([]Pipeline, error) {
 	// Based on official Aliyun DevOps API documentation:
 	// https://help.aliyun.com/zh/yunxiao/developer-reference/listpipelines-get-a-list-of-pipelines
 	// GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines
@@ -2273,44 +2440,15 @@ func (c *Client) listPipelinesWithTokenAndStatus(organizationId string, statusLi
 
 		path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines?%s", organizationId, queryParams)
 
-		// Make the request and get raw response
-		url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to make request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
+		resp, err := c.do(ctx, getRequest("ListPipelines", path))
 		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if os.Getenv("FLOWT_DEBUG") == "1" {
-			debugLogger.Printf("Request URL: %s", url)
-			debugLogger.Printf("Response Status: %d", resp.StatusCode)
-			debugLogger.Printf("Response Headers: %v", resp.Header)
-			debugLogger.Printf("Response Body (first 1000 chars): %.1000s", string(respBody))
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+			return nil, err
 		}
 
 		// According to API docs, response is a direct array
 		var pipelineItems []map[string]interface{}
-		if err := json.Unmarshal(respBody, &pipelineItems); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+		if err := json.Unmarshal(resp.Body, &pipelineItems); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 		}
 
 		// If no items found, we've reached the end
@@ -2462,12 +2600,12 @@ func (c *Client) listPipelinesWithTokenAndStatus(organizationId string, statusLi
 }
 
 // getPipelineRunWithToken retrieves pipeline run details using personal access token authentication
-func (c *Client) getPipelineRunWithToken(organizationId, pipelineIdStr, runIdStr string) (*PipelineRun, error) {
+func (c *Client) getPipelineRunWithToken(ctx context.Context, organizationId, pipelineIdStr, runIdStr string) (*PipelineRun, error) {
 	// Based on Aliyun DevOps API pattern, pipeline run details might follow similar structure
 	// This needs to be updated with the correct API endpoint for getting pipeline run details
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/runs/%s", organizationId, pipelineIdStr, runIdStr)
 
-	response, err := c.makeTokenRequest("GET", path, nil)
+	response, err := c.makeTokenRequest(ctx, "GetPipelineRun", "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pipeline run with token: %w", err)
 	}
@@ -2538,6 +2676,11 @@ func (c *Client) getPipelineRunWithToken(organizationId, pipelineIdStr, runIdStr
 // ListPipelineJobHistorys retrieves pipeline job execution history using the correct API endpoint
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/listpipelinejobhistorys
 func (c *Client) ListPipelineJobHistorys(organizationId, pipelineId, category, identifier string, page, perPage int) ([]PipelineRun, error) {
+	return c.ListPipelineJobHistorysContext(context.Background(), organizationId, pipelineId, category, identifier, page, perPage)
+}
+
+// ListPipelineJobHistorysContext is like ListPipelineJobHistorys but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelineJobHistorysContext(ctx context.Context, organizationId, pipelineId, category, identifier string, page, perPage int) ([]PipelineRun, error) {
 	if !c.useToken {
 		return nil, fmt.Errorf("ListPipelineJobHistorys only supports token-based authentication")
 	}
@@ -2565,44 +2708,15 @@ func (c *Client) ListPipelineJobHistorys(organizationId, pipelineId, category, i
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/getComponentsWithoutButtons?pipelineId=%s&category=%s&identifier=%s&perPage=%d&page=%d",
 		organizationId, pipelineId, category, identifier, perPage, page)
 
-	// Make the request
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, getRequest("ListPipelineJobHistorys", path))
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("ListPipelineJobHistorys URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Headers: %v", resp.Header)
-		debugLogger.Printf("Response Body: %.1000s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	// According to documentation, response is a direct array
 	var jobHistoryItems []map[string]interface{}
-	if err := json.Unmarshal(respBody, &jobHistoryItems); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+	if err := json.Unmarshal(resp.Body, &jobHistoryItems); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 	}
 
 	var pipelineRuns []PipelineRun
@@ -2651,6 +2765,11 @@ func (c *Client) ListPipelineJobHistorys(organizationId, pipelineId, category, i
 // GetVMDeployOrder retrieves VM deployment order details
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/getvmdeployorder
 func (c *Client) GetVMDeployOrder(organizationId, pipelineId, deployOrderId string) (*VMDeployOrder, error) {
+	return c.GetVMDeployOrderContext(context.Background(), organizationId, pipelineId, deployOrderId)
+}
+
+// GetVMDeployOrderContext is like GetVMDeployOrder but carries ctx for cancellation and deadlines.
+func (c *Client) GetVMDeployOrderContext(ctx context.Context, organizationId, pipelineId, deployOrderId string) (*VMDeployOrder, error) {
 	if !c.useToken {
 		return nil, fmt.Errorf("GetVMDeployOrder only supports token-based authentication")
 	}
@@ -2661,41 +2780,13 @@ func (c *Client) GetVMDeployOrder(organizationId, pipelineId, deployOrderId stri
 
 	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/deploy/{deployOrderId}
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/deploy/%s", organizationId, pipelineId, deployOrderId)
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	req, err := http.NewRequest("GET", url, nil)
+	resp, err := c.do(ctx, getRequest("GetVMDeployOrder", path))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("GetVMDeployOrder URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %.1000s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	var responseData map[string]interface{}
-	if err := json.Unmarshal(respBody, &responseData); err != nil {
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
@@ -2771,6 +2862,11 @@ func (c *Client) GetVMDeployOrder(organizationId, pipelineId, deployOrderId stri
 // GetVMDeployMachineLog retrieves deployment log for a specific machine
 // Based on: https://help.aliyun.com/zh/yunxiao/developer-reference/getvmdeploymachinelog
 func (c *Client) GetVMDeployMachineLog(organizationId, pipelineId, deployOrderId, machineSn string) (*VMDeployMachineLog, error) {
+	return c.GetVMDeployMachineLogContext(context.Background(), organizationId, pipelineId, deployOrderId, machineSn)
+}
+
+// GetVMDeployMachineLogContext is like GetVMDeployMachineLog but carries ctx for cancellation and deadlines.
+func (c *Client) GetVMDeployMachineLogContext(ctx context.Context, organizationId, pipelineId, deployOrderId, machineSn string) (*VMDeployMachineLog, error) {
 	if !c.useToken {
 		return nil, fmt.Errorf("GetVMDeployMachineLog only supports token-based authentication")
 	}
@@ -2781,41 +2877,13 @@ func (c *Client) GetVMDeployMachineLog(organizationId, pipelineId, deployOrderId
 
 	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}/deploy/{deployOrderId}/machine/{machineSn}/log
 	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s/deploy/%s/machine/%s/log", organizationId, pipelineId, deployOrderId, machineSn)
-	url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := c.do(ctx, getRequest("GetVMDeployMachineLog", path))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if os.Getenv("FLOWT_DEBUG") == "1" {
-		debugLogger.Printf("GetVMDeployMachineLog URL: %s", url)
-		debugLogger.Printf("Response Status: %d", resp.StatusCode)
-		debugLogger.Printf("Response Body: %.1000s", string(respBody))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	var responseData map[string]interface{}
-	if err := json.Unmarshal(respBody, &responseData); err != nil {
+	if err := json.Unmarshal(resp.Body, &responseData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
@@ -2846,23 +2914,33 @@ type PipelinePageCallback func(pipelines []Pipeline, currentPage, totalPages int
 
 // ListPipelinesWithCallback loads pipelines page by page and calls the callback for each page
 func (c *Client) ListPipelinesWithCallback(organizationId string, callback PipelinePageCallback) error {
-	return c.listPipelinesWithCallbackAndStatus(organizationId, nil, callback)
+	return c.ListPipelinesWithCallbackContext(context.Background(), organizationId, callback)
+}
+
+// ListPipelinesWithCallbackContext is like ListPipelinesWithCallback but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelinesWithCallbackContext(ctx context.Context, organizationId string, callback PipelinePageCallback) error {
+	return c.listPipelinesWithCallbackAndStatus(ctx, organizationId, nil, callback)
 }
 
 // ListPipelinesWithStatusAndCallback loads pipelines with status filter page by page and calls the callback for each page
 func (c *Client) ListPipelinesWithStatusAndCallback(organizationId string, statusList []string, callback PipelinePageCallback) error {
-	return c.listPipelinesWithCallbackAndStatus(organizationId, statusList, callback)
+	return c.ListPipelinesWithStatusAndCallbackContext(context.Background(), organizationId, statusList, callback)
+}
+
+// ListPipelinesWithStatusAndCallbackContext is like ListPipelinesWithStatusAndCallback but carries ctx for cancellation and deadlines.
+func (c *Client) ListPipelinesWithStatusAndCallbackContext(ctx context.Context, organizationId string, statusList []string, callback PipelinePageCallback) error {
+	return c.listPipelinesWithCallbackAndStatus(ctx, organizationId, statusList, callback)
 }
 
 // listPipelinesWithCallbackAndStatus implements the core pagination logic with callback
-func (c *Client) listPipelinesWithCallbackAndStatus(organizationId string, statusList []string, callback PipelinePageCallback) error {
+func (c *Client) listPipelinesWithCallbackAndStatus(ctx context.Context, organizationId string, statusList []string, callback PipelinePageCallback) error {
 	if c.useToken {
-		return c.listPipelinesWithTokenAndCallback(organizationId, statusList, callback)
+		return c.listPipelinesWithTokenAndCallback(ctx, organizationId, statusList, callback)
 	}
 
 	// For SDK-based authentication, fall back to loading all at once
 	// This could be enhanced later if needed
-	pipelines, err := c.ListPipelinesWithStatus(organizationId, statusList)
+	pipelines, err := c.ListPipelinesWithStatusContext(ctx, organizationId, statusList)
 	if err != nil {
 		return err
 	}
@@ -2872,7 +2950,7 @@ func (c *Client) listPipelinesWithCallbackAndStatus(organizationId string, statu
 }
 
 // listPipelinesWithTokenAndCallback implements token-based pagination with callback
-func (c *Client) listPipelinesWithTokenAndCallback(organizationId string, statusList []string, callback PipelinePageCallback) error {
+func (c *Client) listPipelinesWithTokenAndCallback(ctx context.Context, organizationId string, statusList []string, callback PipelinePageCallback) error {
 	page := 1
 	perPage := 30   // Maximum per page according to API docs
 	totalPages := 1 // Will be updated from response headers
@@ -2889,44 +2967,15 @@ func (c *Client) listPipelinesWithTokenAndCallback(organizationId string, status
 
 		path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines?%s", organizationId, queryParams)
 
-		// Make the request and get raw response
-		url := fmt.Sprintf("https://%s%s", c.endpoint, path)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("x-yunxiao-token", c.personalAccessToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to make request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
+		resp, err := c.do(ctx, getRequest("ListPipelines", path))
 		if err != nil {
-			return fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if os.Getenv("FLOWT_DEBUG") == "1" {
-			debugLogger.Printf("Request URL: %s", url)
-			debugLogger.Printf("Response Status: %d", resp.StatusCode)
-			debugLogger.Printf("Response Headers: %v", resp.Header)
-			debugLogger.Printf("Response Body (first 1000 chars): %.1000s", string(respBody))
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+			return err
 		}
 
 		// According to API docs, response is a direct array
 		var pipelineItems []map[string]interface{}
-		if err := json.Unmarshal(respBody, &pipelineItems); err != nil {
-			return fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(respBody))
+		if err := json.Unmarshal(resp.Body, &pipelineItems); err != nil {
+			return fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
 		}
 
 		// Update total pages from headers if available
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testToken = "test-token"
+	testOrg   = "org1"
+)
+
+// newTestClient starts an httptest stand-in of the Yunxiao OpenAPI and returns
+// a token client pointed at it with fast retries.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	// Make sure a proxy from the environment never intercepts the local server
+	t.Setenv("http_proxy", "")
+	t.Setenv("https_proxy", "")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-yunxiao-token") != testToken {
+			http.Error(w, `{"errorCode":"Unauthorized","errorMessage":"invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClientWithToken(srv.URL, testToken)
+	if err != nil {
+		t.Fatalf("NewClientWithToken: %v", err)
+	}
+	client.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	})
+	return client
+}
+
+func TestGetRequestRetriedOnServerError(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"content":"hello"}`)
+	})
+
+	log, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3")
+	if err != nil {
+		t.Fatalf("GetPipelineJobRunLog: %v", err)
+	}
+	if log != "hello" {
+		t.Errorf("log = %q, want %q", log, "hello")
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestGetRequestGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 4 {
+		t.Errorf("calls = %d, want 4", got)
+	}
+}
+
+func TestClientErrorNotRetried(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	if _, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestTokenRequestErrorsNameTheOperation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err := client.GetLatestPipelineRunInfo(testOrg, "1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Operation != "GetLatestPipelineRunInfo" {
+		t.Errorf("err = %#v, want an APIError of GetLatestPipelineRunInfo", err)
+	}
+}
+
+func TestRateLimitHonorsRetryAfter(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// Capped by MaxBackoff in the test policy
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"content":"ok"}`)
+	})
+
+	start := time.Now()
+	if _, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3"); err != nil {
+		t.Fatalf("GetPipelineJobRunLog: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("retried after %s, expected to wait for Retry-After capped at 20ms", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestRunPipelineNotRetriedOnServerError(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if _, err := client.RunPipeline(testOrg, "42", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1: a run must never be created twice", got)
+	}
+}
+
+func TestRunPipelineRetriedWhenRateLimited(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/oapi/v1/flow/organizations/org1/pipelines/42/runs" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body["params"] != `{"runningBranchs":{"repo":"main"}}` {
+			t.Errorf("params = %q", body["params"])
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		// CreatePipelineRun answers with the bare run ID
+		fmt.Fprint(w, "21")
+	})
+
+	run, err := client.RunPipeline(testOrg, "42", map[string]string{"runningBranchs": `{"repo":"main"}`})
+	if err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+	if run.RunID != "21" || run.PipelineID != "42" {
+		t.Errorf("run = %+v, want RunID 21 and PipelineID 42", run)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestRunPipelineRequestBody(t *testing.T) {
+	var params string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		params = body["params"]
+		fmt.Fprint(w, "21")
+	})
+
+	runParams, err := RunParams(map[string]string{"https://example.com/order.git": "release/1.8"}, map[string]string{"DEPLOY_ENV": "prod"})
+	if err != nil {
+		t.Fatalf("RunParams: %v", err)
+	}
+	runParams["comment"] = `{"ticket":42}`
+	if _, err := client.RunPipeline(testOrg, "42", runParams); err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+
+	// As documented for CreatePipelineRun: branches and variables are objects,
+	// anything else a string
+	want := `{"comment":"{\"ticket\":42}","envs":{"DEPLOY_ENV":"prod"},"runningBranchs":{"https://example.com/order.git":"release/1.8"}}`
+	if params != want {
+		t.Errorf("params = %s\nwant     %s", params, want)
+	}
+}
+
+func TestStopPipelineRunNotRetriedOnServerError(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if err := client.StopPipelineRun(testOrg, "42", "21"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestListPipelineRunsFollowsPagination(t *testing.T) {
+	const perPage = 30
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		count := perPage
+		if page == 2 {
+			count = 5
+		}
+		var items []map[string]interface{}
+		for i := 0; i < count; i++ {
+			items = append(items, map[string]interface{}{
+				"pipelineRunId": (page-1)*perPage + i + 1,
+				"pipelineId":    42,
+				"status":        "SUCCESS",
+				"triggerMode":   1,
+			})
+		}
+		w.Header().Set("x-total-pages", "2")
+		w.Header().Set("x-page", strconv.Itoa(page))
+		json.NewEncoder(w).Encode(items)
+	})
+
+	runs, err := client.ListPipelineRuns(testOrg, "42")
+	if err != nil {
+		t.Fatalf("ListPipelineRuns: %v", err)
+	}
+	if len(runs) != perPage+5 {
+		t.Fatalf("len(runs) = %d, want %d", len(runs), perPage+5)
+	}
+	if runs[0].RunID != "1" || runs[len(runs)-1].RunID != "35" {
+		t.Errorf("unexpected run IDs %s..%s", runs[0].RunID, runs[len(runs)-1].RunID)
+	}
+	if runs[0].TriggerMode != "MANUAL" {
+		t.Errorf("TriggerMode = %q, want MANUAL", runs[0].TriggerMode)
+	}
+}
+
+func TestCancelledContextStopsRetrying(t *testing.T) {
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Second})
+
+	_, err := client.GetPipelineJobRunLogContext(ctx, testOrg, "1", "2", "3")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestRequestTimeoutIsRetried(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, `{"content":"late"}`)
+	})
+	client.SetRequestTimeout(50 * time.Millisecond)
+
+	log, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3")
+	if err != nil {
+		t.Fatalf("GetPipelineJobRunLog: %v", err)
+	}
+	if log != "late" {
+		t.Errorf("log = %q, want %q", log, "late")
+	}
+}
+
+func TestMissingTokenRejected(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be reached")
+	})
+	client.personalAccessToken = "wrong"
+
+	_, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want a 401 failure", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"garbage", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxRefPages bounds how many pages of branches or tags are fetched, so that
+// a server that keeps answering cannot keep the listing going forever
+const maxRefPages = 100
+
+// RepositoryRef is a branch or a tag of a code repository
+type RepositoryRef struct {
+	Name     string `json:"name"`
+	CommitID string `json:"commitId"` // Commit the ref points at
+}
+
+// RepositoryPath returns the full path of a repository URL, which Codeup
+// accepts in place of a repository ID, e.g. "demo/order-service" for both
+// https://codeup.aliyun.com/demo/order-service.git and
+// git@codeup.aliyun.com:demo/order-service.git
+func RepositoryPath(repoURL string) (string, error) {
+	path := ""
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		path = u.Path
+	} else if i := strings.Index(repoURL, ":"); i >= 0 && strings.Contains(repoURL[:i], "@") {
+		path = repoURL[i+1:] // scp-like SSH URL
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return "", fmt.Errorf("%q is not a repository URL", repoURL)
+	}
+	return path, nil
+}
+
+// ListRepositoryBranches retrieves the branches of the Codeup repository at a
+// repository URL, as found in PipelineRunInfo.RepositoryURLs.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/listbranches
+func (c *Client) ListRepositoryBranches(organizationId, repoURL string) ([]RepositoryRef, error) {
+	return c.ListRepositoryBranchesContext(context.Background(), organizationId, repoURL)
+}
+
+// ListRepositoryBranchesContext is like ListRepositoryBranches but carries ctx for cancellation and deadlines.
+func (c *Client) ListRepositoryBranchesContext(ctx context.Context, organizationId, repoURL string) ([]RepositoryRef, error) {
+	return c.listRepositoryRefs(ctx, "ListRepositoryBranches", "branches", organizationId, repoURL)
+}
+
+// ListRepositoryTags retrieves the tags of the Codeup repository at a
+// repository URL.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/listtags
+func (c *Client) ListRepositoryTags(organizationId, repoURL string) ([]RepositoryRef, error) {
+	return c.ListRepositoryTagsContext(context.Background(), organizationId, repoURL)
+}
+
+// ListRepositoryTagsContext is like ListRepositoryTags but carries ctx for cancellation and deadlines.
+func (c *Client) ListRepositoryTagsContext(ctx context.Context, organizationId, repoURL string) ([]RepositoryRef, error) {
+	return c.listRepositoryRefs(ctx, "ListRepositoryTags", "tags", organizationId, repoURL)
+}
+
+// listRepositoryRefs pages through the branches or the tags of a repository
+func (c *Client) listRepositoryRefs(ctx context.Context, name, kind, organizationId, repoURL string) ([]RepositoryRef, error) {
+	if !c.useToken {
+		return nil, fmt.Errorf("%s only supports token-based authentication", name)
+	}
+	if organizationId == "" {
+		return nil, fmt.Errorf("organizationId is required")
+	}
+	repoPath, err := RepositoryPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []RepositoryRef
+	perPage := 30
+	for page := 1; page <= maxRefPages; page++ {
+		// API endpoint: GET https://{domain}/oapi/v1/codeup/organizations/{organizationId}/repositories/{repositoryId}/branches
+		// The repository ID may be the URL-encoded full path of the repository
+		path := fmt.Sprintf("/oapi/v1/codeup/organizations/%s/repositories/%s/%s?page=%d&perPage=%d",
+			organizationId, url.PathEscape(repoPath), kind, page, perPage)
+		resp, err := c.do(ctx, getRequest(name, path))
+		if err != nil {
+			return nil, err
+		}
+
+		var items []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+		if err := json.Unmarshal(resp.Body, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response as array: %w. Response body: %.500s", err, string(resp.Body))
+		}
+		for _, item := range items {
+			if item.Name != "" {
+				refs = append(refs, RepositoryRef{Name: item.Name, CommitID: item.Commit.ID})
+			}
+		}
+
+		// The last page is the one x-total-pages names, or else a short one
+		if len(items) == 0 {
+			break
+		}
+		if totalPages, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("x-total-pages"))); err == nil {
+			if page >= totalPages {
+				break
+			}
+		} else if len(items) < perPage {
+			break
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRepositoryPath(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://codeup.aliyun.com/demo/order-service.git", "demo/order-service"},
+		{"https://codeup.aliyun.com/60d54f3daccf2bbd6659f3ad/backend/api", "60d54f3daccf2bbd6659f3ad/backend/api"},
+		{"git@codeup.aliyun.com:demo/order-service.git", "demo/order-service"},
+		{"https://codeup.aliyun.com/", ""},
+		{"order-service", ""},
+	}
+	for _, tt := range tests {
+		got, err := RepositoryPath(tt.url)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("RepositoryPath(%q) = %q, want an error", tt.url, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("RepositoryPath(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestListRepositoryBranchesStopsPaging(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		totalPages string
+		pages      int // Pages with a full page of branches, then empty ones
+		want       int // Requests made
+	}{
+		{"total pages", "2", 5, 2},
+		{"padded header", " 2 ", 5, 2},
+		{"malformed header, empty page", "two", 3, 4},
+		{"pages past the total", "0", 5, 1},
+		{"endless pages", "", 1000, maxRefPages},
+	} {
+		requests := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if tt.totalPages != "" {
+				w.Header().Set("x-total-pages", tt.totalPages)
+			}
+			if requests > tt.pages {
+				fmt.Fprint(w, "[]")
+				return
+			}
+			fmt.Fprint(w, "[")
+			for i := 0; i < 30; i++ {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"name":"b%d-%d"}`, requests, i)
+			}
+			fmt.Fprint(w, "]")
+		})
+		if _, err := client.ListRepositoryBranches(testOrg, "https://codeup.aliyun.com/demo/app.git"); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if requests != tt.want {
+			t.Errorf("%s: %d requests, want %d", tt.name, requests, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PipelineSource is a code repository a pipeline builds
+type PipelineSource struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`   // e.g., codeup, gitlab
+	Repo   string `json:"repo"`   // Repository URL, the key of runningBranchs
+	Branch string `json:"branch"` // Branch built by default
+}
+
+// PipelineVariable is a variable declared in a pipeline's definition
+type PipelineVariable struct {
+	Name        string `json:"name" yaml:"name"`
+	Value       string `json:"value" yaml:"value"` // Default value
+	Description string `json:"description" yaml:"description"`
+	Runtime     bool   `json:"runtime" yaml:"runtime"` // Whether it may be set when a run is started
+	Secret      bool   `json:"isEncrypted" yaml:"isEncrypted"`
+}
+
+// PipelineDefinition is a pipeline as it is configured: the repositories it
+// builds and the variables it declares
+type PipelineDefinition struct {
+	PipelineID string             `json:"pipelineId"`
+	Name       string             `json:"name"`
+	Sources    []PipelineSource   `json:"sources"`
+	Variables  []PipelineVariable `json:"variables"`
+}
+
+// RuntimeVariables returns the variables that may be set when a run is started
+func (d *PipelineDefinition) RuntimeVariables() []PipelineVariable {
+	var vars []PipelineVariable
+	for _, v := range d.Variables {
+		if v.Runtime {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// GetPipeline retrieves the definition of a pipeline: its sources and the
+// variables declared in its flow YAML.
+// Based on official API: https://help.aliyun.com/zh/yunxiao/developer-reference/getpipeline
+func (c *Client) GetPipeline(organizationId, pipelineId string) (*PipelineDefinition, error) {
+	return c.GetPipelineContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetPipelineContext is like GetPipeline but carries ctx for cancellation and deadlines.
+func (c *Client) GetPipelineContext(ctx context.Context, organizationId, pipelineId string) (*PipelineDefinition, error) {
+	if !c.useToken {
+		return nil, fmt.Errorf("GetPipeline only supports token-based authentication")
+	}
+	if organizationId == "" || pipelineId == "" {
+		return nil, fmt.Errorf("organizationId and pipelineId are required")
+	}
+
+	// API endpoint: GET https://{domain}/oapi/v1/flow/organizations/{organizationId}/pipelines/{pipelineId}
+	path := fmt.Sprintf("/oapi/v1/flow/organizations/%s/pipelines/%s", organizationId, pipelineId)
+	resp, err := c.do(ctx, getRequest("GetPipeline", path))
+	if err != nil {
+		return nil, err
+	}
+	definition, err := parsePipelineDefinition(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	definition.PipelineID = pipelineId
+	return definition, nil
+}
+
+// parsePipelineDefinition parses a GetPipeline response. The repositories are
+// listed in pipelineConfig.sources; the variables are declared in the flow
+// YAML of pipelineConfig.flow:
+//
+//	variables:
+//	  - name: DEPLOY_ENV
+//	    value: staging
+//	    description: Environment to deploy to
+//	    runtime: true
+func parsePipelineDefinition(body []byte) (*PipelineDefinition, error) {
+	var response struct {
+		Name           string `json:"name"`
+		PipelineConfig struct {
+			Flow    string `json:"flow"`
+			Sources []struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+				Data struct {
+					Repo   string `json:"repo"`
+					Branch string `json:"branch"`
+				} `json:"data"`
+			} `json:"sources"`
+		} `json:"pipelineConfig"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	definition := &PipelineDefinition{Name: response.Name}
+	for _, source := range response.PipelineConfig.Sources {
+		if source.Data.Repo == "" {
+			continue
+		}
+		definition.Sources = append(definition.Sources, PipelineSource{
+			Name:   source.Name,
+			Type:   source.Type,
+			Repo:   source.Data.Repo,
+			Branch: source.Data.Branch,
+		})
+	}
+
+	if response.PipelineConfig.Flow != "" {
+		var flow struct {
+			Variables []PipelineVariable `yaml:"variables"`
+		}
+		if err := yaml.Unmarshal([]byte(response.PipelineConfig.Flow), &flow); err != nil {
+			return nil, fmt.Errorf("failed to parse the flow YAML of pipeline %s: %w", response.Name, err)
+		}
+		definition.Variables = flow.Variables
+	}
+	return definition, nil
+}
+
+// RunParams builds the parameters of RunPipeline from the branch to build in
+// each repository and the values of runtime variables. Either may be empty.
+func RunParams(branches, variables map[string]string) (map[string]string, error) {
+	params := make(map[string]string)
+	if len(branches) > 0 {
+		runningBranchs, err := json.Marshal(branches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode branches: %w", err)
+		}
+		params["runningBranchs"] = string(runningBranchs)
+	}
+	if len(variables) > 0 {
+		envs, err := json.Marshal(variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode variables: %w", err)
+		}
+		params["envs"] = string(envs)
+	}
+	return params, nil
+}
+
+// RunSources returns the repositories a run of a pipeline builds and the
+// branch built in each by default, so that a pipeline builds the same
+// repositories however its run is started. The repositories are those of the
+// definition, or those of the latest run if the definition lists none; their
+// branches are those of the latest run, then those of the definition, then
+// master. Either argument may be nil.
+func RunSources(definition *PipelineDefinition, latest *PipelineRunInfo) []PipelineSource {
+	var latestBranches map[string]string
+	if latest != nil {
+		latestBranches = latest.RepositoryURLs
+	}
+
+	var sources []PipelineSource
+	if definition != nil && len(definition.Sources) > 0 {
+		for _, source := range definition.Sources {
+			if branch := latestBranches[source.Repo]; branch != "" {
+				source.Branch = branch
+			}
+			sources = append(sources, source)
+		}
+	} else {
+		for repo, branch := range latestBranches {
+			sources = append(sources, PipelineSource{Repo: repo, Branch: branch})
+		}
+		sort.Slice(sources, func(i, j int) bool { return sources[i].Repo < sources[j].Repo })
+	}
+	for i := range sources {
+		if sources[i].Branch == "" {
+			sources[i].Branch = "master"
+		}
+	}
+	return sources
+}
+
+// LoadRunSources loads the definition and the latest run of a pipeline and
+// returns its RunSources, along with the definition or nil if it could not be
+// loaded. A pipeline whose definition and runs cannot be loaded has no sources.
+func LoadRunSources(ctx context.Context, service PipelineService, organizationId, pipelineId string) ([]PipelineSource, *PipelineDefinition) {
+	definition, err := service.GetPipelineContext(ctx, organizationId, pipelineId)
+	if err != nil {
+		definition = nil // Fall back to the repositories of the latest run
+	}
+	latest, err := service.GetLatestPipelineRunInfoContext(ctx, organizationId, pipelineId)
+	if err != nil {
+		latest = nil
+	}
+	return RunSources(definition, latest), definition
+}
+
+// objectRunParams are the parameters of RunPipeline that the API documents as
+// JSON objects rather than strings:
+//
+//	{"runningBranchs": {"<repo url>": "<branch>"}, "envs": {"<name>": "<value>"}}
+var objectRunParams = map[string]bool{"runningBranchs": true, "envs": true}
+
+// runParamsJSON encodes the parameters of RunPipeline as the JSON document the
+// API expects. runningBranchs and envs, as built by RunParams, are embedded as
+// objects; any other parameter is a string, even if it looks like JSON.
+func runParamsJSON(params map[string]string) (string, error) {
+	doc := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		if objectRunParams[key] {
+			doc[key] = json.RawMessage(value)
+		} else {
+			doc[key] = value
+		}
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal params to JSON: %w", err)
+	}
+	return string(encoded), nil
+}
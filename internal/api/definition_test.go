@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePipelineDefinition(t *testing.T) {
+	body := []byte(`{
+		"name": "release",
+		"pipelineConfig": {
+			"flow": "variables:\n  - name: DEPLOY_ENV\n    value: staging\n    runtime: true\n  - name: REGISTRY\n    value: registry.example.com\n",
+			"sources": [
+				{"name": "order-service", "type": "codeup", "data": {"repo": "https://example.com/order.git", "branch": "master"}},
+				{"name": "artifact", "type": "packages", "data": {}}
+			]
+		}
+	}`)
+	d, err := parsePipelineDefinition(body)
+	if err != nil {
+		t.Fatalf("parsePipelineDefinition: %v", err)
+	}
+	if len(d.Sources) != 1 || d.Sources[0].Repo != "https://example.com/order.git" || d.Sources[0].Branch != "master" {
+		t.Errorf("sources = %+v", d.Sources)
+	}
+	runtime := d.RuntimeVariables()
+	if len(d.Variables) != 2 || len(runtime) != 1 || runtime[0].Name != "DEPLOY_ENV" || runtime[0].Value != "staging" {
+		t.Errorf("variables = %+v", d.Variables)
+	}
+}
+
+func TestRunSources(t *testing.T) {
+	definition := &PipelineDefinition{Sources: []PipelineSource{
+		{Name: "order", Repo: "https://example.com/order.git", Branch: "develop"},
+		{Repo: "https://example.com/payment.git"},
+	}}
+	latest := &PipelineRunInfo{RepositoryURLs: map[string]string{
+		"https://example.com/order.git":  "release/1.8",
+		"https://example.com/legacy.git": "main",
+	}}
+
+	// The definition names the repositories, the latest run their branches
+	sources := RunSources(definition, latest)
+	if len(sources) != 2 || sources[0].Name != "order" || sources[0].Branch != "release/1.8" || sources[1].Branch != "master" {
+		t.Errorf("sources = %+v", sources)
+	}
+	if sources := RunSources(definition, nil); sources[0].Branch != "develop" {
+		t.Errorf("without runs: sources = %+v", sources)
+	}
+	sources = RunSources(nil, latest)
+	if len(sources) != 2 || sources[0].Repo != "https://example.com/legacy.git" || sources[1].Branch != "release/1.8" {
+		t.Errorf("without a definition: sources = %+v", sources)
+	}
+	if sources := RunSources(nil, nil); len(sources) != 0 {
+		t.Errorf("without anything: sources = %+v", sources)
+	}
+}
+
+func TestRunParamsJSON(t *testing.T) {
+	params, err := RunParams(map[string]string{"https://example.com/order.git": "release/1.8"}, map[string]string{"DEPLOY_ENV": "prod"})
+	if err != nil {
+		t.Fatalf("RunParams: %v", err)
+	}
+	params["comment"] = "hotfix"
+	encoded, err := runParamsJSON(params)
+	if err != nil {
+		t.Fatalf("runParamsJSON: %v", err)
+	}
+
+	// Branches and variables are objects, anything else stays a string
+	var doc struct {
+		RunningBranchs map[string]string `json:"runningBranchs"`
+		Envs           map[string]string `json:"envs"`
+		Comment        string            `json:"comment"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &doc); err != nil {
+		t.Fatalf("params %s: %v", encoded, err)
+	}
+	if doc.RunningBranchs["https://example.com/order.git"] != "release/1.8" || doc.Envs["DEPLOY_ENV"] != "prod" || doc.Comment != "hotfix" {
+		t.Errorf("params = %s", encoded)
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors describing the kind of a Yunxiao API failure. An *APIError
+// matches exactly one of them with errors.Is, e.g.
+//
+//	if errors.Is(err, api.ErrNotFound) { ... }
+var (
+	ErrUnauthorized   = errors.New("authentication failed")
+	ErrForbidden      = errors.New("permission denied")
+	ErrNotFound       = errors.New("not found")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrBadRequest     = errors.New("bad request")
+	ErrServer         = errors.New("server error")
+	ErrUnexpectedHTML = errors.New("unexpected HTML response")
+)
+
+// APIError is returned for every request the Yunxiao OpenAPI rejected or
+// answered with something that is not an API response. Use errors.As to
+// inspect the details and errors.Is with the sentinels above to branch on the
+// kind of failure.
+type APIError struct {
+	Kind       error  // One of the Err* sentinels
+	StatusCode int    // HTTP status code
+	Code       string // Yunxiao error code from the response body, if any
+	Message    string // Yunxiao error message from the response body, if any
+	RequestID  string // Request ID from the response headers or body, if any
+	Operation  string // Operation name, e.g. "ListPipelineRuns"
+	Method     string // HTTP method
+	Endpoint   string // Request path without the query string
+	Body       string // Raw response body, truncated
+}
+
+// maxErrorBodyLength bounds the raw response body kept in an APIError.
+const maxErrorBodyLength = 1000
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.Kind == ErrUnexpectedHTML {
+		fmt.Fprintf(&b, "%s %s: received HTML response instead of JSON (status %d)", e.Method, e.Endpoint, e.StatusCode)
+	} else {
+		fmt.Fprintf(&b, "%s %s: API request failed with status %d", e.Method, e.Endpoint, e.StatusCode)
+	}
+
+	switch {
+	case e.Code != "" && e.Message != "":
+		fmt.Fprintf(&b, ": %s: %s", e.Code, e.Message)
+	case e.Message != "":
+		fmt.Fprintf(&b, ": %s", e.Message)
+	case e.Code != "":
+		fmt.Fprintf(&b, ": %s", e.Code)
+	case e.Body != "" && e.Kind != ErrUnexpectedHTML:
+		fmt.Fprintf(&b, ": %.200s", e.Body)
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request ID %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+// Unwrap makes errors.Is match the error's kind.
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// Hint returns a short suggestion on how the user may fix the failure.
+func (e *APIError) Hint() string {
+	switch e.Kind {
+	case ErrUnauthorized:
+		return "Check that personal_access_token is valid and has not expired."
+	case ErrForbidden:
+		return "The token has no permission for this resource. Check its scopes and your role in the organization."
+	case ErrNotFound:
+		return "Check organization_id and that the pipeline or run still exists."
+	case ErrRateLimited:
+		return "Too many requests. Wait a moment and try again."
+	case ErrUnexpectedHTML:
+		return "This usually means the endpoint setting is wrong or authentication failed at a gateway."
+	case ErrServer:
+		return "Yunxiao reported an internal error. Try again later."
+	default:
+		return ""
+	}
+}
+
+// newAPIError builds an APIError from a response that was not a successful API response.
+func newAPIError(req *apiRequest, resp *apiResponse) *APIError {
+	endpoint := req.path
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+
+	body := string(resp.Body)
+	if len(body) > maxErrorBodyLength {
+		body = body[:maxErrorBodyLength]
+	}
+
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Operation:  req.name,
+		Method:     req.method,
+		Endpoint:   endpoint,
+		Body:       body,
+		RequestID:  firstHeader(resp.Header, "x-acs-request-id", "x-request-id", "x-yunxiao-request-id"),
+	}
+
+	html := looksLikeHTML(resp.Body)
+	if !html {
+		e.parseBody(resp.Body)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		e.Kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		e.Kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		e.Kind = ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+	case resp.StatusCode >= 500:
+		e.Kind = ErrServer
+	case html:
+		e.Kind = ErrUnexpectedHTML
+	default:
+		e.Kind = ErrBadRequest
+	}
+	return e
+}
+
+// parseBody extracts the Yunxiao error code, message and request ID from a
+// JSON error body such as {"errorCode": "...", "errorMessage": "...", "requestId": "..."}.
+func (e *APIError) parseBody(body []byte) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return
+	}
+	e.Code = firstField(fields, "errorCode", "code")
+	e.Message = firstField(fields, "errorMessage", "message", "errorMsg")
+	if e.RequestID == "" {
+		e.RequestID = firstField(fields, "requestId", "traceId")
+	}
+}
+
+// looksLikeHTML reports whether a response body is an HTML page, which the
+// gateway serves for unknown hosts and some authentication failures.
+func looksLikeHTML(body []byte) bool {
+	trimmed := strings.TrimSpace(string(body))
+	return strings.HasPrefix(trimmed, "<")
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func firstField(fields map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch value := fields[name].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", value)
+		}
+	}
+	return ""
+}
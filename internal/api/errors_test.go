@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAPIErrorFromJSONBody(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-acs-request-id", "req-123")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorCode":"PipelineNotExist","errorMessage":"pipeline 42 does not exist"}`)
+	})
+
+	_, err := client.GetPipelineRunDetails(testOrg, "42", "7")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("errors.Is(err, ErrNotFound) = false for %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("errors.As(err, *APIError) = false for %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound ||
+		apiErr.Code != "PipelineNotExist" ||
+		apiErr.Message != "pipeline 42 does not exist" ||
+		apiErr.RequestID != "req-123" ||
+		apiErr.Method != http.MethodGet ||
+		apiErr.Endpoint != "/oapi/v1/flow/organizations/org1/pipelines/42/runs/7" {
+		t.Errorf("unexpected error details: %+v", apiErr)
+	}
+	if !strings.Contains(err.Error(), "request ID req-123") {
+		t.Errorf("error message %q lacks the request ID", err)
+	}
+}
+
+func TestAPIErrorKinds(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusUnauthorized, `{"errorCode":"InvalidToken"}`, ErrUnauthorized},
+		{http.StatusForbidden, `{"errorCode":"Forbidden.NoPermission","requestId":"abc"}`, ErrForbidden},
+		{http.StatusBadRequest, `{"errorMessage":"invalid branch"}`, ErrBadRequest},
+		{http.StatusOK, "<html><body>Login</body></html>", ErrUnexpectedHTML},
+		{http.StatusBadRequest, "<!DOCTYPE html><html></html>", ErrUnexpectedHTML},
+		{http.StatusBadGateway, "<html>Bad Gateway</html>", ErrServer},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", tt.status, tt.want), func(t *testing.T) {
+			var calls int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+			_, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want kind %v", err, tt.want)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || (apiErr.Hint() == "" && tt.want != ErrBadRequest) {
+				t.Errorf("expected an *APIError with a hint, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUnexpectedHTMLNotRetried(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, "<html></html>")
+	})
+
+	if _, err := client.GetPipelineJobRunLog(testOrg, "1", "2", "3"); !errors.Is(err, ErrUnexpectedHTML) {
+		t.Fatalf("err = %v, want ErrUnexpectedHTML", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestRateLimitedErrorAfterRetries(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := client.ListPipelineRuns(testOrg, "42")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+}
@@ -0,0 +1,50 @@
+package fake
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is a manually advanced clock for deterministic tests: pass its Now to
+// SetClock and move it on with Advance, or with Sleep where the code under
+// test waits between polls.
+type Clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewClock returns a clock standing at 2024-05-01 10:00 UTC.
+func NewClock() *Clock {
+	return &Clock{t: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the time the clock stands at.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Advance moves the clock on by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// Sleep advances the clock by d instead of waiting, as the Sleep option of
+// runwatch.Watch and plan.Executor.
+func (c *Clock) Sleep(_ context.Context, d time.Duration) error {
+	c.Advance(d)
+	return nil
+}
+
+// NewWithClock returns an empty service simulating its runs against a new
+// Clock, and the clock.
+func NewWithClock() (*Service, *Clock) {
+	c := NewClock()
+	s := New()
+	s.SetClock(c.Now)
+	return s, c
+}
@@ -0,0 +1,147 @@
+package fake
+
+import (
+	"fmt"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+// DemoOrganizationID is the organization ID used with NewDemo. The fake
+// accepts any organization ID; this one is merely shown in the UI.
+const DemoOrganizationID = "demo-org"
+
+// NewDemo returns a service seeded with a handful of groups, pipelines and run
+// history, including a run that is in progress, so that every view of the UI
+// has something to show without credentials.
+func NewDemo() *Service {
+	s := New()
+	now := s.now()
+
+	backend := s.AddGroup("Backend Services")
+	frontend := s.AddGroup("Frontend")
+	ops := s.AddGroup("Operations")
+
+	build := func(name string, d time.Duration) JobSpec {
+		return JobSpec{
+			Name:     name,
+			Duration: d,
+			Steps: []StepSpec{
+				{Name: "Checkout", Log: []string{"Cloning repository", "Checking out branch"}},
+				{Name: "Build", Log: []string{
+					"Restoring build cache",
+					"Resolving dependencies",
+					"Compiling sources",
+					"Compiled 248 files",
+					"Packaging artifact",
+				}},
+				{Name: "Upload artifact", Log: []string{"Uploading artifact to the artifact repository"}},
+			},
+		}
+	}
+	test := func(name string, d time.Duration) JobSpec {
+		lines := []string{"Starting test containers"}
+		for i := 1; i <= 12; i++ {
+			lines = append(lines, fmt.Sprintf("--- PASS: TestSuite/case_%02d (0.%02ds)", i, i*7%100))
+		}
+		lines = append(lines, "ok  \tall packages passed")
+		return JobSpec{Name: name, Duration: d, Log: lines}
+	}
+	deploy := func(name string, d time.Duration, machines ...string) JobSpec {
+		return JobSpec{
+			Name:     name,
+			Duration: d,
+			Deploy:   &DeploySpec{Machines: machines, Batches: 2},
+		}
+	}
+
+	service := func(name string, groupID string) string {
+		repo := fmt.Sprintf("https://codeup.aliyun.com/demo/%s.git", name)
+		p := s.AddPipeline(name, []StageSpec{
+			{Name: "Build", Jobs: []JobSpec{build("Java Build", 40*time.Second)}},
+			{Name: "Test", Jobs: []JobSpec{
+				test("Unit Tests", 30*time.Second),
+				test("Integration Tests", 50*time.Second),
+			}},
+			{Name: "Deploy", Jobs: []JobSpec{
+				deploy("Deploy to Staging", 60*time.Second, "10.0.1.11", "10.0.1.12", "10.0.1.13", "10.0.1.14"),
+			}},
+		}, map[string]string{repo: "master"}, groupID)
+		return p.PipelineID
+	}
+
+	order := service("order-service", backend.GroupID)
+	payment := service("payment-service", backend.GroupID)
+	user := service("user-service", backend.GroupID)
+	service("inventory-service", backend.GroupID)
+
+	web := s.AddPipeline("web-console", []StageSpec{
+		{Name: "Build", Jobs: []JobSpec{build("Node.js Build", 45*time.Second)}},
+		{Name: "Publish", Jobs: []JobSpec{{Name: "Upload to OSS", Duration: 20 * time.Second}}},
+	}, map[string]string{"https://codeup.aliyun.com/demo/web-console.git": "main"}, frontend.GroupID).PipelineID
+
+	release := s.AddPipeline("production-release", []StageSpec{
+		{Name: "Build", Jobs: []JobSpec{
+			build("Build order-service", 40*time.Second),
+			build("Build payment-service", 35*time.Second),
+		}},
+		{Name: "Deploy", Jobs: []JobSpec{
+			deploy("Deploy to Production", 90*time.Second, "10.0.9.21", "10.0.9.22", "10.0.9.23", "10.0.9.24", "10.0.9.25", "10.0.9.26"),
+		}},
+	}, map[string]string{
+		"https://codeup.aliyun.com/demo/order-service.git":   "release/1.8",
+		"https://codeup.aliyun.com/demo/payment-service.git": "release/1.8",
+	}, ops.GroupID, backend.GroupID).PipelineID
+
+	s.SetVariables(release,
+		api.PipelineVariable{Name: "DEPLOY_ENV", Value: "production", Description: "Environment to deploy to", Runtime: true},
+		api.PipelineVariable{Name: "SKIP_SMOKE_TESTS", Value: "false", Description: "Skip the smoke tests after deploying", Runtime: true},
+		api.PipelineVariable{Name: "REGISTRY", Value: "registry.cn-hangzhou.aliyuncs.com/demo"},
+	)
+
+	hotfix := s.AddPipeline("hotfix-release", []StageSpec{
+		{Name: "Build", Jobs: []JobSpec{build("Build order-service", 40*time.Second)}},
+		{Name: "Approve", Jobs: []JobSpec{{
+			Name:     "Release approval",
+			Validate: &ValidateSpec{Description: "Ship the order-service hotfix to production", Validators: []string{"demo", "ops-lead"}},
+		}}},
+		{Name: "Deploy", Jobs: []JobSpec{{
+			Name:     "Deploy to Production",
+			Duration: 60 * time.Second,
+			// Pauses after its first batch until it is resumed from the job's actions
+			Deploy: &DeploySpec{Machines: []string{"10.0.9.21", "10.0.9.22"}, Batches: 2, Pause: true},
+		}}},
+	}, map[string]string{"https://codeup.aliyun.com/demo/order-service.git": "hotfix/1.8.1"}, ops.GroupID).PipelineID
+
+	// Branches and tags of the release repositories for the run dialog's picker
+	s.SetRefs("https://codeup.aliyun.com/demo/order-service.git",
+		[]string{"master", "develop", "feature/order-export", "feature/split-payments", "hotfix/1.8.1", "release/1.7", "release/1.8"},
+		[]string{"v1.7.0", "v1.8.0", "v1.8.1"})
+	s.SetRefs("https://codeup.aliyun.com/demo/payment-service.git",
+		[]string{"master", "develop", "feature/alipay-refunds", "release/1.7", "release/1.8"},
+		[]string{"v1.7.0", "v1.8.0"})
+
+	s.AddPipeline("nightly-cleanup", []StageSpec{
+		{Name: "Cleanup", Jobs: []JobSpec{{Name: "Purge old artifacts", Duration: 15 * time.Second}}},
+	}, nil, ops.GroupID)
+
+	// History: finished runs in the past, a failed one and runs in progress
+	for i, id := range []string{order, payment, user, web, release} {
+		for day := 5; day >= 1; day-- {
+			opts := RunOptions{}
+			if day%2 == 0 {
+				opts.TriggerMode = "PUSH"
+			}
+			if (i+day)%4 == 0 {
+				opts.FailJobs = []string{"Integration Tests"}
+			}
+			s.AddRun(id, now.Add(-time.Duration(day)*24*time.Hour-time.Duration(i)*time.Hour), opts)
+		}
+	}
+	s.AddRun(payment, now.Add(-2*time.Hour), RunOptions{FailJobs: []string{"Deploy to Staging"}})
+	s.AddRun(order, now.Add(-50*time.Second), RunOptions{})
+	s.AddRun(release, now.Add(-30*time.Second), RunOptions{})
+	s.AddRun(hotfix, now.Add(-2*time.Minute), RunOptions{}) // Waits for its approval
+
+	return s
+}
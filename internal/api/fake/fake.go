@@ -0,0 +1,1813 @@
+// Package fake provides an in-memory implementation of api.PipelineService.
+//
+// Runs are simulated from the pipeline's stage and job specs and the service's
+// clock: stages run one after another, the jobs of a stage run in parallel,
+// each job takes its Duration and its log grows while it runs. This makes the
+// fake usable both for deterministic tests (with a manual clock) and for demos.
+package fake
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+// JobSpec describes a job of a simulated pipeline.
+type JobSpec struct {
+	Name     string
+	Duration time.Duration
+	Fail     bool          // The job fails once its Duration has elapsed
+	Log      []string      // Log lines, revealed progressively while the job runs
+	Steps    []StepSpec    // Steps sharing the job's duration evenly; their logs make up the job's
+	Deploy   *DeploySpec   // Makes the job a VM deployment job
+	Validate *ValidateSpec // Makes the job a manual validation gate; Duration is ignored
+}
+
+// StepSpec describes a step of a simulated job.
+type StepSpec struct {
+	Name string
+	Log  []string // Log lines, revealed progressively while the step runs
+}
+
+// ValidateSpec describes a manual validation gate (人工卡点): the job waits
+// until it is passed, and succeeds, or refused, and fails.
+type ValidateSpec struct {
+	Description string
+	Validators  []string
+	Forbidden   bool // The caller is not a validator, so passing or refusing fails
+}
+
+// DeploySpec describes the VM deployment performed by a job.
+type DeploySpec struct {
+	Machines []string // Machine IPs
+	Batches  int      // Number of deployment batches, at least 1
+	Pause    bool     // The deployment pauses after its first batch until it is resumed
+}
+
+// StageSpec describes a stage of a simulated pipeline.
+type StageSpec struct {
+	Name string
+	Jobs []JobSpec
+}
+
+// RunOptions customizes a run created with AddRun.
+type RunOptions struct {
+	Branches    map[string]string // Repository URL to branch; defaults to the pipeline's repositories
+	Envs        map[string]string // Values of runtime variables; the others keep their defaults
+	FailJobs    []string          // Names of jobs that fail in this run regardless of their spec
+	TriggerMode string            // Defaults to MANUAL
+}
+
+// Service is an in-memory api.PipelineService. The zero value is not usable;
+// create one with New or NewDemo.
+type Service struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	groups     []*group
+	pipelines  []*pipeline
+	runs       map[string]*run // Keyed by run ID
+	deploys    map[string]*job // Keyed by deploy order ID
+	refs       map[string]refs // Keyed by repository path
+	errors     map[string]error
+	nextID     int64
+	nextRunID  int64
+	nextJobID  int64
+	nextDeploy int64
+}
+
+// refs are the branches and tags of a repository
+type refs struct {
+	branches []string
+	tags     []string
+}
+
+type group struct {
+	id   string
+	name string
+}
+
+type pipeline struct {
+	api.Pipeline
+	groupIDs []string
+	stages   []StageSpec
+	repos    map[string]string // Repository URL to default branch
+	vars     []api.PipelineVariable
+	runs     []*run // Oldest first
+}
+
+type run struct {
+	id          string
+	pipeline    *pipeline
+	number      int
+	startTime   time.Time
+	canceledAt  time.Time
+	triggerMode string
+	branches    map[string]string
+	envs        map[string]string // Values of runtime variables the run was started with
+	stages      [][]*job
+}
+
+type job struct {
+	id       int64
+	sign     string
+	spec     JobSpec
+	fail     bool
+	run      *run
+	deployID string
+
+	// Actions taken on the job; zero if they were not
+	retriedAt    time.Time // The job runs again from here, and passes
+	stoppedAt    time.Time // The job is canceled here
+	skippedAt    time.Time // The job's failure is skipped here, letting the run go on
+	decidedAt    time.Time // The validation gate is passed or refused here
+	passed       bool      // Whether the validation gate was passed rather than refused
+	comment      string    // Comment given with the decision on the validation gate
+	resumedAt    time.Time // The paused deployment goes on with its next batch here
+	rolledBackAt time.Time // The deployment is rolled back here
+}
+
+// never is when a validation gate nobody decided on is due to end
+var never = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var _ api.PipelineService = (*Service)(nil)
+
+// New returns an empty service using the wall clock.
+func New() *Service {
+	return &Service{
+		now:        time.Now,
+		runs:       make(map[string]*run),
+		deploys:    make(map[string]*job),
+		refs:       make(map[string]refs),
+		errors:     make(map[string]error),
+		nextID:     1000,
+		nextRunID:  1,
+		nextJobID:  50000,
+		nextDeploy: 9000,
+	}
+}
+
+// SetClock replaces the clock runs are simulated against.
+func (s *Service) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetError makes the named operation (e.g. "RunPipeline") fail with err until
+// it is cleared by passing a nil error.
+func (s *Service) SetError(operation string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errors, operation)
+		return
+	}
+	s.errors[operation] = err
+}
+
+// AddGroup adds a pipeline group and returns it.
+func (s *Service) AddGroup(name string) api.PipelineGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	g := &group{id: strconv.FormatInt(s.nextID, 10), name: name}
+	s.groups = append(s.groups, g)
+	return api.PipelineGroup{GroupID: g.id, Name: g.name}
+}
+
+// AddPipeline adds a pipeline with the given stages. repos maps repository
+// URLs to their default branch. The pipeline is put into the given groups.
+func (s *Service) AddPipeline(name string, stages []StageSpec, repos map[string]string, groupIDs ...string) api.Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	now := s.now()
+	p := &pipeline{
+		Pipeline: api.Pipeline{
+			PipelineID:  strconv.FormatInt(s.nextID, 10),
+			Name:        name,
+			Creator:     "demo",
+			CreatorName: "demo",
+			Modifier:    "demo",
+			CreateTime:  now.Add(-30 * 24 * time.Hour),
+			UpdateTime:  now.Add(-24 * time.Hour),
+		},
+		groupIDs: groupIDs,
+		stages:   stages,
+		repos:    copyMap(repos),
+	}
+	s.pipelines = append(s.pipelines, p)
+	return p.Pipeline
+}
+
+// SetVariables declares the variables of a pipeline, as its flow YAML would.
+func (s *Service) SetVariables(pipelineID string, vars ...api.PipelineVariable) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.pipeline(pipelineID)
+	if err != nil {
+		return err
+	}
+	p.vars = append([]api.PipelineVariable(nil), vars...)
+	return nil
+}
+
+// SetRefs sets the branches and tags of a repository. Without them a
+// repository of a pipeline has the branches its pipelines and runs build and
+// no tags.
+func (s *Service) SetRefs(repoURL string, branches, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs[repoPath(repoURL)] = refs{
+		branches: append([]string(nil), branches...),
+		tags:     append([]string(nil), tags...),
+	}
+}
+
+// AddRun starts a run of a pipeline at the given time, which may lie in the
+// past to seed history. It returns the run ID.
+func (s *Service) AddRun(pipelineID string, startedAt time.Time, opts RunOptions) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.pipeline(pipelineID)
+	if err != nil {
+		return "", err
+	}
+	return s.startRun(p, startedAt, opts).id, nil
+}
+
+// startRun creates a run; s.mu must be held.
+func (s *Service) startRun(p *pipeline, startedAt time.Time, opts RunOptions) *run {
+	id := strconv.FormatInt(s.nextRunID, 10)
+	s.nextRunID++
+
+	branches := opts.Branches
+	if len(branches) == 0 {
+		branches = p.repos
+	}
+	trigger := opts.TriggerMode
+	if trigger == "" {
+		trigger = "MANUAL"
+	}
+	failing := make(map[string]bool)
+	for _, name := range opts.FailJobs {
+		failing[name] = true
+	}
+
+	r := &run{
+		id:          id,
+		pipeline:    p,
+		number:      len(p.runs) + 1,
+		startTime:   startedAt,
+		triggerMode: trigger,
+		branches:    copyMap(branches),
+		envs:        make(map[string]string),
+	}
+	for _, v := range p.vars {
+		r.envs[v.Name] = v.Value
+	}
+	for name, value := range opts.Envs {
+		r.envs[name] = value
+	}
+	for si, stage := range p.stages {
+		var jobs []*job
+		for ji, spec := range stage.Jobs {
+			s.nextJobID++
+			j := &job{
+				id:   s.nextJobID,
+				sign: fmt.Sprintf("job_%d_%d", si, ji),
+				spec: spec,
+				fail: spec.Fail || failing[spec.Name],
+				run:  r,
+			}
+			if spec.Deploy != nil {
+				s.nextDeploy++
+				j.deployID = strconv.FormatInt(s.nextDeploy, 10)
+				s.deploys[j.deployID] = j
+			}
+			jobs = append(jobs, j)
+		}
+		r.stages = append(r.stages, jobs)
+	}
+
+	p.runs = append(p.runs, r)
+	s.runs[id] = r
+	return r
+}
+
+// ---- simulation ----
+
+// jobState is the simulated state of a job at a point in time.
+type jobState struct {
+	status    string
+	startTime time.Time
+	endTime   time.Time
+	progress  float64 // Fraction of the job's duration that has elapsed, 0..1
+	paused    bool    // The deployment waits to be resumed after its first batch
+}
+
+// runState is the simulated state of a run at a point in time.
+type runState struct {
+	status     string
+	finishTime time.Time
+	jobs       [][]jobState
+}
+
+func (r *run) state(now time.Time) runState {
+	st := runState{status: "RUNNING"}
+	stageStart := r.startTime
+	blocked := false // A previous stage failed or the run was canceled before this stage
+
+	for _, jobs := range r.stages {
+		states := make([]jobState, len(jobs))
+		stageEnd := stageStart
+		stageFailed := false
+		stageStopped := false // A job was stopped on its own
+		stageRunning := false // A job is running, rather than only waiting for validation
+		stageWaiting := false
+
+		for i, j := range jobs {
+			if blocked {
+				states[i] = jobState{status: "INIT"}
+				continue
+			}
+			js, end := j.state(stageStart, r.canceledAt, now)
+			if end.After(stageEnd) {
+				stageEnd = end
+			}
+			switch {
+			case js.status == "FAILED":
+				stageFailed = true
+			case js.status == "CANCELED" && !j.stoppedAt.IsZero():
+				stageStopped = true
+			case js.status == "RUNNING":
+				stageRunning = true
+			case js.status == "WAITING":
+				stageWaiting = true
+			}
+			states[i] = js
+		}
+		st.jobs = append(st.jobs, states)
+
+		if blocked {
+			continue
+		}
+		if !r.canceledAt.IsZero() && !now.Before(r.canceledAt) && r.canceledAt.Before(stageEnd) {
+			st.status = "CANCELED"
+			st.finishTime = r.canceledAt
+			blocked = true
+			continue
+		}
+		if now.Before(stageEnd) {
+			// Still running; later stages have not started yet
+			if stageWaiting && !stageRunning {
+				st.status = "WAITING"
+			}
+			blocked = true
+			continue
+		}
+		if stageFailed {
+			st.status = "FAILED"
+			st.finishTime = stageEnd
+			blocked = true
+			continue
+		}
+		if stageStopped {
+			st.status = "CANCELED"
+			st.finishTime = stageEnd
+			blocked = true
+			continue
+		}
+		stageStart = stageEnd
+	}
+
+	if st.status == "RUNNING" && !blocked {
+		st.status = "SUCCESS"
+		st.finishTime = stageStart
+	}
+	return st
+}
+
+// state simulates the job at now, in a stage that started at stageStart of a
+// run canceled at canceledAt (zero if it was not). It also returns the time
+// the job ends, or is due to end, which is when the next stage may start.
+func (j *job) state(stageStart, canceledAt, now time.Time) (jobState, time.Time) {
+	start, fail := stageStart, j.fail
+	if !j.retriedAt.IsZero() {
+		start, fail = j.retriedAt, false
+	}
+	end := start.Add(j.spec.Duration)
+	if j.spec.Validate != nil {
+		// A validation gate waits until it is decided, however long that takes
+		end = never
+		if !j.decidedAt.IsZero() {
+			end, fail = j.decidedAt, !j.passed
+		}
+	}
+	if pausedAt, ok := j.pausedAt(start); ok {
+		// A paused deployment is due to end once the rest of it is done
+		end = never
+		if j.resumed(start) {
+			end = maxTime(j.resumedAt, pausedAt).Add(start.Add(j.spec.Duration).Sub(pausedAt))
+		}
+	}
+	finish := end
+	if !j.stoppedAt.IsZero() && j.stoppedAt.Before(end) {
+		finish = j.stoppedAt
+	}
+
+	js := jobState{startTime: start}
+	switch {
+	case !canceledAt.IsZero() && !canceledAt.After(start):
+		return jobState{status: "INIT"}, end
+	case !canceledAt.IsZero() && canceledAt.Before(end) && !now.Before(canceledAt):
+		js.status = "CANCELED"
+		js.endTime = canceledAt
+		js.progress = fraction(j.elapsed(start, canceledAt), j.spec.Duration)
+	case !j.skippedAt.IsZero() && !now.Before(j.skippedAt):
+		js.status = "SKIPPED"
+		js.endTime = finish
+		js.progress = fraction(j.elapsed(start, finish), j.spec.Duration)
+		return js, j.skippedAt
+	case !j.stoppedAt.IsZero() && !now.Before(j.stoppedAt):
+		js.status = "CANCELED"
+		js.endTime = finish
+		js.progress = fraction(j.elapsed(start, finish), j.spec.Duration)
+		return js, finish
+	case now.Before(start):
+		js = jobState{status: "INIT"}
+	case now.Before(end) && j.spec.Validate != nil:
+		js.status = "WAITING"
+	case now.Before(end):
+		js.status = "RUNNING"
+		js.progress = fraction(j.elapsed(start, now), j.spec.Duration)
+		if pausedAt, ok := j.pausedAt(start); ok && !now.Before(pausedAt) && (!j.resumed(start) || now.Before(j.resumedAt)) {
+			js.paused = true
+		}
+	case fail:
+		js.status = "FAILED"
+		js.endTime = end
+		js.progress = 1
+	default:
+		js.status = "SUCCESS"
+		js.endTime = end
+		js.progress = 1
+	}
+	return js, end
+}
+
+// pausedAt returns when the deployment of a job started at start pauses after
+// its first batch, if it pauses at all.
+func (j *job) pausedAt(start time.Time) (time.Time, bool) {
+	d := j.spec.Deploy
+	if d == nil || !d.Pause || d.Batches < 2 {
+		return time.Time{}, false
+	}
+	return start.Add(j.spec.Duration / time.Duration(d.Batches)), true
+}
+
+// resumed reports whether the deployment of a job started at start was
+// resumed; resuming an earlier attempt of a retried job does not count.
+func (j *job) resumed(start time.Time) bool {
+	return !j.resumedAt.IsZero() && !j.resumedAt.Before(start)
+}
+
+// elapsed returns how much of its duration a job started at start has spent
+// running at t, leaving out the time its deployment was paused.
+func (j *job) elapsed(start, t time.Time) time.Duration {
+	pausedAt, ok := j.pausedAt(start)
+	switch {
+	case !ok || t.Before(pausedAt):
+		return t.Sub(start)
+	case !j.resumed(start) || t.Before(j.resumedAt):
+		return pausedAt.Sub(start)
+	default:
+		return pausedAt.Sub(start) + t.Sub(maxTime(j.resumedAt, pausedAt))
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func fraction(elapsed, total time.Duration) float64 {
+	if total <= 0 {
+		return 1
+	}
+	f := float64(elapsed) / float64(total)
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+func (r *run) summary(now time.Time) api.PipelineRun {
+	st := r.state(now)
+	return api.PipelineRun{
+		RunID:       r.id,
+		PipelineID:  r.pipeline.PipelineID,
+		Status:      st.status,
+		StartTime:   r.startTime,
+		FinishTime:  st.finishTime,
+		TriggerMode: r.triggerMode,
+	}
+}
+
+// jobLog returns the part of the job's log produced so far.
+func (j *job) log(st jobState) string {
+	if st.status == "INIT" {
+		return ""
+	}
+	if j.spec.Validate != nil {
+		return j.validationLog(st)
+	}
+	lines := j.lines()
+	n := len(lines)
+	if st.status == "RUNNING" || st.status == "CANCELED" {
+		n = int(float64(len(lines))*st.progress + 0.5)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] Job %s started\n", st.startTime.Format("15:04:05"), j.spec.Name)
+	if len(j.run.envs) > 0 {
+		fmt.Fprintf(&b, "Variables: %s\n", formatEnvs(j.run.envs))
+	}
+	for _, line := range lines[:n] {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	switch st.status {
+	case "SUCCESS":
+		fmt.Fprintf(&b, "[%s] Job %s finished successfully\n", st.endTime.Format("15:04:05"), j.spec.Name)
+	case "FAILED":
+		fmt.Fprintf(&b, "[%s] ERROR: Job %s failed with exit code 1\n", st.endTime.Format("15:04:05"), j.spec.Name)
+	case "CANCELED":
+		fmt.Fprintf(&b, "[%s] Job %s was canceled\n", st.endTime.Format("15:04:05"), j.spec.Name)
+	case "SKIPPED":
+		fmt.Fprintf(&b, "[%s] Job %s was skipped\n", st.endTime.Format("15:04:05"), j.spec.Name)
+	}
+	return b.String()
+}
+
+// formatEnvs lists the variables of a run as NAME=value, sorted by name
+func formatEnvs(envs map[string]string) string {
+	pairs := make([]string, 0, len(envs))
+	for name, value := range envs {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// validationLog returns the log of a validation gate: what it waits for and
+// how it was decided.
+func (j *job) validationLog(st jobState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] Waiting for manual validation: %s\n", st.startTime.Format("15:04:05"), j.spec.Validate.Description)
+	fmt.Fprintf(&b, "Validators: %s\n", strings.Join(j.spec.Validate.Validators, ", "))
+	decision := ""
+	switch st.status {
+	case "SUCCESS":
+		decision = "Passed"
+	case "FAILED":
+		decision = "Refused"
+	case "CANCELED":
+		fmt.Fprintf(&b, "[%s] Validation was canceled\n", st.endTime.Format("15:04:05"))
+	case "SKIPPED":
+		fmt.Fprintf(&b, "[%s] Validation was skipped\n", st.endTime.Format("15:04:05"))
+	}
+	if decision != "" {
+		fmt.Fprintf(&b, "[%s] %s by demo", st.endTime.Format("15:04:05"), decision)
+		if j.comment != "" {
+			fmt.Fprintf(&b, ": %s", j.comment)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// lines returns all lines of the job's log.
+func (j *job) lines() []string {
+	if len(j.spec.Steps) == 0 {
+		if len(j.spec.Log) == 0 {
+			return defaultLog(j.spec.Name)
+		}
+		return j.spec.Log
+	}
+	var lines []string
+	for _, step := range j.spec.Steps {
+		lines = append(lines, step.Log...)
+	}
+	return lines
+}
+
+// steps returns the job's steps; a job without steps runs as a single step.
+func (j *job) steps() []StepSpec {
+	if len(j.spec.Steps) > 0 {
+		return j.spec.Steps
+	}
+	return []StepSpec{{Name: j.spec.Name, Log: j.lines()}}
+}
+
+// stepStates simulates the steps of the job: they run one after another, each
+// taking an even share of the job's duration. The last step of a failed job
+// fails.
+func (j *job) stepStates(st jobState) []api.JobStep {
+	steps := j.steps()
+	n := len(steps)
+	each := j.spec.Duration / time.Duration(n)
+
+	states := make([]api.JobStep, n)
+	for i, step := range steps {
+		state := api.JobStep{Index: strconv.Itoa(i + 1), Name: step.Name, Status: "INIT"}
+		begin, end := float64(i)/float64(n), float64(i+1)/float64(n)
+		switch {
+		case st.status == "INIT" || st.progress < begin:
+		case st.progress >= end:
+			state.Status = "SUCCESS"
+			if st.status == "FAILED" && i == n-1 {
+				state.Status = "FAILED"
+			}
+			state.StartTime = st.startTime.Add(time.Duration(i) * each)
+			state.EndTime = state.StartTime.Add(each)
+		default:
+			state.Status = st.status // RUNNING or CANCELED
+			state.StartTime = st.startTime.Add(time.Duration(i) * each)
+			state.EndTime = st.endTime
+		}
+		states[i] = state
+	}
+	return states
+}
+
+// stepLog returns the part of the log of the job's step produced so far.
+func (j *job) stepLog(st jobState, index int) string {
+	state := j.stepStates(st)[index]
+	if state.Status == "INIT" {
+		return ""
+	}
+	lines := j.steps()[index].Log
+	n := len(lines)
+	if state.Status == "RUNNING" || state.Status == "CANCELED" {
+		// Progress through this step rather than the whole job
+		progress := min(max(st.progress*float64(len(j.steps()))-float64(index), 0), 1)
+		n = int(float64(len(lines))*progress + 0.5)
+	}
+
+	var b strings.Builder
+	for _, line := range lines[:n] {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if state.Status == "FAILED" {
+		fmt.Fprintf(&b, "ERROR: step %s failed with exit code 1\n", state.Name)
+	}
+	return b.String()
+}
+
+func defaultLog(name string) []string {
+	lines := make([]string, 0, 10)
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("%s: step %d/10 done", name, i))
+	}
+	return lines
+}
+
+// state returns the simulated state of the job; s.mu must be held.
+func (s *Service) jobState(j *job) (jobState, runState) {
+	rs := j.run.state(s.now())
+	for si, jobs := range j.run.stages {
+		for ji, candidate := range jobs {
+			if candidate == j {
+				return rs.jobs[si][ji], rs
+			}
+		}
+	}
+	return jobState{status: "INIT"}, rs
+}
+
+// ---- lookup helpers, s.mu must be held ----
+
+func (s *Service) check(ctx context.Context, operation, organizationId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.errors[operation]; err != nil {
+		return err
+	}
+	if organizationId == "" {
+		return fmt.Errorf("organizationId is required")
+	}
+	return nil
+}
+
+func (s *Service) pipeline(pipelineID string) (*pipeline, error) {
+	for _, p := range s.pipelines {
+		if p.PipelineID == pipelineID {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("pipeline %s: %w", pipelineID, api.ErrNotFound)
+}
+
+func (s *Service) run(pipelineID, runID string) (*run, error) {
+	r, ok := s.runs[runID]
+	if !ok || r.pipeline.PipelineID != pipelineID {
+		return nil, fmt.Errorf("run %s of pipeline %s: %w", runID, pipelineID, api.ErrNotFound)
+	}
+	return r, nil
+}
+
+// pipelineSummary returns the pipeline with the status of its latest run filled in.
+func (s *Service) pipelineSummary(p *pipeline) api.Pipeline {
+	result := p.Pipeline
+	if len(p.runs) > 0 {
+		latest := p.runs[len(p.runs)-1].summary(s.now())
+		result.Status = latest.Status
+		result.LastRunStatus = latest.Status
+		result.LastRunTime = latest.StartTime
+		if !latest.FinishTime.IsZero() {
+			result.LastRunTime = latest.FinishTime
+		}
+	}
+	return result
+}
+
+func (s *Service) listPipelines(statusList []string, groupID string) []api.Pipeline {
+	wanted := make(map[string]bool)
+	for _, status := range statusList {
+		if status = strings.TrimSpace(status); status != "" {
+			wanted[status] = true
+		}
+	}
+
+	var result []api.Pipeline
+	for _, p := range s.pipelines {
+		if groupID != "" && !contains(p.groupIDs, groupID) {
+			continue
+		}
+		summary := s.pipelineSummary(p)
+		if len(wanted) > 0 && !wanted[summary.Status] {
+			continue
+		}
+		result = append(result, summary)
+	}
+	return result
+}
+
+// toInterfaces converts a list of strings the way it reads back from JSON
+func toInterfaces(list []string) []interface{} {
+	result := make([]interface{}, len(list))
+	for i, item := range list {
+		result[i] = item
+	}
+	return result
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// repoPath returns the full path of a repository URL; a full path is returned
+// as it is
+func repoPath(repo string) string {
+	if path, err := api.RepositoryPath(repo); err == nil {
+		return path
+	}
+	return strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+}
+
+// repositoryRefs returns the branches and tags of a repository
+func (s *Service) repositoryRefs(repo string) (refs, error) {
+	path := repoPath(repo)
+	known := make(map[string]bool)
+	for _, p := range s.pipelines {
+		for url, branch := range p.repos {
+			if repoPath(url) == path {
+				known[branch] = true
+			}
+		}
+		for _, r := range p.runs {
+			for url, branch := range r.branches {
+				if repoPath(url) == path {
+					known[branch] = true
+				}
+			}
+		}
+	}
+	if set, ok := s.refs[path]; ok {
+		return set, nil
+	}
+	if len(known) == 0 {
+		return refs{}, fmt.Errorf("repository %s: %w", repo, api.ErrNotFound)
+	}
+	var result refs
+	for branch := range known {
+		if branch != "" {
+			result.branches = append(result.branches, branch)
+		}
+	}
+	sort.Strings(result.branches)
+	return result, nil
+}
+
+// refList returns named refs of a repository, each pointing at a commit made up
+// of the repository and the name
+func refList(repo string, names []string) []api.RepositoryRef {
+	result := make([]api.RepositoryRef, len(names))
+	for i, name := range names {
+		result[i] = api.RepositoryRef{Name: name, CommitID: fmt.Sprintf("%x", sha1.Sum([]byte(repoPath(repo)+"@"+name)))}
+	}
+	return result
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// ---- api.PipelineService ----
+
+// ListPipelines returns all pipelines.
+func (s *Service) ListPipelines(organizationId string) ([]api.Pipeline, error) {
+	return s.ListPipelinesContext(context.Background(), organizationId)
+}
+
+// ListPipelinesContext returns all pipelines.
+func (s *Service) ListPipelinesContext(ctx context.Context, organizationId string) ([]api.Pipeline, error) {
+	return s.ListPipelinesWithStatusContext(ctx, organizationId, nil)
+}
+
+// ListPipelinesWithStatus returns the pipelines whose latest run has one of the given statuses.
+func (s *Service) ListPipelinesWithStatus(organizationId string, statusList []string) ([]api.Pipeline, error) {
+	return s.ListPipelinesWithStatusContext(context.Background(), organizationId, statusList)
+}
+
+// ListPipelinesWithStatusContext returns the pipelines whose latest run has one of the given statuses.
+func (s *Service) ListPipelinesWithStatusContext(ctx context.Context, organizationId string, statusList []string) ([]api.Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListPipelines", organizationId); err != nil {
+		return nil, err
+	}
+	return s.listPipelines(statusList, ""), nil
+}
+
+// ListPipelinesWithCallback returns all pipelines in pages of 30.
+func (s *Service) ListPipelinesWithCallback(organizationId string, callback api.PipelinePageCallback) error {
+	return s.ListPipelinesWithStatusAndCallbackContext(context.Background(), organizationId, nil, callback)
+}
+
+// ListPipelinesWithCallbackContext returns all pipelines in pages of 30.
+func (s *Service) ListPipelinesWithCallbackContext(ctx context.Context, organizationId string, callback api.PipelinePageCallback) error {
+	return s.ListPipelinesWithStatusAndCallbackContext(ctx, organizationId, nil, callback)
+}
+
+// ListPipelinesWithStatusAndCallback returns the matching pipelines in pages of 30.
+func (s *Service) ListPipelinesWithStatusAndCallback(organizationId string, statusList []string, callback api.PipelinePageCallback) error {
+	return s.ListPipelinesWithStatusAndCallbackContext(context.Background(), organizationId, statusList, callback)
+}
+
+// ListPipelinesWithStatusAndCallbackContext returns the matching pipelines in pages of 30.
+func (s *Service) ListPipelinesWithStatusAndCallbackContext(ctx context.Context, organizationId string, statusList []string, callback api.PipelinePageCallback) error {
+	pipelines, err := s.ListPipelinesWithStatusContext(ctx, organizationId, statusList)
+	if err != nil {
+		return err
+	}
+
+	const perPage = 30
+	totalPages := (len(pipelines) + perPage - 1) / perPage
+	if totalPages == 0 {
+		return callback([]api.Pipeline{}, 1, 1, true)
+	}
+	for page := 1; page <= totalPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := page * perPage
+		if end > len(pipelines) {
+			end = len(pipelines)
+		}
+		if err := callback(pipelines[(page-1)*perPage:end], page, totalPages, page == totalPages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPipelineDetails returns a single pipeline.
+func (s *Service) GetPipelineDetails(organizationId string, pipelineId string) (*api.Pipeline, error) {
+	return s.GetPipelineDetailsContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetPipelineDetailsContext returns a single pipeline.
+func (s *Service) GetPipelineDetailsContext(ctx context.Context, organizationId string, pipelineId string) (*api.Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineDetails", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	summary := s.pipelineSummary(p)
+	return &summary, nil
+}
+
+// GetPipeline returns the definition of a pipeline: its repositories, sorted
+// by URL, and its variables.
+func (s *Service) GetPipeline(organizationId, pipelineId string) (*api.PipelineDefinition, error) {
+	return s.GetPipelineContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetPipelineContext is like GetPipeline but carries ctx.
+func (s *Service) GetPipelineContext(ctx context.Context, organizationId, pipelineId string) (*api.PipelineDefinition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipeline", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	definition := &api.PipelineDefinition{
+		PipelineID: p.PipelineID,
+		Name:       p.Name,
+		Variables:  append([]api.PipelineVariable(nil), p.vars...),
+	}
+	for repo, branch := range p.repos {
+		name := strings.TrimSuffix(repo[strings.LastIndex(repo, "/")+1:], ".git")
+		definition.Sources = append(definition.Sources, api.PipelineSource{Name: name, Type: "codeup", Repo: repo, Branch: branch})
+	}
+	sort.Slice(definition.Sources, func(i, j int) bool { return definition.Sources[i].Repo < definition.Sources[j].Repo })
+	return definition, nil
+}
+
+// ListRepositoryBranches returns the branches of a repository of a pipeline.
+// The repository may be given by URL or by full path.
+func (s *Service) ListRepositoryBranches(organizationId, repoURL string) ([]api.RepositoryRef, error) {
+	return s.ListRepositoryBranchesContext(context.Background(), organizationId, repoURL)
+}
+
+// ListRepositoryBranchesContext returns the branches of a repository of a pipeline.
+func (s *Service) ListRepositoryBranchesContext(ctx context.Context, organizationId, repoURL string) ([]api.RepositoryRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListRepositoryBranches", organizationId); err != nil {
+		return nil, err
+	}
+	set, err := s.repositoryRefs(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return refList(repoURL, set.branches), nil
+}
+
+// ListRepositoryTags returns the tags of a repository of a pipeline.
+func (s *Service) ListRepositoryTags(organizationId, repoURL string) ([]api.RepositoryRef, error) {
+	return s.ListRepositoryTagsContext(context.Background(), organizationId, repoURL)
+}
+
+// ListRepositoryTagsContext returns the tags of a repository of a pipeline.
+func (s *Service) ListRepositoryTagsContext(ctx context.Context, organizationId, repoURL string) ([]api.RepositoryRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListRepositoryTags", organizationId); err != nil {
+		return nil, err
+	}
+	set, err := s.repositoryRefs(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return refList(repoURL, set.tags), nil
+}
+
+// ListPipelineGroups returns all pipeline groups.
+func (s *Service) ListPipelineGroups(organizationId string) ([]api.PipelineGroup, error) {
+	return s.ListPipelineGroupsContext(context.Background(), organizationId)
+}
+
+// ListPipelineGroupsContext returns all pipeline groups.
+func (s *Service) ListPipelineGroupsContext(ctx context.Context, organizationId string) ([]api.PipelineGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListPipelineGroups", organizationId); err != nil {
+		return nil, err
+	}
+	var result []api.PipelineGroup
+	for _, g := range s.groups {
+		result = append(result, api.PipelineGroup{GroupID: g.id, Name: g.name})
+	}
+	return result, nil
+}
+
+// ListPipelineGroupPipelines returns the pipelines of a group. The
+// "statusList" (comma separated) and "pipelineName" options are supported.
+func (s *Service) ListPipelineGroupPipelines(organizationId string, groupId int, options map[string]interface{}) ([]api.Pipeline, error) {
+	return s.ListPipelineGroupPipelinesContext(context.Background(), organizationId, groupId, options)
+}
+
+// ListPipelineGroupPipelinesContext is like ListPipelineGroupPipelines but carries ctx.
+func (s *Service) ListPipelineGroupPipelinesContext(ctx context.Context, organizationId string, groupId int, options map[string]interface{}) ([]api.Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListPipelineGroupPipelines", organizationId); err != nil {
+		return nil, err
+	}
+
+	var statusList []string
+	if list, ok := options["statusList"].(string); ok && list != "" {
+		statusList = strings.Split(list, ",")
+	}
+	pipelines := s.listPipelines(statusList, strconv.Itoa(groupId))
+
+	if name, ok := options["pipelineName"].(string); ok && name != "" {
+		var filtered []api.Pipeline
+		for _, p := range pipelines {
+			if strings.Contains(p.Name, name) {
+				filtered = append(filtered, p)
+			}
+		}
+		pipelines = filtered
+	}
+	return pipelines, nil
+}
+
+// RunPipeline starts a new run. The "runningBranchs" parameter, a JSON object
+// of repository URL to branch, overrides the pipeline's default branches.
+func (s *Service) RunPipeline(organizationId string, pipelineIdStr string, params map[string]string) (*api.PipelineRun, error) {
+	return s.RunPipelineContext(context.Background(), organizationId, pipelineIdStr, params)
+}
+
+// RunPipelineContext is like RunPipeline but carries ctx.
+func (s *Service) RunPipelineContext(ctx context.Context, organizationId string, pipelineIdStr string, params map[string]string) (*api.PipelineRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "RunPipeline", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches map[string]string
+	if raw, ok := params["runningBranchs"]; ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &branches); err != nil {
+			return nil, fmt.Errorf("invalid runningBranchs parameter: %w", err)
+		}
+	}
+
+	var envs map[string]string
+	if raw, ok := params["envs"]; ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &envs); err != nil {
+			return nil, fmt.Errorf("invalid envs parameter: %w", err)
+		}
+	}
+
+	r := s.startRun(p, s.now(), RunOptions{Branches: branches, Envs: envs})
+	return &api.PipelineRun{
+		RunID:      r.id,
+		PipelineID: p.PipelineID,
+		Status:     "RUNNING",
+	}, nil
+}
+
+// StopPipelineRun cancels a running run.
+func (s *Service) StopPipelineRun(organizationId string, pipelineId string, runId string) error {
+	return s.StopPipelineRunContext(context.Background(), organizationId, pipelineId, runId)
+}
+
+// StopPipelineRunContext is like StopPipelineRun but carries ctx.
+func (s *Service) StopPipelineRunContext(ctx context.Context, organizationId string, pipelineId string, runId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "StopPipelineRun", organizationId); err != nil {
+		return err
+	}
+	r, err := s.run(pipelineId, runId)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if status := r.state(now).status; status != "RUNNING" {
+		return fmt.Errorf("run %s is not running (status %s): %w", runId, status, api.ErrBadRequest)
+	}
+	r.canceledAt = now
+	return nil
+}
+
+// RetryPipelineJobRun runs a failed or stopped job again; the retry passes.
+func (s *Service) RetryPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.RetryPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// RetryPipelineJobRunContext is like RetryPipelineJobRun but carries ctx.
+func (s *Service) RetryPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.jobAction(ctx, "RetryPipelineJobRun", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		if (status != "FAILED" && status != "CANCELED") || !j.run.canceledAt.IsZero() {
+			return fmt.Errorf("job %s cannot be retried (status %s): %w", jobId, status, api.ErrBadRequest)
+		}
+		j.retriedAt, j.stoppedAt, j.decidedAt, j.rolledBackAt = now, time.Time{}, time.Time{}, time.Time{}
+		return nil
+	})
+}
+
+// SkipPipelineJobRun skips a failed or stopped job, letting the run go on.
+func (s *Service) SkipPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.SkipPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// SkipPipelineJobRunContext is like SkipPipelineJobRun but carries ctx.
+func (s *Service) SkipPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.jobAction(ctx, "SkipPipelineJobRun", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		if (status != "FAILED" && status != "CANCELED") || !j.run.canceledAt.IsZero() {
+			return fmt.Errorf("job %s cannot be skipped (status %s): %w", jobId, status, api.ErrBadRequest)
+		}
+		j.skippedAt = now
+		return nil
+	})
+}
+
+// StopPipelineJobRun cancels a running job.
+func (s *Service) StopPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.StopPipelineJobRunContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// StopPipelineJobRunContext is like StopPipelineJobRun but carries ctx.
+func (s *Service) StopPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error {
+	return s.jobAction(ctx, "StopPipelineJobRun", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		if status != "RUNNING" {
+			return fmt.Errorf("job %s is not running (status %s): %w", jobId, status, api.ErrBadRequest)
+		}
+		j.stoppedAt = now
+		return nil
+	})
+}
+
+// PassPipelineValidate passes a waiting validation gate.
+func (s *Service) PassPipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return s.PassPipelineValidateContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
+
+// PassPipelineValidateContext is like PassPipelineValidate but carries ctx.
+func (s *Service) PassPipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return s.jobAction(ctx, "PassPipelineValidate", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		return j.decide(jobId, status, now, true, comment)
+	})
+}
+
+// RefusePipelineValidate refuses a waiting validation gate.
+func (s *Service) RefusePipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return s.RefusePipelineValidateContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, comment)
+}
+
+// RefusePipelineValidateContext is like RefusePipelineValidate but carries ctx.
+func (s *Service) RefusePipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error {
+	return s.jobAction(ctx, "RefusePipelineValidate", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		return j.decide(jobId, status, now, false, comment)
+	})
+}
+
+// decide records the decision on a validation gate that is waiting for one
+func (j *job) decide(jobId, status string, now time.Time, passed bool, comment string) error {
+	switch {
+	case j.spec.Validate == nil:
+		return fmt.Errorf("job %s is not a manual validation: %w", jobId, api.ErrBadRequest)
+	case status != "WAITING":
+		return fmt.Errorf("job %s is not waiting for validation (status %s): %w", jobId, status, api.ErrBadRequest)
+	case j.spec.Validate.Forbidden:
+		return fmt.Errorf("not a validator of job %s: %w", jobId, api.ErrForbidden)
+	}
+	j.decidedAt, j.passed, j.comment = now, passed, comment
+	return nil
+}
+
+// jobAction looks up a job and applies an action to it given its status
+func (s *Service) jobAction(ctx context.Context, operation, organizationId, pipelineId, pipelineRunId, jobId string, apply func(j *job, status string, now time.Time) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, operation, organizationId); err != nil {
+		return err
+	}
+	j, err := s.job(pipelineId, pipelineRunId, jobId)
+	if err != nil {
+		return err
+	}
+	js, _ := s.jobState(j)
+	return apply(j, js.status, s.now())
+}
+
+// ExecutePipelineJobAction executes an action the job currently offers, such
+// as resuming, rolling back or retrying a machine of a VM deployment.
+func (s *Service) ExecutePipelineJobAction(organizationId, pipelineId, pipelineRunId, jobId string, action api.JobAction) error {
+	return s.ExecutePipelineJobActionContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, action)
+}
+
+// ExecutePipelineJobActionContext is like ExecutePipelineJobAction but carries ctx.
+func (s *Service) ExecutePipelineJobActionContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string, action api.JobAction) error {
+	return s.jobAction(ctx, "ExecutePipelineJobAction", organizationId, pipelineId, pipelineRunId, jobId, func(j *job, status string, now time.Time) error {
+		js, _ := s.jobState(j)
+		offered := false
+		for _, a := range j.actions(js) {
+			if a.Type == action.Type && !a.Disable {
+				offered = true
+			}
+		}
+		if !offered {
+			return fmt.Errorf("job %s does not offer action %s (status %s): %w", jobId, action.Type, status, api.ErrBadRequest)
+		}
+		switch action.Type {
+		case "PassPipelineValidate", "RefusePipelineValidate":
+			comment, _ := action.Params["comment"].(string)
+			return j.decide(jobId, status, now, action.Type == "PassPipelineValidate", comment)
+		case "ResumeVMDeployOrder":
+			j.resumedAt = now
+		case "RetryVMDeployMachine":
+			// The deployment starts over, without pausing again, and passes
+			j.retriedAt, j.resumedAt, j.rolledBackAt = now, now, time.Time{}
+		case "RollbackVMDeployOrder":
+			j.rolledBackAt = now
+		default:
+			return fmt.Errorf("action %s cannot be executed: %w", action.Type, api.ErrBadRequest)
+		}
+		return nil
+	})
+}
+
+// GetLatestPipelineRun returns the most recent run of a pipeline.
+func (s *Service) GetLatestPipelineRun(organizationId, pipelineId string) (*api.PipelineRun, error) {
+	return s.GetLatestPipelineRunContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetLatestPipelineRunContext is like GetLatestPipelineRun but carries ctx.
+func (s *Service) GetLatestPipelineRunContext(ctx context.Context, organizationId, pipelineId string) (*api.PipelineRun, error) {
+	info, err := s.GetLatestPipelineRunInfoContext(ctx, organizationId, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	return info.PipelineRun, nil
+}
+
+// GetLatestPipelineRunInfo returns the most recent run of a pipeline with its branches.
+func (s *Service) GetLatestPipelineRunInfo(organizationId, pipelineId string) (*api.PipelineRunInfo, error) {
+	return s.GetLatestPipelineRunInfoContext(context.Background(), organizationId, pipelineId)
+}
+
+// GetLatestPipelineRunInfoContext is like GetLatestPipelineRunInfo but carries ctx.
+func (s *Service) GetLatestPipelineRunInfoContext(ctx context.Context, organizationId, pipelineId string) (*api.PipelineRunInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetLatestPipelineRun", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.runs) == 0 {
+		return nil, fmt.Errorf("pipeline %s has no runs: %w", pipelineId, api.ErrNotFound)
+	}
+	latest := p.runs[len(p.runs)-1]
+	summary := latest.summary(s.now())
+	return &api.PipelineRunInfo{
+		PipelineRun:    &summary,
+		RepositoryURLs: copyMap(latest.branches),
+	}, nil
+}
+
+// GetPipelineRun returns a single run.
+func (s *Service) GetPipelineRun(organizationId string, pipelineIdStr string, runIdStr string) (*api.PipelineRun, error) {
+	return s.GetPipelineRunContext(context.Background(), organizationId, pipelineIdStr, runIdStr)
+}
+
+// GetPipelineRunContext is like GetPipelineRun but carries ctx.
+func (s *Service) GetPipelineRunContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (*api.PipelineRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineRun", organizationId); err != nil {
+		return nil, err
+	}
+	r, err := s.run(pipelineIdStr, runIdStr)
+	if err != nil {
+		return nil, err
+	}
+	summary := r.summary(s.now())
+	return &summary, nil
+}
+
+// GetPipelineRunDetails returns a run with its stages and jobs.
+func (s *Service) GetPipelineRunDetails(organizationId, pipelineId, pipelineRunId string) (*api.PipelineRunDetails, error) {
+	return s.GetPipelineRunDetailsContext(context.Background(), organizationId, pipelineId, pipelineRunId)
+}
+
+// GetPipelineRunDetailsContext is like GetPipelineRunDetails but carries ctx.
+func (s *Service) GetPipelineRunDetailsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId string) (*api.PipelineRunDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineRunDetails", organizationId); err != nil {
+		return nil, err
+	}
+	r, err := s.run(pipelineId, pipelineRunId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	st := r.state(now)
+	runID, _ := strconv.ParseInt(r.id, 10, 64)
+	pid, _ := strconv.ParseInt(pipelineId, 10, 64)
+	details := &api.PipelineRunDetails{
+		PipelineRunID: runID,
+		PipelineID:    pid,
+		Status:        st.status,
+		TriggerMode:   TriggerModeCode(r.triggerMode),
+		CreateTime:    r.startTime.UnixMilli(),
+		UpdateTime:    now.UnixMilli(),
+	}
+	if !st.finishTime.IsZero() {
+		// Like the real API, a finished run was last updated when it finished
+		details.UpdateTime = st.finishTime.UnixMilli()
+	}
+	for si, jobs := range r.stages {
+		stage := api.Stage{Index: strconv.Itoa(si + 1), Name: r.pipeline.stages[si].Name}
+		for ji, j := range jobs {
+			js := st.jobs[si][ji]
+			apiJob := api.Job{
+				ID:        j.id,
+				JobSign:   j.sign,
+				Name:      j.spec.Name,
+				Status:    js.status,
+				StartTime: js.startTime,
+				EndTime:   js.endTime,
+			}
+			apiJob.Actions = j.actions(js)
+			stage.Jobs = append(stage.Jobs, apiJob)
+		}
+		details.Stages = append(details.Stages, stage)
+	}
+	return details, nil
+}
+
+// actions returns the actions the job offers in the given state, as the API
+// lists them in the run details
+func (j *job) actions(js jobState) []api.JobAction {
+	var actions []api.JobAction
+	if j.spec.Validate != nil && js.status == "WAITING" {
+		params := map[string]interface{}{"validators": toInterfaces(j.spec.Validate.Validators)}
+		actions = append(actions,
+			api.JobAction{Type: "PassPipelineValidate", DisplayType: "BUTTON", Name: "通过", Title: j.spec.Validate.Description, Disable: j.spec.Validate.Forbidden, Params: params},
+			api.JobAction{Type: "RefusePipelineValidate", DisplayType: "BUTTON", Name: "拒绝", Title: j.spec.Validate.Description, Disable: j.spec.Validate.Forbidden, Params: params},
+		)
+	}
+	if j.deployID == "" || js.status == "INIT" {
+		return actions
+	}
+
+	id, _ := strconv.ParseFloat(j.deployID, 64)
+	order := func(extra ...interface{}) map[string]interface{} {
+		params := map[string]interface{}{"deployOrderId": id}
+		for i := 0; i+1 < len(extra); i += 2 {
+			params[extra[i].(string)] = extra[i+1]
+		}
+		return params
+	}
+	actions = append(actions, api.JobAction{Type: "GetVMDeployOrder", DisplayType: "LINK", Name: "查看部署单", Params: order()})
+	if js.paused {
+		actions = append(actions, api.JobAction{Type: "ResumeVMDeployOrder", DisplayType: "BUTTON", Name: "继续部署", Title: "Deploy the next batch", Params: order()})
+	}
+	if js.status == "FAILED" {
+		if sn, ok := j.failedMachine(); ok {
+			actions = append(actions, api.JobAction{Type: "RetryVMDeployMachine", DisplayType: "BUTTON", Name: "重试机器", Title: "Retry the failed machine", Params: order("machineSn", sn)})
+		}
+	}
+	if js.status == "SUCCESS" || js.status == "FAILED" {
+		// A finished deployment can be rolled back, but only once
+		actions = append(actions, api.JobAction{
+			Type:        "RollbackVMDeployOrder",
+			DisplayType: "BUTTON",
+			Name:        "回滚",
+			Title:       "Roll back to the previous version",
+			Disable:     !j.rolledBackAt.IsZero(),
+			Params:      order(),
+		})
+	}
+	return actions
+}
+
+// TriggerModeCode maps a trigger mode name such as "MANUAL" back to the API's numeric code.
+func TriggerModeCode(mode string) int {
+	switch mode {
+	case "SCHEDULE":
+		return 2
+	case "PUSH":
+		return 3
+	case "PIPELINE":
+		return 5
+	case "WEBHOOK":
+		return 6
+	default:
+		return 1
+	}
+}
+
+// ListPipelineRuns returns the runs of a pipeline, newest first.
+func (s *Service) ListPipelineRuns(organizationId string, pipelineId string) ([]api.PipelineRun, error) {
+	return s.ListPipelineRunsContext(context.Background(), organizationId, pipelineId)
+}
+
+// ListPipelineRunsContext is like ListPipelineRuns but carries ctx.
+func (s *Service) ListPipelineRunsContext(ctx context.Context, organizationId string, pipelineId string) ([]api.PipelineRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListPipelineRuns", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	runs := make([]api.PipelineRun, 0, len(p.runs))
+	for i := len(p.runs) - 1; i >= 0; i-- {
+		runs = append(runs, p.runs[i].summary(now))
+	}
+	return runs, nil
+}
+
+// ListPipelineJobHistorys returns the runs that executed the job with the given job sign.
+func (s *Service) ListPipelineJobHistorys(organizationId, pipelineId, category, identifier string, page, perPage int) ([]api.PipelineRun, error) {
+	return s.ListPipelineJobHistorysContext(context.Background(), organizationId, pipelineId, category, identifier, page, perPage)
+}
+
+// ListPipelineJobHistorysContext is like ListPipelineJobHistorys but carries ctx.
+func (s *Service) ListPipelineJobHistorysContext(ctx context.Context, organizationId, pipelineId, category, identifier string, page, perPage int) ([]api.PipelineRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "ListPipelineJobHistorys", organizationId); err != nil {
+		return nil, err
+	}
+	p, err := s.pipeline(pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 || perPage > 30 {
+		perPage = 10
+	}
+
+	now := s.now()
+	var history []api.PipelineRun
+	for i := len(p.runs) - 1; i >= 0; i-- {
+		r := p.runs[i]
+		st := r.state(now)
+		for si, jobs := range r.stages {
+			for ji, j := range jobs {
+				if j.sign != identifier {
+					continue
+				}
+				history = append(history, api.PipelineRun{
+					RunID:       r.id,
+					PipelineID:  pipelineId,
+					Status:      st.jobs[si][ji].status,
+					TriggerMode: fmt.Sprintf("Execute #%d", r.number),
+				})
+			}
+		}
+	}
+
+	start := (page - 1) * perPage
+	if start >= len(history) {
+		return nil, nil
+	}
+	end := start + perPage
+	if end > len(history) {
+		end = len(history)
+	}
+	return history[start:end], nil
+}
+
+// GetPipelineJobRunLog returns the log a job has produced so far.
+func (s *Service) GetPipelineJobRunLog(organizationId, pipelineId, pipelineRunId, jobId string) (string, error) {
+	return s.GetPipelineJobRunLogContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// GetPipelineJobRunLogContext is like GetPipelineJobRunLog but carries ctx.
+func (s *Service) GetPipelineJobRunLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineJobRunLog", organizationId); err != nil {
+		return "", err
+	}
+	j, err := s.job(pipelineId, pipelineRunId, jobId)
+	if err != nil {
+		return "", err
+	}
+	js, _ := s.jobState(j)
+	return j.log(js), nil
+}
+
+// GetPipelineJobSteps returns the steps of a job and their status.
+func (s *Service) GetPipelineJobSteps(organizationId, pipelineId, pipelineRunId, jobId string) ([]api.JobStep, error) {
+	return s.GetPipelineJobStepsContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId)
+}
+
+// GetPipelineJobStepsContext is like GetPipelineJobSteps but carries ctx.
+func (s *Service) GetPipelineJobStepsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) ([]api.JobStep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineJobSteps", organizationId); err != nil {
+		return nil, err
+	}
+	j, err := s.job(pipelineId, pipelineRunId, jobId)
+	if err != nil {
+		return nil, err
+	}
+	js, _ := s.jobState(j)
+	return j.stepStates(js), nil
+}
+
+// GetPipelineJobStepLog returns the log a step of a job has produced so far.
+func (s *Service) GetPipelineJobStepLog(organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error) {
+	return s.GetPipelineJobStepLogContext(context.Background(), organizationId, pipelineId, pipelineRunId, jobId, stepIndex)
+}
+
+// GetPipelineJobStepLogContext is like GetPipelineJobStepLog but carries ctx.
+func (s *Service) GetPipelineJobStepLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineJobStepLog", organizationId); err != nil {
+		return "", err
+	}
+	j, err := s.job(pipelineId, pipelineRunId, jobId)
+	if err != nil {
+		return "", err
+	}
+	index, err := strconv.Atoi(stepIndex)
+	if err != nil || index < 1 || index > len(j.steps()) {
+		return "", fmt.Errorf("step %s of job %s: %w", stepIndex, jobId, api.ErrNotFound)
+	}
+	js, _ := s.jobState(j)
+	return j.stepLog(js, index-1), nil
+}
+
+func (s *Service) job(pipelineId, runId, jobId string) (*job, error) {
+	r, err := s.run(pipelineId, runId)
+	if err != nil {
+		return nil, err
+	}
+	for _, jobs := range r.stages {
+		for _, j := range jobs {
+			if strconv.FormatInt(j.id, 10) == jobId {
+				return j, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("job %s of run %s: %w", jobId, runId, api.ErrNotFound)
+}
+
+// GetPipelineRunLogs returns the logs of all jobs of a run, concatenated.
+func (s *Service) GetPipelineRunLogs(organizationId string, pipelineIdStr string, runIdStr string) (string, error) {
+	return s.GetPipelineRunLogsContext(context.Background(), organizationId, pipelineIdStr, runIdStr)
+}
+
+// GetPipelineRunLogsContext is like GetPipelineRunLogs but carries ctx.
+func (s *Service) GetPipelineRunLogsContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetPipelineRunLogs", organizationId); err != nil {
+		return "", err
+	}
+	r, err := s.run(pipelineIdStr, runIdStr)
+	if err != nil {
+		return "", err
+	}
+
+	st := r.state(s.now())
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pipeline Run Logs - Run ID: %s\n", runIdStr)
+	fmt.Fprintf(&b, "Pipeline ID: %s\n", pipelineIdStr)
+	fmt.Fprintf(&b, "Status: %s\n", st.status)
+	b.WriteString(strings.Repeat("=", 81) + "\n\n")
+	for si, jobs := range r.stages {
+		fmt.Fprintf(&b, "[yellow]Stage: %s (%d)[-]\n", r.pipeline.stages[si].Name, si+1)
+		for ji, j := range jobs {
+			js := st.jobs[si][ji]
+			fmt.Fprintf(&b, "[yellow]Job: %s (ID: %d) Status: %s[-]\n", j.spec.Name, j.id, js.status)
+			b.WriteString(j.log(js))
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// GetVMDeployOrder returns the deployment order of a VM deployment job.
+func (s *Service) GetVMDeployOrder(organizationId, pipelineId, deployOrderId string) (*api.VMDeployOrder, error) {
+	return s.GetVMDeployOrderContext(context.Background(), organizationId, pipelineId, deployOrderId)
+}
+
+// GetVMDeployOrderContext is like GetVMDeployOrder but carries ctx.
+func (s *Service) GetVMDeployOrderContext(ctx context.Context, organizationId, pipelineId, deployOrderId string) (*api.VMDeployOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetVMDeployOrder", organizationId); err != nil {
+		return nil, err
+	}
+	j, ok := s.deploys[deployOrderId]
+	if !ok || j.run.pipeline.PipelineID != pipelineId {
+		return nil, fmt.Errorf("deploy order %s: %w", deployOrderId, api.ErrNotFound)
+	}
+	js, _ := s.jobState(j)
+	return j.deployOrder(js), nil
+}
+
+// deployOrder simulates the deployment progress: machines are deployed batch
+// by batch over the job's duration. When the job fails, the first machine of
+// the last batch fails.
+func (j *job) deployOrder(js jobState) *api.VMDeployOrder {
+	spec := j.spec.Deploy
+	batches := spec.Batches
+	if batches < 1 {
+		batches = 1
+	}
+	id, _ := strconv.Atoi(j.deployID)
+
+	current := int(js.progress*float64(batches)) + 1
+	if current > batches {
+		current = batches
+	}
+	if js.paused {
+		// The first batch is done and the next one waits for the resume
+		current = 2
+	}
+
+	order := &api.VMDeployOrder{
+		DeployOrderId: id,
+		Status:        js.status,
+		Creator:       "demo",
+		CreateTime:    js.startTime.UnixMilli(),
+		UpdateTime:    js.startTime.UnixMilli(),
+		CurrentBatch:  current,
+		TotalBatch:    batches,
+		DeployMachineInfo: api.VMDeployMachineInfo{
+			BatchNum:    batches,
+			HostGroupId: 100 + id%100,
+		},
+	}
+	if !js.endTime.IsZero() {
+		order.UpdateTime = js.endTime.UnixMilli()
+	}
+	switch {
+	case js.paused:
+		order.Status = "PAUSE"
+	case !j.rolledBackAt.IsZero() && !js.endTime.IsZero():
+		order.Status = "ROLLBACK"
+		order.UpdateTime = j.rolledBackAt.UnixMilli()
+	}
+
+	for i, ip := range spec.Machines {
+		batch := i*batches/len(spec.Machines) + 1
+		status := "WAITING"
+		switch {
+		case js.status == "SUCCESS" || batch < current:
+			status = "SUCCESS"
+		case batch > current || js.paused:
+			status = "WAITING"
+		case js.status == "RUNNING":
+			status = "RUNNING"
+		case js.status == "CANCELED":
+			status = "CANCELED"
+		case js.status == "FAILED":
+			status = "SUCCESS"
+			if i == firstMachineOfBatch(len(spec.Machines), batches, batch) {
+				status = "FAILED"
+			}
+		}
+		order.DeployMachineInfo.DeployMachines = append(order.DeployMachineInfo.DeployMachines, api.VMDeployMachine{
+			IP:           ip,
+			MachineSn:    machineSn(ip),
+			Status:       status,
+			ClientStatus: "ONLINE",
+			BatchNum:     batch,
+			CreateTime:   order.CreateTime,
+			UpdateTime:   order.UpdateTime,
+		})
+	}
+	return order
+}
+
+// failedMachine returns the serial number of the machine a failed deployment
+// fails on, the first one of its last batch
+func (j *job) failedMachine() (string, bool) {
+	spec := j.spec.Deploy
+	batches := max(spec.Batches, 1)
+	i := firstMachineOfBatch(len(spec.Machines), batches, batches)
+	if i < 0 {
+		return "", false
+	}
+	return machineSn(spec.Machines[i]), true
+}
+
+func firstMachineOfBatch(machines, batches, batch int) int {
+	for i := 0; i < machines; i++ {
+		if i*batches/machines+1 == batch {
+			return i
+		}
+	}
+	return -1
+}
+
+func machineSn(ip string) string {
+	return "sn-" + strings.ReplaceAll(ip, ".", "-")
+}
+
+// GetVMDeployMachineLog returns the deployment log of one machine.
+func (s *Service) GetVMDeployMachineLog(organizationId, pipelineId, deployOrderId, machineSn string) (*api.VMDeployMachineLog, error) {
+	return s.GetVMDeployMachineLogContext(context.Background(), organizationId, pipelineId, deployOrderId, machineSn)
+}
+
+// GetVMDeployMachineLogContext is like GetVMDeployMachineLog but carries ctx.
+func (s *Service) GetVMDeployMachineLogContext(ctx context.Context, organizationId, pipelineId, deployOrderId, sn string) (*api.VMDeployMachineLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "GetVMDeployMachineLog", organizationId); err != nil {
+		return nil, err
+	}
+	j, ok := s.deploys[deployOrderId]
+	if !ok || j.run.pipeline.PipelineID != pipelineId {
+		return nil, fmt.Errorf("deploy order %s: %w", deployOrderId, api.ErrNotFound)
+	}
+	js, _ := s.jobState(j)
+	order := j.deployOrder(js)
+
+	for _, m := range order.DeployMachineInfo.DeployMachines {
+		if m.MachineSn != sn {
+			continue
+		}
+		log := &api.VMDeployMachineLog{
+			AliyunRegion:  "cn-hangzhou",
+			DeployLogPath: "/home/admin/app/deploy.log",
+		}
+		var b strings.Builder
+		if m.Status != "WAITING" {
+			log.DeployBeginTime = js.startTime.Format("2006-01-02 15:04:05")
+			fmt.Fprintf(&b, "[%s] Downloading package to %s\n", log.DeployBeginTime, m.IP)
+			b.WriteString("Stopping application\n")
+		}
+		switch m.Status {
+		case "SUCCESS":
+			b.WriteString("Starting application\nHealth check passed\n")
+			log.DeployEndTime = time.UnixMilli(order.UpdateTime).Format("2006-01-02 15:04:05")
+		case "FAILED":
+			b.WriteString("Starting application\nERROR: health check failed after 3 attempts\n")
+			log.DeployEndTime = time.UnixMilli(order.UpdateTime).Format("2006-01-02 15:04:05")
+		case "CANCELED":
+			b.WriteString("Deployment canceled\n")
+		}
+		if order.Status == "ROLLBACK" && m.Status != "WAITING" {
+			fmt.Fprintf(&b, "[%s] Rolled back to the previous version\n", j.rolledBackAt.Format("2006-01-02 15:04:05"))
+		}
+		log.DeployLog = b.String()
+		return log, nil
+	}
+	return nil, fmt.Errorf("machine %s in deploy order %s: %w", sn, deployOrderId, api.ErrNotFound)
+}
+
+// PipelineID returns the ID of the pipeline with the given name.
+func (s *Service) PipelineID(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pipelines {
+		if p.Name == name {
+			return p.PipelineID, true
+		}
+	}
+	return "", false
+}
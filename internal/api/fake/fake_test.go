@@ -0,0 +1,542 @@
+package fake
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+const org = "org"
+
+func newService(t *testing.T) (*Service, *Clock, string) {
+	t.Helper()
+	s, c := NewWithClock()
+	g := s.AddGroup("group")
+	p := s.AddPipeline("svc", []StageSpec{
+		{Name: "Build", Jobs: []JobSpec{{Name: "build", Duration: 10 * time.Second, Log: []string{"a", "b", "c", "d"}}}},
+		{Name: "Test", Jobs: []JobSpec{
+			{Name: "unit", Duration: 10 * time.Second},
+			{Name: "e2e", Duration: 20 * time.Second},
+		}},
+		{Name: "Deploy", Jobs: []JobSpec{{Name: "deploy", Duration: 10 * time.Second, Deploy: &DeploySpec{Machines: []string{"10.0.0.1", "10.0.0.2"}, Batches: 2}}}},
+	}, map[string]string{"https://example.com/svc.git": "master"}, g.GroupID)
+	return s, c, p.PipelineID
+}
+
+func runStatus(t *testing.T, s *Service, pipelineID, runID string) string {
+	t.Helper()
+	run, err := s.GetPipelineRun(org, pipelineID, runID)
+	if err != nil {
+		t.Fatalf("GetPipelineRun: %v", err)
+	}
+	return run.Status
+}
+
+func TestRunLifecycle(t *testing.T) {
+	s, c, pid := newService(t)
+
+	run, err := s.RunPipeline(org, pid, map[string]string{"runningBranchs": `{"https://example.com/svc.git":"feature/x"}`})
+	if err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+
+	details, err := s.GetPipelineRunDetails(org, pid, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRunDetails: %v", err)
+	}
+	if got := []string{details.Status, details.Stages[0].Jobs[0].Status, details.Stages[1].Jobs[0].Status}; strings.Join(got, ",") != "RUNNING,RUNNING,INIT" {
+		t.Errorf("statuses at start = %v", got)
+	}
+
+	c.Advance(15 * time.Second)
+	details, _ = s.GetPipelineRunDetails(org, pid, run.RunID)
+	if got := []string{details.Stages[0].Jobs[0].Status, details.Stages[1].Jobs[0].Status, details.Stages[1].Jobs[1].Status}; strings.Join(got, ",") != "SUCCESS,RUNNING,RUNNING" {
+		t.Errorf("statuses after 15s = %v", got)
+	}
+
+	c.Advance(20 * time.Second)
+	details, _ = s.GetPipelineRunDetails(org, pid, run.RunID)
+	if got := details.Stages[1].Jobs[0].Status + "," + details.Stages[2].Jobs[0].Status; got != "SUCCESS,RUNNING" {
+		t.Errorf("statuses after 35s = %v", got)
+	}
+	if len(details.Stages[2].Jobs[0].Actions) != 1 {
+		t.Errorf("deploy job should expose its deploy order action, got %+v", details.Stages[2].Jobs[0].Actions)
+	}
+
+	c.Advance(10 * time.Second)
+	if got := runStatus(t, s, pid, run.RunID); got != "SUCCESS" {
+		t.Errorf("final status = %s, want SUCCESS", got)
+	}
+
+	info, err := s.GetLatestPipelineRunInfo(org, pid)
+	if err != nil {
+		t.Fatalf("GetLatestPipelineRunInfo: %v", err)
+	}
+	if info.RepositoryURLs["https://example.com/svc.git"] != "feature/x" {
+		t.Errorf("RepositoryURLs = %v", info.RepositoryURLs)
+	}
+}
+
+func TestFailedJobStopsLaterStages(t *testing.T) {
+	s, c, pid := newService(t)
+	runID, err := s.AddRun(pid, c.Now(), RunOptions{FailJobs: []string{"unit"}})
+	if err != nil {
+		t.Fatalf("AddRun: %v", err)
+	}
+
+	// The stage finishes when e2e is done, then the run fails
+	c.Advance(25 * time.Second)
+	if got := runStatus(t, s, pid, runID); got != "RUNNING" {
+		t.Errorf("status while e2e runs = %s, want RUNNING", got)
+	}
+	c.Advance(10 * time.Second)
+	details, _ := s.GetPipelineRunDetails(org, pid, runID)
+	if details.Status != "FAILED" || details.Stages[1].Jobs[0].Status != "FAILED" || details.Stages[2].Jobs[0].Status != "INIT" {
+		t.Errorf("unexpected details: run %s, unit %s, deploy %s", details.Status, details.Stages[1].Jobs[0].Status, details.Stages[2].Jobs[0].Status)
+	}
+
+	log, _ := s.GetPipelineJobRunLog(org, pid, runID, strconv.FormatInt(details.Stages[1].Jobs[0].ID, 10))
+	if !strings.Contains(log, "ERROR") {
+		t.Errorf("failed job log lacks an error line:\n%s", log)
+	}
+}
+
+func TestStopPipelineRun(t *testing.T) {
+	s, c, pid := newService(t)
+	run, _ := s.RunPipeline(org, pid, nil)
+
+	c.Advance(5 * time.Second)
+	if err := s.StopPipelineRun(org, pid, run.RunID); err != nil {
+		t.Fatalf("StopPipelineRun: %v", err)
+	}
+	c.Advance(time.Minute)
+
+	details, _ := s.GetPipelineRunDetails(org, pid, run.RunID)
+	if details.Status != "CANCELED" || details.Stages[0].Jobs[0].Status != "CANCELED" || details.Stages[1].Jobs[0].Status != "INIT" {
+		t.Errorf("unexpected details after stop: run %s, build %s, unit %s", details.Status, details.Stages[0].Jobs[0].Status, details.Stages[1].Jobs[0].Status)
+	}
+
+	if err := s.StopPipelineRun(org, pid, run.RunID); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("stopping a finished run: err = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestJobActions(t *testing.T) {
+	s, c, pid := newService(t)
+	runID, _ := s.AddRun(pid, c.Now(), RunOptions{FailJobs: []string{"unit"}})
+	details, _ := s.GetPipelineRunDetails(org, pid, runID)
+	unit := strconv.FormatInt(details.Stages[1].Jobs[0].ID, 10)
+	deploy := strconv.FormatInt(details.Stages[2].Jobs[0].ID, 10)
+	statuses := func() string {
+		details, _ := s.GetPipelineRunDetails(org, pid, runID)
+		return details.Status + " " + details.Stages[1].Jobs[0].Status + " " + details.Stages[2].Jobs[0].Status
+	}
+
+	c.Advance(35 * time.Second)
+	if err := s.StopPipelineJobRun(org, pid, runID, unit); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("stopping a failed job: err = %v, want ErrBadRequest", err)
+	}
+
+	// The retried job passes and the run goes on with the next stage
+	if err := s.RetryPipelineJobRun(org, pid, runID, unit); err != nil {
+		t.Fatalf("RetryPipelineJobRun: %v", err)
+	}
+	c.Advance(5 * time.Second)
+	if got := statuses(); got != "RUNNING RUNNING INIT" {
+		t.Errorf("while retrying: %s", got)
+	}
+	c.Advance(10 * time.Second)
+	if got := statuses(); got != "RUNNING SUCCESS RUNNING" {
+		t.Errorf("after the retry: %s", got)
+	}
+
+	// A stopped job cancels the run until it is skipped
+	if err := s.StopPipelineJobRun(org, pid, runID, deploy); err != nil {
+		t.Fatalf("StopPipelineJobRun: %v", err)
+	}
+	if got := statuses(); got != "CANCELED SUCCESS CANCELED" {
+		t.Errorf("after the stop: %s", got)
+	}
+	if err := s.SkipPipelineJobRun(org, pid, runID, deploy); err != nil {
+		t.Fatalf("SkipPipelineJobRun: %v", err)
+	}
+	if got := statuses(); got != "SUCCESS SUCCESS SKIPPED" {
+		t.Errorf("after the skip: %s", got)
+	}
+	if err := s.RetryPipelineJobRun(org, pid, runID, deploy); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("retrying a skipped job: err = %v, want ErrBadRequest", err)
+	}
+	if err := s.SkipPipelineJobRun(org, pid, runID, "1"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("skipping an unknown job: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidationGate(t *testing.T) {
+	s, c := NewWithClock()
+	p := s.AddPipeline("release", []StageSpec{
+		{Name: "Approve", Jobs: []JobSpec{{Name: "approval", Validate: &ValidateSpec{Description: "Release to production?", Validators: []string{"alice"}}}}},
+		{Name: "Deploy", Jobs: []JobSpec{{Name: "deploy", Duration: 10 * time.Second}}},
+	}, nil)
+	run, _ := s.RunPipeline(org, p.PipelineID, nil)
+	details, _ := s.GetPipelineRunDetails(org, p.PipelineID, run.RunID)
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	// The gate waits however long it takes
+	c.Advance(time.Hour)
+	details, _ = s.GetPipelineRunDetails(org, p.PipelineID, run.RunID)
+	gate, ok := details.Stages[0].Jobs[0].ValidationGate()
+	if details.Status != "WAITING" || details.Stages[0].Jobs[0].Status != "WAITING" || !ok {
+		t.Fatalf("waiting run: status %s, gate %s, actions %+v", details.Status, details.Stages[0].Jobs[0].Status, details.Stages[0].Jobs[0].Actions)
+	}
+	if gate.Description != "Release to production?" || strings.Join(gate.Validators, ",") != "alice" || !gate.Allowed {
+		t.Errorf("unexpected gate: %+v", gate)
+	}
+
+	if err := s.PassPipelineValidate(org, p.PipelineID, run.RunID, jobID, "looks good"); err != nil {
+		t.Fatalf("PassPipelineValidate: %v", err)
+	}
+	c.Advance(5 * time.Second)
+	details, _ = s.GetPipelineRunDetails(org, p.PipelineID, run.RunID)
+	if details.Status != "RUNNING" || details.Stages[0].Jobs[0].Status != "SUCCESS" || details.Stages[1].Jobs[0].Status != "RUNNING" {
+		t.Errorf("after passing: run %s, gate %s, deploy %s", details.Status, details.Stages[0].Jobs[0].Status, details.Stages[1].Jobs[0].Status)
+	}
+	if _, ok := details.Stages[0].Jobs[0].ValidationGate(); ok {
+		t.Error("a decided gate still offers the decision")
+	}
+	if log, _ := s.GetPipelineJobRunLog(org, p.PipelineID, run.RunID, jobID); !strings.Contains(log, "Passed by demo: looks good") {
+		t.Errorf("the decision is not in the log:\n%s", log)
+	}
+	if err := s.RefusePipelineValidate(org, p.PipelineID, run.RunID, jobID, ""); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("deciding twice: err = %v, want ErrBadRequest", err)
+	}
+
+	// Refusing fails the run; a user who is not a validator cannot decide
+	forbidden := s.AddPipeline("locked", []StageSpec{
+		{Name: "Approve", Jobs: []JobSpec{{Name: "approval", Validate: &ValidateSpec{Forbidden: true}}}},
+	}, nil)
+	run, _ = s.RunPipeline(org, forbidden.PipelineID, nil)
+	details, _ = s.GetPipelineRunDetails(org, forbidden.PipelineID, run.RunID)
+	jobID = strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+	if err := s.RefusePipelineValidate(org, forbidden.PipelineID, run.RunID, jobID, "no"); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("refusing without permission: err = %v, want ErrForbidden", err)
+	}
+	if gate, _ := details.Stages[0].Jobs[0].ValidationGate(); gate == nil || gate.Allowed {
+		t.Errorf("the gate should not be allowed: %+v", gate)
+	}
+}
+
+func TestDeployJobActions(t *testing.T) {
+	s, c := NewWithClock()
+	machines := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}
+	p := s.AddPipeline("release", []StageSpec{
+		{Name: "Deploy", Jobs: []JobSpec{{Name: "deploy", Duration: 40 * time.Second, Deploy: &DeploySpec{Machines: machines, Batches: 4, Pause: true}}}},
+	}, nil)
+	runID, _ := s.AddRun(p.PipelineID, c.Now(), RunOptions{FailJobs: []string{"deploy"}})
+	job := func() api.Job {
+		details, _ := s.GetPipelineRunDetails(org, p.PipelineID, runID)
+		return details.Stages[0].Jobs[0]
+	}
+	offered := func(j api.Job, typ string) (api.JobAction, bool) {
+		for _, a := range j.Actions {
+			if a.Type == typ && !a.Disable {
+				return a, true
+			}
+		}
+		return api.JobAction{}, false
+	}
+	jobID := strconv.FormatInt(job().ID, 10)
+	link, _ := offered(job(), "GetVMDeployOrder")
+	deployID := strconv.FormatFloat(link.Params["deployOrderId"].(float64), 'f', 0, 64)
+
+	// The deployment pauses after its first batch, however long that takes
+	c.Advance(time.Hour)
+	j := job()
+	order, _ := s.GetVMDeployOrder(org, p.PipelineID, deployID)
+	if j.Status != "RUNNING" || order.Status != "PAUSE" || order.CurrentBatch != 2 {
+		t.Fatalf("paused deployment: job %s, order %s, batch %d", j.Status, order.Status, order.CurrentBatch)
+	}
+	if got := order.DeployMachineInfo.DeployMachines[0].Status + " " + order.DeployMachineInfo.DeployMachines[1].Status; got != "SUCCESS WAITING" {
+		t.Errorf("machines while paused: %s", got)
+	}
+	if _, ok := offered(j, "RollbackVMDeployOrder"); ok {
+		t.Error("a running deployment offers a rollback")
+	}
+	rollback := api.JobAction{Type: "RollbackVMDeployOrder"}
+	if err := s.ExecutePipelineJobAction(org, p.PipelineID, runID, jobID, rollback); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("rolling back a running deployment: err = %v, want ErrBadRequest", err)
+	}
+	resume, ok := offered(j, "ResumeVMDeployOrder")
+	if !ok {
+		t.Fatalf("the paused deployment offers no resume: %+v", j.Actions)
+	}
+	if err := s.ExecutePipelineJobAction(org, p.PipelineID, runID, jobID, resume); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+
+	// The rest of the deployment takes the rest of the duration, then fails
+	c.Advance(29 * time.Second)
+	if j := job(); j.Status != "RUNNING" {
+		t.Errorf("resumed deployment: %s", j.Status)
+	}
+	c.Advance(time.Second)
+	j = job()
+	retry, ok := offered(j, "RetryVMDeployMachine")
+	if j.Status != "FAILED" || !ok || retry.Params["machineSn"] != "sn-10-0-0-4" {
+		t.Fatalf("failed deployment: status %s, actions %+v", j.Status, j.Actions)
+	}
+	if err := s.ExecutePipelineJobAction(org, p.PipelineID, runID, jobID, rollback); err != nil {
+		t.Fatalf("rolling back: %v", err)
+	}
+	if order, _ := s.GetVMDeployOrder(org, p.PipelineID, deployID); order.Status != "ROLLBACK" {
+		t.Errorf("rolled back order: %s", order.Status)
+	}
+	if _, ok := offered(job(), "RollbackVMDeployOrder"); ok {
+		t.Error("a deployment can only be rolled back once")
+	}
+
+	// Retrying the machine deploys again without pausing, and passes
+	if err := s.ExecutePipelineJobAction(org, p.PipelineID, runID, jobID, retry); err != nil {
+		t.Fatalf("retrying the machine: %v", err)
+	}
+	c.Advance(40 * time.Second)
+	if j := job(); j.Status != "SUCCESS" {
+		t.Errorf("after retrying the machine: %s", j.Status)
+	}
+	if err := s.ExecutePipelineJobAction(org, p.PipelineID, runID, jobID, api.JobAction{Type: "GetVMDeployOrder"}); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("executing a link: err = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestPipelineDefinitionAndVariables(t *testing.T) {
+	s, c, pid := newService(t)
+	if err := s.SetVariables(pid,
+		api.PipelineVariable{Name: "DEPLOY_ENV", Value: "staging", Runtime: true},
+		api.PipelineVariable{Name: "REGISTRY", Value: "registry.example.com"},
+	); err != nil {
+		t.Fatalf("SetVariables: %v", err)
+	}
+
+	d, err := s.GetPipeline(org, pid)
+	if err != nil {
+		t.Fatalf("GetPipeline: %v", err)
+	}
+	if len(d.Sources) != 1 || d.Sources[0].Repo != "https://example.com/svc.git" || d.Sources[0].Name != "svc" || len(d.RuntimeVariables()) != 1 {
+		t.Errorf("definition = %+v", d)
+	}
+
+	params, _ := api.RunParams(nil, map[string]string{"DEPLOY_ENV": "prod"})
+	run, err := s.RunPipeline(org, pid, params)
+	if err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+	c.Advance(time.Second)
+	details, _ := s.GetPipelineRunDetails(org, pid, run.RunID)
+	log, _ := s.GetPipelineJobRunLog(org, pid, run.RunID, strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10))
+	if !strings.Contains(log, "Variables: DEPLOY_ENV=prod REGISTRY=registry.example.com") {
+		t.Errorf("the run's variables are not in its log:\n%s", log)
+	}
+}
+
+func TestRepositoryRefs(t *testing.T) {
+	s, _, pid := newService(t)
+	const repo = "https://example.com/svc.git"
+	params, _ := api.RunParams(map[string]string{repo: "feature/login"}, nil)
+	if _, err := s.RunPipeline(org, pid, params); err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+
+	branches, err := s.ListRepositoryBranches(org, repo)
+	if err != nil {
+		t.Fatalf("ListRepositoryBranches: %v", err)
+	}
+	if len(branches) != 2 || branches[0].Name != "feature/login" || branches[1].Name != "master" {
+		t.Errorf("branches built by the pipeline = %+v", branches)
+	}
+	if byPath, _ := s.ListRepositoryBranches(org, "svc"); len(byPath) != 2 {
+		t.Errorf("branches by full path = %+v", byPath)
+	}
+
+	s.SetRefs(repo, []string{"main"}, []string{"v1.0.0"})
+	branches, _ = s.ListRepositoryBranches(org, repo)
+	tags, _ := s.ListRepositoryTags(org, repo)
+	if len(branches) != 1 || branches[0].Name != "main" || len(tags) != 1 || tags[0].Name != "v1.0.0" {
+		t.Errorf("refs set = %+v, %+v", branches, tags)
+	}
+	if _, err := s.ListRepositoryTags(org, "https://example.com/nope.git"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown repository: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestJobLogGrows(t *testing.T) {
+	s, c, pid := newService(t)
+	run, _ := s.RunPipeline(org, pid, nil)
+	details, _ := s.GetPipelineRunDetails(org, pid, run.RunID)
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	var lengths []int
+	for i := 0; i < 3; i++ {
+		log, err := s.GetPipelineJobRunLog(org, pid, run.RunID, jobID)
+		if err != nil {
+			t.Fatalf("GetPipelineJobRunLog: %v", err)
+		}
+		lengths = append(lengths, len(log))
+		c.Advance(5 * time.Second)
+	}
+	if !(lengths[0] < lengths[1] && lengths[1] < lengths[2]) {
+		t.Errorf("log lengths %v should grow", lengths)
+	}
+
+	log, _ := s.GetPipelineJobRunLog(org, pid, run.RunID, jobID)
+	if !strings.HasSuffix(log, "finished successfully\n") {
+		t.Errorf("finished job log = %q", log)
+	}
+}
+
+func TestJobSteps(t *testing.T) {
+	s, c := NewWithClock()
+	p := s.AddPipeline("steps", []StageSpec{{Name: "Build", Jobs: []JobSpec{{
+		Name:     "build",
+		Duration: 30 * time.Second,
+		Fail:     true,
+		Steps: []StepSpec{
+			{Name: "checkout", Log: []string{"cloning"}},
+			{Name: "compile", Log: []string{"compiling", "compiled"}},
+			{Name: "test", Log: []string{"testing"}},
+		},
+	}}}}, nil)
+	run, _ := s.RunPipeline(org, p.PipelineID, nil)
+	details, _ := s.GetPipelineRunDetails(org, p.PipelineID, run.RunID)
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	statuses := func() string {
+		steps, err := s.GetPipelineJobSteps(org, p.PipelineID, run.RunID, jobID)
+		if err != nil {
+			t.Fatalf("GetPipelineJobSteps: %v", err)
+		}
+		var list []string
+		for _, step := range steps {
+			list = append(list, step.Name+"="+step.Status)
+		}
+		return strings.Join(list, " ")
+	}
+
+	c.Advance(15 * time.Second)
+	if got := statuses(); got != "checkout=SUCCESS compile=RUNNING test=INIT" {
+		t.Errorf("steps halfway = %s", got)
+	}
+	if log, _ := s.GetPipelineJobStepLog(org, p.PipelineID, run.RunID, jobID, "2"); log != "compiling\n" {
+		t.Errorf("log of the running step = %q", log)
+	}
+
+	c.Advance(15 * time.Second)
+	if got := statuses(); got != "checkout=SUCCESS compile=SUCCESS test=FAILED" {
+		t.Errorf("steps at the end = %s", got)
+	}
+	if log, _ := s.GetPipelineJobStepLog(org, p.PipelineID, run.RunID, jobID, "3"); !strings.Contains(log, "testing\nERROR: step test failed") {
+		t.Errorf("log of the failed step = %q", log)
+	}
+	if _, err := s.GetPipelineJobStepLog(org, p.PipelineID, run.RunID, jobID, "4"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown step: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestVMDeployOrder(t *testing.T) {
+	s, c, pid := newService(t)
+	run, _ := s.RunPipeline(org, pid, nil)
+	c.Advance(31 * time.Second)
+
+	details, _ := s.GetPipelineRunDetails(org, pid, run.RunID)
+	action := details.Stages[2].Jobs[0].Actions[0]
+	deployID := strconv.FormatFloat(action.Params["deployOrderId"].(float64), 'f', 0, 64)
+
+	order, err := s.GetVMDeployOrder(org, pid, deployID)
+	if err != nil {
+		t.Fatalf("GetVMDeployOrder: %v", err)
+	}
+	machines := order.DeployMachineInfo.DeployMachines
+	if order.Status != "RUNNING" || order.CurrentBatch != 1 || order.TotalBatch != 2 || machines[0].Status != "RUNNING" || machines[1].Status != "WAITING" {
+		t.Errorf("unexpected order in first batch: %+v", order)
+	}
+
+	c.Advance(10 * time.Second)
+	order, _ = s.GetVMDeployOrder(org, pid, deployID)
+	if order.Status != "SUCCESS" || order.DeployMachineInfo.DeployMachines[1].Status != "SUCCESS" {
+		t.Errorf("unexpected finished order: %+v", order)
+	}
+
+	log, err := s.GetVMDeployMachineLog(org, pid, deployID, order.DeployMachineInfo.DeployMachines[0].MachineSn)
+	if err != nil {
+		t.Fatalf("GetVMDeployMachineLog: %v", err)
+	}
+	if !strings.Contains(log.DeployLog, "Health check passed") {
+		t.Errorf("machine log = %q", log.DeployLog)
+	}
+}
+
+func TestListingAndErrors(t *testing.T) {
+	s, c, pid := newService(t)
+	s.RunPipeline(org, pid, nil)
+	other := s.AddPipeline("idle", nil, nil)
+
+	running, err := s.ListPipelinesWithStatus(org, []string{"RUNNING", "WAITING"})
+	if err != nil || len(running) != 1 || running[0].PipelineID != pid {
+		t.Errorf("running pipelines = %v, %v", running, err)
+	}
+
+	var pages int
+	err = s.ListPipelinesWithCallback(org, func(pipelines []api.Pipeline, page, total int, complete bool) error {
+		pages++
+		if len(pipelines) != 2 || !complete {
+			t.Errorf("page %d/%d: %d pipelines, complete=%v", page, total, len(pipelines), complete)
+		}
+		return nil
+	})
+	if err != nil || pages != 1 {
+		t.Errorf("ListPipelinesWithCallback: pages=%d err=%v", pages, err)
+	}
+
+	groups, _ := s.ListPipelineGroups(org)
+	groupID, _ := strconv.Atoi(groups[0].GroupID)
+	inGroup, _ := s.ListPipelineGroupPipelines(org, groupID, nil)
+	if len(inGroup) != 1 || inGroup[0].Name != "svc" {
+		t.Errorf("group pipelines = %v", inGroup)
+	}
+
+	c.Advance(time.Hour)
+	if runs, _ := s.ListPipelineRuns(org, pid); len(runs) != 1 || runs[0].Status != "SUCCESS" {
+		t.Errorf("runs = %v", runs)
+	}
+
+	if _, err := s.GetLatestPipelineRun(org, other.PipelineID); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("latest run of idle pipeline: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetPipelineRun(org, pid, "999"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown run: err = %v, want ErrNotFound", err)
+	}
+
+	injected := errors.New("boom")
+	s.SetError("RunPipeline", injected)
+	if _, err := s.RunPipeline(org, pid, nil); !errors.Is(err, injected) {
+		t.Errorf("injected error: got %v", err)
+	}
+	s.SetError("RunPipeline", nil)
+	if _, err := s.RunPipeline(org, pid, nil); err != nil {
+		t.Errorf("after clearing the injected error: %v", err)
+	}
+}
+
+func TestDemoSeed(t *testing.T) {
+	s := NewDemo()
+	pipelines, err := s.ListPipelines(DemoOrganizationID)
+	if err != nil || len(pipelines) == 0 {
+		t.Fatalf("demo pipelines = %v, %v", pipelines, err)
+	}
+	running, _ := s.ListPipelinesWithStatus(DemoOrganizationID, []string{"RUNNING"})
+	if len(running) == 0 {
+		t.Error("demo should have a run in progress")
+	}
+}
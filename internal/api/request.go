@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed token-authenticated requests are retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first one; 1 disables retries
+	InitialBackoff time.Duration // Delay before the first retry, doubled on every further retry
+	MaxBackoff     time.Duration // Upper bound for a single wait, including Retry-After
+}
+
+// DefaultRetryPolicy is the retry policy used by new clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     15 * time.Second,
+}
+
+// apiRequest describes a single Yunxiao OpenAPI call made with the personal access token.
+type apiRequest struct {
+	name   string      // Operation name used in debug logs, e.g. "ListPipelines"
+	method string      // HTTP method
+	path   string      // Path below the endpoint, including the query string
+	body   interface{} // Marshalled to JSON when non-nil
+
+	// idempotent marks calls that may be repeated after they possibly reached
+	// the server. Calls that are not idempotent (running or stopping a
+	// pipeline) are only retried when the server explicitly rejected them
+	// with 429 before doing any work.
+	idempotent bool
+}
+
+// apiResponse is a successful response with its body fully read.
+type apiResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// SetRetryPolicy replaces the retry policy used for token-authenticated requests.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	c.retryPolicy = policy
+}
+
+// baseURL returns the scheme and host requests are sent to. The endpoint is
+// normally a bare host name served over HTTPS, but a full URL such as
+// http://127.0.0.1:8080 is accepted too, which is handy for local stand-ins.
+func (c *Client) baseURL() string {
+	if strings.HasPrefix(c.endpoint, "http://") || strings.HasPrefix(c.endpoint, "https://") {
+		return strings.TrimRight(c.endpoint, "/")
+	}
+	return "https://" + c.endpoint
+}
+
+// do sends req through the shared request pipeline: it sets the authentication
+// headers, applies the per-request deadline, logs in debug mode, retries
+// transient failures according to the retry policy and returns an *APIError
+// for every non-2xx or non-API response.
+func (c *Client) do(ctx context.Context, req *apiRequest) (*apiResponse, error) {
+	if !c.useToken {
+		return nil, fmt.Errorf("client not configured for token-based requests")
+	}
+
+	var payload []byte
+	if req.body != nil {
+		var err error
+		payload, err = json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	policy := c.retryPolicy
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, retryAfter, err := c.doOnce(ctx, req, payload, attempt)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= policy.MaxAttempts || !c.shouldRetry(ctx, req, resp, err) {
+			return nil, err
+		}
+
+		wait := backoffDelay(policy, attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+			if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+				wait = policy.MaxBackoff
+			}
+		}
+
+		if os.Getenv("FLOWT_DEBUG") == "1" {
+			debugLogger.Printf("%s attempt %d failed: %v; retrying in %s", req.name, attempt, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (giving up retrying after: %w)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// doOnce performs a single attempt. When the API rejects the request it
+// returns the response alongside an *APIError so the caller can decide
+// whether to retry, together with the wait requested through a Retry-After
+// header.
+func (c *Client) doOnce(ctx context.Context, req *apiRequest, payload []byte, attempt int) (*apiResponse, time.Duration, error) {
+	reqCtx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	url := c.baseURL() + req.path
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, req.method, url, body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Authentication header for Aliyun DevOps API, see
+	// https://help.aliyun.com/zh/yunxiao/developer-reference/obtain-personal-access-token
+	httpReq.Header.Set("x-yunxiao-token", c.personalAccessToken)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "flowt-aliyun-devops-client/1.0")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if os.Getenv("FLOWT_DEBUG") == "1" {
+		debugLogger.Printf("%s %s %s (attempt %d)", req.name, req.method, url, attempt)
+		if payload != nil {
+			debugLogger.Printf("%s Request Body: %s", req.name, string(payload))
+		}
+		debugLogger.Printf("%s Response Status: %d", req.name, httpResp.StatusCode)
+		debugLogger.Printf("%s Response Headers: %v", req.name, httpResp.Header)
+		debugLogger.Printf("%s Response Body: %.1000s", req.name, string(respBody))
+	}
+
+	resp := &apiResponse{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+		Body:       respBody,
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		retryAfter := parseRetryAfter(httpResp.Header.Get("Retry-After"), time.Now())
+		return resp, retryAfter, newAPIError(req, resp)
+	}
+
+	// Every OpenAPI endpoint answers with JSON or a bare value; an HTML page
+	// means the request never reached the API
+	if looksLikeHTML(respBody) {
+		return resp, 0, newAPIError(req, resp)
+	}
+
+	return resp, 0, nil
+}
+
+// shouldRetry decides whether a failed attempt is worth repeating.
+func (c *Client) shouldRetry(ctx context.Context, req *apiRequest, resp *apiResponse, err error) bool {
+	// The caller gave up; a per-request timeout on the other hand is transient
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if resp == nil {
+		// Transport level failure. The request may or may not have reached the
+		// server, so only repeat it when doing so is harmless.
+		return req.idempotent && !errors.Is(err, context.Canceled)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// Rate limited requests were rejected before being processed
+		return true
+	case resp.StatusCode >= 500:
+		return req.idempotent
+	default:
+		return false
+	}
+}
+
+// backoffDelay returns the exponential backoff with jitter before retry number attempt.
+func backoffDelay(policy RetryPolicy, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if policy.MaxBackoff > 0 && delay >= policy.MaxBackoff {
+			delay = policy.MaxBackoff
+			break
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Up to 20% jitter so that concurrent callers don't retry in lockstep
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay - jitter
+}
+
+// parseRetryAfter interprets a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if wait := when.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// getRequest builds an idempotent GET request.
+func getRequest(name, path string) *apiRequest {
+	return &apiRequest{name: name, method: http.MethodGet, path: path, idempotent: true}
+}
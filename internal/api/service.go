@@ -0,0 +1,77 @@
+package api
+
+import "context"
+
+// PipelineService is the set of Yunxiao Flow operations the UI and the command
+// line work with. *Client implements it against the real API; the fake
+// package provides an in-memory implementation for tests and demos.
+type PipelineService interface {
+	ListPipelines(organizationId string) ([]Pipeline, error)
+	ListPipelinesContext(ctx context.Context, organizationId string) ([]Pipeline, error)
+	ListPipelinesWithStatus(organizationId string, statusList []string) ([]Pipeline, error)
+	ListPipelinesWithStatusContext(ctx context.Context, organizationId string, statusList []string) ([]Pipeline, error)
+	ListPipelinesWithCallback(organizationId string, callback PipelinePageCallback) error
+	ListPipelinesWithCallbackContext(ctx context.Context, organizationId string, callback PipelinePageCallback) error
+	ListPipelinesWithStatusAndCallback(organizationId string, statusList []string, callback PipelinePageCallback) error
+	ListPipelinesWithStatusAndCallbackContext(ctx context.Context, organizationId string, statusList []string, callback PipelinePageCallback) error
+	GetPipelineDetails(organizationId string, pipelineId string) (*Pipeline, error)
+	GetPipelineDetailsContext(ctx context.Context, organizationId string, pipelineId string) (*Pipeline, error)
+	GetPipeline(organizationId, pipelineId string) (*PipelineDefinition, error)
+	GetPipelineContext(ctx context.Context, organizationId, pipelineId string) (*PipelineDefinition, error)
+
+	ListRepositoryBranches(organizationId, repoURL string) ([]RepositoryRef, error)
+	ListRepositoryBranchesContext(ctx context.Context, organizationId, repoURL string) ([]RepositoryRef, error)
+	ListRepositoryTags(organizationId, repoURL string) ([]RepositoryRef, error)
+	ListRepositoryTagsContext(ctx context.Context, organizationId, repoURL string) ([]RepositoryRef, error)
+
+	ListPipelineGroups(organizationId string) ([]PipelineGroup, error)
+	ListPipelineGroupsContext(ctx context.Context, organizationId string) ([]PipelineGroup, error)
+	ListPipelineGroupPipelines(organizationId string, groupId int, options map[string]interface{}) ([]Pipeline, error)
+	ListPipelineGroupPipelinesContext(ctx context.Context, organizationId string, groupId int, options map[string]interface{}) ([]Pipeline, error)
+
+	RunPipeline(organizationId string, pipelineIdStr string, params map[string]string) (*PipelineRun, error)
+	RunPipelineContext(ctx context.Context, organizationId string, pipelineIdStr string, params map[string]string) (*PipelineRun, error)
+	StopPipelineRun(organizationId string, pipelineId string, runId string) error
+	StopPipelineRunContext(ctx context.Context, organizationId string, pipelineId string, runId string) error
+	RetryPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error
+	RetryPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error
+	SkipPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error
+	SkipPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error
+	StopPipelineJobRun(organizationId, pipelineId, pipelineRunId, jobId string) error
+	StopPipelineJobRunContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error
+	PassPipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error
+	PassPipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error
+	RefusePipelineValidate(organizationId, pipelineId, pipelineRunId, jobId, comment string) error
+	RefusePipelineValidateContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error
+	ExecutePipelineJobAction(organizationId, pipelineId, pipelineRunId, jobId string, action JobAction) error
+	ExecutePipelineJobActionContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string, action JobAction) error
+
+	GetLatestPipelineRun(organizationId, pipelineId string) (*PipelineRun, error)
+	GetLatestPipelineRunContext(ctx context.Context, organizationId, pipelineId string) (*PipelineRun, error)
+	GetLatestPipelineRunInfo(organizationId, pipelineId string) (*PipelineRunInfo, error)
+	GetLatestPipelineRunInfoContext(ctx context.Context, organizationId, pipelineId string) (*PipelineRunInfo, error)
+	GetPipelineRun(organizationId string, pipelineIdStr string, runIdStr string) (*PipelineRun, error)
+	GetPipelineRunContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (*PipelineRun, error)
+	GetPipelineRunDetails(organizationId, pipelineId, pipelineRunId string) (*PipelineRunDetails, error)
+	GetPipelineRunDetailsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId string) (*PipelineRunDetails, error)
+	ListPipelineRuns(organizationId string, pipelineId string) ([]PipelineRun, error)
+	ListPipelineRunsContext(ctx context.Context, organizationId string, pipelineId string) ([]PipelineRun, error)
+	ListPipelineJobHistorys(organizationId, pipelineId, category, identifier string, page, perPage int) ([]PipelineRun, error)
+	ListPipelineJobHistorysContext(ctx context.Context, organizationId, pipelineId, category, identifier string, page, perPage int) ([]PipelineRun, error)
+
+	GetPipelineJobRunLog(organizationId, pipelineId, pipelineRunId, jobId string) (string, error)
+	GetPipelineJobRunLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) (string, error)
+	GetPipelineJobSteps(organizationId, pipelineId, pipelineRunId, jobId string) ([]JobStep, error)
+	GetPipelineJobStepsContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) ([]JobStep, error)
+	GetPipelineJobStepLog(organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error)
+	GetPipelineJobStepLogContext(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, stepIndex string) (string, error)
+	GetPipelineRunLogs(organizationId string, pipelineIdStr string, runIdStr string) (string, error)
+	GetPipelineRunLogsContext(ctx context.Context, organizationId string, pipelineIdStr string, runIdStr string) (string, error)
+
+	GetVMDeployOrder(organizationId, pipelineId, deployOrderId string) (*VMDeployOrder, error)
+	GetVMDeployOrderContext(ctx context.Context, organizationId, pipelineId, deployOrderId string) (*VMDeployOrder, error)
+	GetVMDeployMachineLog(organizationId, pipelineId, deployOrderId, machineSn string) (*VMDeployMachineLog, error)
+	GetVMDeployMachineLogContext(ctx context.Context, organizationId, pipelineId, deployOrderId, machineSn string) (*VMDeployMachineLog, error)
+}
+
+var _ PipelineService = (*Client)(nil)
@@ -0,0 +1,66 @@
+// Package batch starts runs of many pipelines at once, such as the service
+// pipelines of a release, with a bounded number of RunPipeline calls in
+// flight.
+package batch
+
+import (
+	"context"
+	"sync"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+// DefaultWorkers is the number of pipelines started at the same time when no
+// other number is given.
+const DefaultWorkers = 4
+
+// Request is a pipeline to run and the parameters to run it with
+type Request struct {
+	PipelineID string
+	Params     map[string]string
+}
+
+// Result is the outcome of a Request: the run it started, or why it did not
+// start one
+type Result struct {
+	Run *api.PipelineRun
+	Err error
+}
+
+// Run starts a run of each request, with at most workers RunPipeline calls in
+// flight (DefaultWorkers if workers is not positive). started, if set, is
+// called as each call returns, from the goroutine that made it. Requests not
+// yet started when ctx is done fail with its error. The results are in the
+// order of the requests.
+func Run(ctx context.Context, service api.PipelineService, organizationId string, requests []Request, workers int, started func(i int, result Result)) []Result {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	results := make([]Result, len(requests))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(requests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var result Result
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					result.Run, result.Err = service.RunPipelineContext(ctx, organizationId, requests[i].PipelineID, requests[i].Params)
+				}
+				results[i] = result
+				if started != nil {
+					started(i, result)
+				}
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
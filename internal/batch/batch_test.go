@@ -0,0 +1,108 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+const org = "org"
+
+// countingService counts the RunPipeline calls in flight, holding each one
+// until release is closed
+type countingService struct {
+	api.PipelineService
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	release  chan struct{}
+}
+
+func (s *countingService) RunPipelineContext(ctx context.Context, organizationId, pipelineId string, params map[string]string) (*api.PipelineRun, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.max {
+		s.max = s.inFlight
+	}
+	s.mu.Unlock()
+	<-s.release
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return s.PipelineService.RunPipelineContext(ctx, organizationId, pipelineId, params)
+}
+
+func newService(t *testing.T, n int) (*fake.Service, []Request) {
+	t.Helper()
+	s := fake.New()
+	var requests []Request
+	for i := 0; i < n; i++ {
+		p := s.AddPipeline(fmt.Sprintf("svc-%d", i), []fake.StageSpec{
+			{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: time.Minute}}},
+		}, map[string]string{fmt.Sprintf("https://example.com/svc-%d.git", i): "master"})
+		params, _ := api.RunParams(map[string]string{fmt.Sprintf("https://example.com/svc-%d.git", i): "release/1.9"}, nil)
+		requests = append(requests, Request{PipelineID: p.PipelineID, Params: params})
+	}
+	return s, requests
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	s, requests := newService(t, 10)
+	service := &countingService{PipelineService: s, release: make(chan struct{})}
+
+	var mu sync.Mutex
+	var reported []int
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(service.release)
+	}()
+	results := Run(context.Background(), service, org, requests, 3, func(i int, result Result) {
+		mu.Lock()
+		reported = append(reported, i)
+		mu.Unlock()
+	})
+
+	if service.max != 3 {
+		t.Errorf("calls in flight = %d, want 3", service.max)
+	}
+	if len(reported) != len(requests) {
+		t.Errorf("reported %d results, want %d", len(reported), len(requests))
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Run == nil || result.Run.PipelineID != requests[i].PipelineID {
+			t.Errorf("result %d = %+v", i, result)
+			continue
+		}
+		info, _ := s.GetLatestPipelineRunInfo(org, requests[i].PipelineID)
+		if info.RepositoryURLs[fmt.Sprintf("https://example.com/svc-%d.git", i)] != "release/1.9" {
+			t.Errorf("pipeline %d ran %v", i, info.RepositoryURLs)
+		}
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	s, requests := newService(t, 2)
+	requests = append(requests, Request{PipelineID: "404"})
+
+	results := Run(context.Background(), s, org, requests, 0, nil)
+	if results[0].Err != nil || results[1].Err != nil {
+		t.Errorf("results = %+v", results)
+	}
+	if !errors.Is(results[2].Err, api.ErrNotFound) {
+		t.Errorf("unknown pipeline: err = %v, want ErrNotFound", results[2].Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, result := range Run(ctx, s, org, requests[:2], 1, nil) {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("request %d after cancel: err = %v", i, result.Err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package cli implements flowt's non-interactive subcommands, which script the
+// same api.PipelineService the terminal UI uses.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/preset"
+)
+
+// Runner executes subcommands against a pipeline service.
+type Runner struct {
+	Service        api.PipelineService
+	OrganizationID string
+	Presets        preset.Presets // Run presets from config.yml, for `flowt run --preset`
+	Schedules      string         // File the schedules of `flowt schedule` are kept in
+	Stdout         io.Writer
+	Stderr         io.Writer
+}
+
+// command is a subcommand; run receives the arguments after its name.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(r *Runner, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"pipelines list", "pipelines list [--status RUNNING,WAITING] [-o FORMAT]", "List pipelines", (*Runner).pipelinesList},
+	{"runs list", "runs list <pipeline> [--limit N] [-o FORMAT]", "List the runs of a pipeline, newest first", (*Runner).runsList},
+	{"runs get", "runs get <pipeline> <run> [-o FORMAT]", "Show the stages and jobs of a run", (*Runner).runsGet},
+	{"deploy-order get", "deploy-order get <pipeline> <deploy order> [-o FORMAT]", "Show a VM deploy order and its machines", (*Runner).deployOrderGet},
+	{"run", "run <pipeline> [--preset NAME] [--branch BRANCH] [--var NAME=VALUE]... [--param KEY=VALUE]... [--watch]", "Start a pipeline run and print its run ID", (*Runner).run},
+	{"watch", "watch <pipeline> [run] [--interval 5s] [--timeout 0] [--no-logs]", "Follow a run (default: the latest) until it finishes", (*Runner).watch},
+	{"stop", "stop <pipeline> <run>", "Stop a running pipeline run", (*Runner).stop},
+	{"logs", "logs <pipeline> <run> [--job NAME]", "Print the logs of a pipeline run", (*Runner).logs},
+	{"plan run", "plan run <plan.yml> [--restart] [--interval 5s]", "Run the pipelines of a plan in the order of their needs", (*Runner).planRun},
+	{"plan status", "plan status <plan.yml> [-o FORMAT]", "Show the saved progress of a plan", (*Runner).planStatus},
+	{"schedule add", "schedule add <pipeline> (--at TIME | --cron EXPR) [--preset NAME] [--branch BRANCH] [--var NAME=VALUE]...", "Schedule a run for `flowt schedule daemon` to start", (*Runner).scheduleAdd},
+	{"schedule list", "schedule list [-o FORMAT]", "List the scheduled runs and their last outcomes", (*Runner).scheduleList},
+	{"schedule rm", "schedule rm <id>", "Remove a scheduled run", (*Runner).scheduleRemove},
+	{"schedule daemon", "schedule daemon [--interval 30s] [--once]", "Start the scheduled runs as they come due", (*Runner).scheduleDaemon},
+}
+
+// IsCommand reports whether name is the first word of a subcommand.
+func IsCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	for _, c := range commands {
+		if strings.Fields(c.name)[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage writes the list of subcommands to w.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  flowt %s\n      %s\n", c.usage, c.summary)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "A <pipeline> is a pipeline name or ID. FORMAT is table (default), json, yaml")
+	fmt.Fprintln(w, "or go-template=TEMPLATE; json, yaml and templates use the JSON field names.")
+}
+
+// Run executes the subcommand named by args.
+func (r *Runner) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		Usage(r.Stdout)
+		return nil
+	}
+
+	for _, c := range commands {
+		words := strings.Fields(c.name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != c.name {
+			continue
+		}
+		err := c.run(r, ctx, args[len(words):])
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	Usage(r.Stderr)
+	return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+// flagSet returns a flag set for the named command that reports errors
+// instead of exiting.
+func (r *Runner) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(r.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(r.Stderr, "Usage: flowt %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments and checks the number of positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	return parseArgsRange(fs, args, positional, positional)
+}
+
+// parseArgsRange is like parseArgs for commands with optional arguments.
+func parseArgsRange(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+
+	if len(rest) < min || len(rest) > max {
+		fs.Usage()
+		if min == max {
+			return nil, fmt.Errorf("%s expects %d argument(s), got %d", fs.Name(), min, len(rest))
+		}
+		return nil, fmt.Errorf("%s expects %d to %d arguments, got %d", fs.Name(), min, max, len(rest))
+	}
+	return rest, nil
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// ExitError is an error that calls for a specific exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit code for an error returned by Run: 0 for nil,
+// the code of an *ExitError, and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
+// DescribeError returns the message of err followed by the hint of the API
+// error it wraps, if any.
+func DescribeError(err error) string {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		if hint := apiErr.Hint(); hint != "" {
+			return err.Error() + ". " + hint
+		}
+	}
+	return err.Error()
+}
+
+// resolvePipeline finds a pipeline by ID or by exact name.
+func (r *Runner) resolvePipeline(ctx context.Context, ref string) (*api.Pipeline, error) {
+	pipelines, err := r.Service.ListPipelinesContext(ctx, r.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+
+	var byName []api.Pipeline
+	for _, p := range pipelines {
+		if p.PipelineID == ref {
+			pipeline := p
+			return &pipeline, nil
+		}
+		if p.Name == ref {
+			byName = append(byName, p)
+		}
+	}
+
+	switch len(byName) {
+	case 0:
+		return nil, fmt.Errorf("pipeline %q not found", ref)
+	case 1:
+		return &byName[0], nil
+	default:
+		ids := make([]string, len(byName))
+		for i, p := range byName {
+			ids[i] = p.PipelineID
+		}
+		return nil, fmt.Errorf("pipeline name %q is ambiguous, use one of the IDs: %s", ref, strings.Join(ids, ", "))
+	}
+}
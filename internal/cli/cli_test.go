@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+	"aliyun-pipelines-tui/internal/preset"
+)
+
+const testOrg = "org"
+
+func newRunner(t *testing.T) (*Runner, *fake.Service, *fake.Clock, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	service, c := fake.NewWithClock()
+	service.AddPipeline("svc", []fake.StageSpec{
+		{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: 10 * time.Second, Log: []string{"compiling"}}}},
+		{Name: "Deploy", Jobs: []fake.JobSpec{{Name: "deploy", Duration: 10 * time.Second, Deploy: &fake.DeploySpec{Machines: []string{"10.0.0.1"}, Batches: 1}}}},
+	}, map[string]string{"https://example.com/svc.git": "master"})
+	service.AddPipeline("dup", nil, nil)
+	service.AddPipeline("dup", nil, nil)
+
+	var stdout, stderr bytes.Buffer
+	r := &Runner{Service: service, OrganizationID: testOrg, Stdout: &stdout, Stderr: &stderr}
+	return r, service, c, &stdout, &stderr
+}
+
+func TestPipelinesList(t *testing.T) {
+	r, _, _, stdout, _ := newRunner(t)
+	if err := r.Run(context.Background(), []string{"pipelines", "list"}); err != nil {
+		t.Fatalf("pipelines list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "svc") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+}
+
+func TestRunStopAndList(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+
+	// The repositories to build are taken from the definition, or else from
+	// the latest run
+	bare := service.AddPipeline("bare", nil, nil)
+	if err := r.Run(ctx, []string{"run", bare.PipelineID, "--branch", "feature/x"}); err == nil || !strings.Contains(err.Error(), "runningBranchs") {
+		t.Errorf("run without repositories: err = %v", err)
+	}
+	service.AddRun(pipelineID, c.Now().Add(-time.Hour), fake.RunOptions{})
+
+	if err := r.Run(ctx, []string{"run", "svc", "--branch", "feature/x"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	runID := strings.TrimSpace(stdout.String())
+
+	info, err := service.GetLatestPipelineRunInfo(testOrg, pipelineID)
+	if err != nil {
+		t.Fatalf("GetLatestPipelineRunInfo: %v", err)
+	}
+	if info.RunID != runID || info.RepositoryURLs["https://example.com/svc.git"] != "feature/x" {
+		t.Errorf("latest run %s with %v, want run %s on feature/x", info.RunID, info.RepositoryURLs, runID)
+	}
+
+	c.Advance(5 * time.Second)
+	// Pipelines may be given by ID
+	if err := r.Run(ctx, []string{"stop", pipelineID, runID}); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	// Flags may precede the positional arguments
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"runs", "list", "--limit", "1", "svc"}); err != nil {
+		t.Fatalf("runs list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], runID+" ") || !strings.Contains(lines[1], "CANCELED") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+}
+
+func TestRunWithVariables(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	service.SetVariables(pipelineID, api.PipelineVariable{Name: "DEPLOY_ENV", Value: "staging", Runtime: true})
+
+	if err := r.Run(ctx, []string{"run", "svc", "--var", "DEPLOY_ENV"}); err == nil || !strings.Contains(err.Error(), "NAME=VALUE") {
+		t.Errorf("malformed --var: err = %v", err)
+	}
+	if err := r.Run(ctx, []string{"run", "svc", "--var", "DEPLOY_ENV=prod"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	runID := strings.TrimSpace(stdout.String())
+	c.Advance(time.Second)
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"logs", "svc", runID, "--job", "build"}); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "DEPLOY_ENV=prod") {
+		t.Errorf("the variable did not reach the run:\n%s", stdout)
+	}
+}
+
+func TestRunWithPreset(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	service.AddRun(pipelineID, c.Now().Add(-time.Hour), fake.RunOptions{})
+	r.Presets = preset.Presets{"svc": {{Name: "hotfix", Branch: "hotfix/1.8", Variables: map[string]string{"HOTFIX": "true", "DEPLOY_ENV": "staging"}}}}
+
+	if err := r.Run(ctx, []string{"run", "svc", "--preset", "nope"}); err == nil || !strings.Contains(err.Error(), "hotfix") {
+		t.Errorf("unknown preset: err = %v, want the presets listed", err)
+	}
+
+	// Flags override the preset
+	if err := r.Run(ctx, []string{"run", "svc", "--preset", "hotfix", "--var", "DEPLOY_ENV=prod"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	runID := strings.TrimSpace(stdout.String())
+	info, _ := service.GetLatestPipelineRunInfo(testOrg, pipelineID)
+	if info.RunID != runID || info.RepositoryURLs["https://example.com/svc.git"] != "hotfix/1.8" {
+		t.Errorf("latest run %s with %v, want run %s on hotfix/1.8", info.RunID, info.RepositoryURLs, runID)
+	}
+	c.Advance(time.Second)
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"logs", "svc", runID, "--job", "build"}); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Variables: DEPLOY_ENV=prod HOTFIX=true") {
+		t.Errorf("the preset's variables did not reach the run:\n%s", stdout)
+	}
+
+	// Without a definition or runs, the repositories of the preset are built,
+	// on --branch if given
+	bare := service.AddPipeline("bare", nil, nil)
+	r.Presets["bare"] = []preset.Preset{{Name: "repos", Branches: map[string]string{"https://example.com/bare.git": "main"}}}
+	params, err := r.runParams(ctx, &bare, "repos", "feature/x", nil, nil)
+	if err != nil || params["runningBranchs"] != `{"https://example.com/bare.git":"feature/x"}` {
+		t.Errorf("runningBranchs = %s, err %v", params["runningBranchs"], err)
+	}
+
+	for _, param := range []string{"runningBranchs=", "envs={oops"} {
+		if err := r.Run(ctx, []string{"run", "svc", "--param", param}); err == nil || !strings.Contains(err.Error(), "--param") {
+			t.Errorf("--param %s: err = %v", param, err)
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	pipelineID, _ := service.PipelineID("svc")
+	runID, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+	c.Advance(time.Minute)
+
+	if err := r.Run(context.Background(), []string{"logs", "svc", runID}); err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	out := stdout.String()
+	for _, want := range []string{"==> Build / build [SUCCESS]", "compiling", "==> Deploy / deploy [SUCCESS]", "--> 10.0.0.1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs lack %q:\n%s", want, out)
+		}
+	}
+
+	if err := r.Run(context.Background(), []string{"logs", "svc", runID, "--job", "nope"}); err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+
+	// The jobs of a run just started have no log to fetch yet
+	running, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+	stdout.Reset()
+	if err := r.Run(context.Background(), []string{"logs", "svc", running, "--job", "deploy"}); err != nil {
+		t.Fatalf("logs of a running run: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "(not started)") {
+		t.Errorf("deploy of a running run:\n%s", stdout)
+	}
+}
+
+func TestResolvePipelineErrors(t *testing.T) {
+	r, service, _, _, _ := newRunner(t)
+	ctx := context.Background()
+
+	if err := r.Run(ctx, []string{"runs", "list", "missing"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown pipeline: err = %v", err)
+	}
+	if err := r.Run(ctx, []string{"runs", "list", "dup"}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("ambiguous pipeline: err = %v", err)
+	}
+	if err := r.Run(ctx, []string{"stop", "svc"}); err == nil {
+		t.Error("expected an error for a missing run argument")
+	}
+	if err := r.Run(ctx, []string{"frobnicate"}); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	service.SetError("ListPipelines", &api.APIError{Kind: api.ErrUnauthorized, StatusCode: 401})
+	err := r.Run(ctx, []string{"runs", "list", "svc"})
+	if !errors.Is(err, api.ErrUnauthorized) || !strings.Contains(DescribeError(err), "personal_access_token") {
+		t.Errorf("unauthorized: err = %v", err)
+	}
+}
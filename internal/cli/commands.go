@@ -0,0 +1,431 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/preset"
+	"aliyun-pipelines-tui/internal/runwatch"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(timeLayout)
+}
+
+// formatDuration returns how long a run took, or has been running so far.
+func formatDuration(start, finish time.Time) string {
+	if start.IsZero() {
+		return "-"
+	}
+	if finish.IsZero() {
+		finish = time.Now()
+	}
+	return finish.Sub(start).Round(time.Second).String()
+}
+
+// millisTime converts a timestamp in milliseconds as used by the API.
+func millisTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// triggerModeName returns the name of a numeric trigger mode.
+func triggerModeName(mode int) string {
+	switch mode {
+	case 1:
+		return "MANUAL"
+	case 2:
+		return "SCHEDULE"
+	case 3:
+		return "PUSH"
+	case 5:
+		return "PIPELINE"
+	case 6:
+		return "WEBHOOK"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", mode)
+	}
+}
+
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// pipelinesList implements `flowt pipelines list`.
+func (r *Runner) pipelinesList(ctx context.Context, args []string) error {
+	fs := r.flagSet("pipelines list", "pipelines list [--status RUNNING,WAITING] [-o FORMAT]")
+	status := fs.String("status", "", "only list pipelines with one of these comma-separated statuses")
+	output := outputFlag(fs)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	var pipelines []api.Pipeline
+	var err error
+	if *status != "" {
+		pipelines, err = r.Service.ListPipelinesWithStatusContext(ctx, r.OrganizationID, strings.Split(*status, ","))
+	} else {
+		pipelines, err = r.Service.ListPipelinesContext(ctx, r.OrganizationID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list pipelines: %w", err)
+	}
+
+	return r.printValue(*output, pipelines, func(w io.Writer) error {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tLAST RUN\tCREATOR")
+		for _, p := range pipelines {
+			status := p.LastRunStatus
+			if status == "" {
+				status = p.Status
+			}
+			if status == "" {
+				status = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.PipelineID, p.Name, status, formatTime(p.LastRunTime), p.CreatorName)
+		}
+		return tw.Flush()
+	})
+}
+
+// runsList implements `flowt runs list`.
+func (r *Runner) runsList(ctx context.Context, args []string) error {
+	fs := r.flagSet("runs list", "runs list <pipeline> [--limit N] [-o FORMAT]")
+	limit := fs.Int("limit", 0, "list at most N runs (0 lists all)")
+	output := outputFlag(fs)
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	runs, err := r.Service.ListPipelineRunsContext(ctx, r.OrganizationID, pipeline.PipelineID)
+	if err != nil {
+		return fmt.Errorf("failed to list runs of %s: %w", pipeline.Name, err)
+	}
+	if *limit > 0 && len(runs) > *limit {
+		runs = runs[:*limit]
+	}
+
+	return r.printValue(*output, runs, func(w io.Writer) error {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "RUN ID\tSTATUS\tTRIGGER\tSTARTED\tDURATION")
+		for _, run := range runs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", run.RunID, run.Status, run.TriggerMode, formatTime(run.StartTime), formatDuration(run.StartTime, run.FinishTime))
+		}
+		return tw.Flush()
+	})
+}
+
+// runsGet implements `flowt runs get`, which shows the stages and jobs of a run.
+func (r *Runner) runsGet(ctx context.Context, args []string) error {
+	fs := r.flagSet("runs get", "runs get <pipeline> <run> [-o FORMAT]")
+	output := outputFlag(fs)
+	rest, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	details, err := r.Service.GetPipelineRunDetailsContext(ctx, r.OrganizationID, pipeline.PipelineID, rest[1])
+	if err != nil {
+		return fmt.Errorf("failed to get run %s of %s: %w", rest[1], pipeline.Name, err)
+	}
+
+	return r.printValue(*output, details, func(w io.Writer) error {
+		fmt.Fprintf(w, "Pipeline: %s (%s)\n", pipeline.Name, pipeline.PipelineID)
+		fmt.Fprintf(w, "Run:      %d\n", details.PipelineRunID)
+		fmt.Fprintf(w, "Status:   %s\n", details.Status)
+		fmt.Fprintf(w, "Trigger:  %s\n", triggerModeName(details.TriggerMode))
+		fmt.Fprintf(w, "Started:  %s\n", formatTime(millisTime(details.CreateTime)))
+		fmt.Fprintln(w)
+
+		tw := newTable(w)
+		fmt.Fprintln(tw, "STAGE\tJOB\tJOB ID\tSTATUS\tSTARTED\tDURATION")
+		for _, stage := range details.Stages {
+			for _, job := range stage.Jobs {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", stage.Name, job.Name, job.ID, job.Status, formatTime(job.StartTime), formatDuration(job.StartTime, job.EndTime))
+			}
+		}
+		return tw.Flush()
+	})
+}
+
+// deployOrderGet implements `flowt deploy-order get`.
+func (r *Runner) deployOrderGet(ctx context.Context, args []string) error {
+	fs := r.flagSet("deploy-order get", "deploy-order get <pipeline> <deploy order> [-o FORMAT]")
+	output := outputFlag(fs)
+	rest, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	order, err := r.Service.GetVMDeployOrderContext(ctx, r.OrganizationID, pipeline.PipelineID, rest[1])
+	if err != nil {
+		return fmt.Errorf("failed to get deploy order %s of %s: %w", rest[1], pipeline.Name, err)
+	}
+
+	return r.printValue(*output, order, func(w io.Writer) error {
+		fmt.Fprintf(w, "Deploy order: %d\n", order.DeployOrderId)
+		fmt.Fprintf(w, "Status:       %s\n", order.Status)
+		fmt.Fprintf(w, "Batch:        %d/%d\n", order.CurrentBatch, order.TotalBatch)
+		fmt.Fprintf(w, "Created:      %s\n", formatTime(millisTime(order.CreateTime)))
+		fmt.Fprintln(w)
+
+		tw := newTable(w)
+		fmt.Fprintln(tw, "IP\tMACHINE SN\tBATCH\tSTATUS\tCLIENT")
+		for _, machine := range order.DeployMachineInfo.DeployMachines {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", machine.IP, machine.MachineSn, machine.BatchNum, machine.Status, machine.ClientStatus)
+		}
+		return tw.Flush()
+	})
+}
+
+// run implements `flowt run`. Unless the run is watched, the run ID is the
+// only thing written to stdout so that scripts can capture it.
+func (r *Runner) run(ctx context.Context, args []string) error {
+	fs := r.flagSet("run", "run <pipeline> [--preset NAME] [--branch BRANCH] [--var NAME=VALUE]... [--param KEY=VALUE]... [--watch]")
+	presetName := fs.String("preset", "", "run with the branches and variables of a preset saved in config.yml")
+	branch := fs.String("branch", "", "branch to build in every repository (default: the branches of the latest run, then those of the definition)")
+	var vars, extra stringList
+	fs.Var(&vars, "var", "value of a runtime variable of the pipeline as NAME=VALUE (repeatable)")
+	fs.Var(&extra, "param", "additional run parameter as KEY=VALUE (repeatable)")
+	watch := fs.Bool("watch", false, "follow the run until it finishes, like `flowt watch`")
+	watchOpts := watchFlags(fs)
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+
+	params, err := r.runParams(ctx, pipeline, *presetName, *branch, vars, extra)
+	if err != nil {
+		return err
+	}
+
+	run, err := r.Service.RunPipelineContext(ctx, r.OrganizationID, pipeline.PipelineID, params)
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", pipeline.Name, err)
+	}
+	fmt.Fprintf(r.Stderr, "Started run %s of pipeline %s (%s)\n", run.RunID, pipeline.Name, pipeline.PipelineID)
+	if *watch {
+		return r.watchRun(ctx, pipeline, run.RunID, watchOpts)
+	}
+	fmt.Fprintln(r.Stdout, run.RunID)
+	return nil
+}
+
+// runParams returns the parameters of a run from the flags of `flowt run`,
+// which `flowt schedule add` takes too.
+func (r *Runner) runParams(ctx context.Context, pipeline *api.Pipeline, presetName, branch string, vars, extra []string) (map[string]string, error) {
+	// Flags given along with a preset override what it says
+	var chosen preset.Preset // The zero preset changes nothing
+	if presetName != "" {
+		p, ok := r.Presets.Find(pipeline.Name, presetName)
+		if !ok {
+			names := preset.Names(r.Presets.For(pipeline.Name))
+			if len(names) == 0 {
+				return nil, fmt.Errorf("pipeline %s has no presets; add them under presets: in ~/.flowt/config.yml", pipeline.Name)
+			}
+			return nil, fmt.Errorf("pipeline %s has no preset %q; its presets are %s", pipeline.Name, presetName, strings.Join(names, ", "))
+		}
+		chosen = p
+	}
+
+	params := make(map[string]string)
+	for _, kv := range extra {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --param %q, expected KEY=VALUE", kv)
+		}
+		// These are embedded in the request as JSON, see api.RunParams
+		if (key == "runningBranchs" || key == "envs") && !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("invalid --param %q: %s must be a JSON object such as '{\"<key>\":\"<value>\"}'", kv, key)
+		}
+		params[key] = value
+	}
+
+	envs := make(map[string]string)
+	for name, value := range chosen.Variables {
+		envs[name] = value
+	}
+	for _, kv := range vars {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q, expected NAME=VALUE", kv)
+		}
+		envs[name] = value
+	}
+	if len(envs) > 0 {
+		varParams, err := api.RunParams(nil, envs)
+		if err != nil {
+			return nil, err
+		}
+		params["envs"] = varParams["envs"]
+	}
+
+	// The repositories are those the run form of the TUI offers
+	if _, ok := params["runningBranchs"]; !ok {
+		sources, _ := api.LoadRunSources(ctx, r.Service, r.OrganizationID, pipeline.PipelineID)
+		runningBranchs := make(map[string]string)
+		if len(sources) == 0 {
+			// Only the repositories the preset names are known
+			for repoURL, presetBranch := range chosen.Branches {
+				runningBranchs[repoURL] = presetBranch
+				if branch != "" {
+					runningBranchs[repoURL] = branch
+				}
+			}
+			wanted := chosen.BranchFor("", branch)
+			if branch != "" {
+				wanted = branch
+			}
+			if wanted != "" && len(runningBranchs) == 0 {
+				return nil, fmt.Errorf("cannot determine the repositories of %s from its definition or latest run, pass --param runningBranchs='{\"<repo url>\":\"%s\"}' instead", pipeline.Name, wanted)
+			}
+		} else {
+			for _, source := range sources {
+				runningBranchs[source.Repo] = chosen.BranchFor(source.Repo, source.Branch)
+				if branch != "" {
+					runningBranchs[source.Repo] = branch
+				}
+			}
+		}
+		if len(runningBranchs) > 0 {
+			runningBranchsJSON, err := json.Marshal(runningBranchs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare parameters: %w", err)
+			}
+			params["runningBranchs"] = string(runningBranchsJSON)
+		}
+	}
+	return params, nil
+}
+
+// stop implements `flowt stop`.
+func (r *Runner) stop(ctx context.Context, args []string) error {
+	fs := r.flagSet("stop", "stop <pipeline> <run>")
+	rest, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := r.Service.StopPipelineRunContext(ctx, r.OrganizationID, pipeline.PipelineID, rest[1]); err != nil {
+		return fmt.Errorf("failed to stop run %s of %s: %w", rest[1], pipeline.Name, err)
+	}
+	fmt.Fprintf(r.Stderr, "Stopped run %s of pipeline %s\n", rest[1], pipeline.Name)
+	return nil
+}
+
+// logs implements `flowt logs`.
+func (r *Runner) logs(ctx context.Context, args []string) error {
+	fs := r.flagSet("logs", "logs <pipeline> <run> [--job NAME]")
+	jobName := fs.String("job", "", "only print the log of the job with this name")
+	rest, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	runID := rest[1]
+	details, err := r.Service.GetPipelineRunDetailsContext(ctx, r.OrganizationID, pipeline.PipelineID, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get run %s of %s: %w", runID, pipeline.Name, err)
+	}
+
+	found := false
+	for _, stage := range details.Stages {
+		for _, job := range stage.Jobs {
+			if *jobName != "" && job.Name != *jobName {
+				continue
+			}
+			found = true
+
+			fmt.Fprintf(r.Stdout, "==> %s / %s [%s]\n", stage.Name, job.Name, job.Status)
+			if !runwatch.HasStarted(job.Status) {
+				fmt.Fprintln(r.Stdout, "(not started)")
+				fmt.Fprintln(r.Stdout)
+				continue
+			}
+			if err := r.writeJobLog(ctx, pipeline.PipelineID, runID, job); err != nil {
+				return err
+			}
+			fmt.Fprintln(r.Stdout)
+		}
+	}
+
+	if *jobName != "" && !found {
+		return fmt.Errorf("run %s of %s has no job named %q", runID, pipeline.Name, *jobName)
+	}
+	return nil
+}
+
+// writeJobLog prints the log of a job; for VM deploy jobs that is the deploy
+// log of each machine.
+func (r *Runner) writeJobLog(ctx context.Context, pipelineID, runID string, job api.Job) error {
+	deployOrderID, err := api.DeployOrderID(job)
+	if err != nil {
+		log, err := r.Service.GetPipelineJobRunLogContext(ctx, r.OrganizationID, pipelineID, runID, fmt.Sprint(job.ID))
+		if err != nil {
+			return fmt.Errorf("failed to get the log of job %s: %w", job.Name, err)
+		}
+		writeText(r.Stdout, log)
+		return nil
+	}
+
+	order, err := r.Service.GetVMDeployOrderContext(ctx, r.OrganizationID, pipelineID, deployOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get deploy order %s: %w", deployOrderID, err)
+	}
+	for _, machine := range order.DeployMachineInfo.DeployMachines {
+		fmt.Fprintf(r.Stdout, "--> %s (batch %d) [%s]\n", machine.IP, machine.BatchNum, machine.Status)
+		machineLog, err := r.Service.GetVMDeployMachineLogContext(ctx, r.OrganizationID, pipelineID, deployOrderID, machine.MachineSn)
+		if err != nil {
+			return fmt.Errorf("failed to get the deploy log of %s: %w", machine.IP, err)
+		}
+		writeText(r.Stdout, machineLog.DeployLog)
+	}
+	return nil
+}
+
+// writeText writes text and terminates it with a newline if it has none.
+func writeText(w io.Writer, text string) {
+	io.WriteString(w, text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		io.WriteString(w, "\n")
+	}
+}
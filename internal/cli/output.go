@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output.
+const (
+	outputTable      = "table"
+	outputJSON       = "json"
+	outputYAML       = "yaml"
+	outputGoTemplate = "go-template"
+)
+
+// outputFlag registers --output and its shorthand -o on fs.
+func outputFlag(fs *flag.FlagSet) *string {
+	format := new(string)
+	usage := "output format: table, json, yaml or go-template=TEMPLATE"
+	fs.StringVar(format, "output", outputTable, usage)
+	fs.StringVar(format, "o", outputTable, "shorthand for --output")
+	return format
+}
+
+// printValue writes value in the requested format. JSON, YAML and templates
+// all see the JSON form of value, so field names are those of its JSON tags;
+// table writes the human-readable form.
+func (r *Runner) printValue(format string, value interface{}, table func(w io.Writer) error) error {
+	// Empty lists are printed as [] rather than null
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice && v.IsNil() {
+		value = []interface{}{}
+	}
+
+	kind, text, _ := strings.Cut(format, "=")
+	switch kind {
+	case outputTable:
+		return table(r.Stdout)
+	case outputJSON:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		_, err = fmt.Fprintf(r.Stdout, "%s\n", data)
+		return err
+	case outputYAML:
+		return writeYAML(r.Stdout, value)
+	case outputGoTemplate:
+		if text == "" {
+			return fmt.Errorf("--output go-template needs a template, e.g. -o 'go-template={{range .}}{{.name}}{{\"\\n\"}}{{end}}'")
+		}
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		data, err := jsonValue(value)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(r.Stdout, data)
+	default:
+		return fmt.Errorf("unknown output format %q, use table, json, yaml or go-template=TEMPLATE", format)
+	}
+}
+
+// jsonValue returns value as decoded from its JSON encoding: maps, slices,
+// strings, booleans and json.Number.
+func jsonValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return decoded, nil
+}
+
+// writeYAML writes value as block-style YAML with the keys in the same order
+// as in its JSON encoding.
+func writeYAML(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	// JSON is YAML, so decoding it into a node keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to convert JSON to YAML: %w", err)
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return encoder.Close()
+}
+
+// blockStyle drops the flow style decoded from JSON, leaving quoting to the
+// encoder.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
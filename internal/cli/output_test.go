@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOutputFormats(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	runID, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+
+	if err := r.Run(ctx, []string{"runs", "list", "svc", "-o", "json"}); err != nil {
+		t.Fatalf("runs list -o json: %v", err)
+	}
+	var runs []api.PipelineRun
+	if err := json.Unmarshal(stdout.Bytes(), &runs); err != nil || len(runs) != 1 || runs[0].RunID != runID {
+		t.Errorf("JSON output %s: %v", stdout, err)
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"runs", "list", "svc", "--output", "yaml"}); err != nil {
+		t.Fatalf("runs list -o yaml: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &decoded); err != nil || len(decoded) != 1 {
+		t.Fatalf("YAML output %s: %v", stdout, err)
+	}
+	// IDs stay strings and keys keep the JSON names and order
+	if decoded[0]["runId"] != runID || !strings.HasPrefix(stdout.String(), "- runId: ") {
+		t.Errorf("YAML output:\n%s", stdout)
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"pipelines", "list", "-o", `go-template={{range .}}{{.name}}={{.pipelineId}};{{end}}`}); err != nil {
+		t.Fatalf("pipelines list -o go-template: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "svc="+pipelineID+";") {
+		t.Errorf("template output = %q", stdout)
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"pipelines", "list", "--status", "RUNNING", "-o", "json"}); err != nil {
+		t.Fatalf("pipelines list: %v", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); !strings.HasPrefix(got, "[") {
+		t.Errorf("list output should be an array, got %s", got)
+	}
+
+	for _, format := range []string{"xml", "go-template", "go-template={{.nope"} {
+		if err := r.Run(ctx, []string{"pipelines", "list", "-o", format}); err == nil {
+			t.Errorf("-o %s: expected an error", format)
+		}
+	}
+}
+
+func TestEmptyListIsJSONArray(t *testing.T) {
+	r, _, _, stdout, _ := newRunner(t)
+	if err := r.Run(context.Background(), []string{"runs", "list", "svc", "-o", "json"}); err != nil {
+		t.Fatalf("runs list: %v", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "[]" {
+		t.Errorf("output = %q, want []", got)
+	}
+}
+
+func TestRunsGetAndDeployOrderGet(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	runID, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+	c.Advance(15 * time.Second)
+
+	if err := r.Run(ctx, []string{"runs", "get", "svc", runID}); err != nil {
+		t.Fatalf("runs get: %v", err)
+	}
+	for _, want := range []string{"Status:   RUNNING", "Build   build", "SUCCESS", "Deploy  deploy"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("runs get output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"runs", "get", "svc", runID, "-o", "json"}); err != nil {
+		t.Fatalf("runs get -o json: %v", err)
+	}
+	var details api.PipelineRunDetails
+	if err := json.Unmarshal(stdout.Bytes(), &details); err != nil {
+		t.Fatalf("runs get JSON %s: %v", stdout, err)
+	}
+	deployID, err := api.DeployOrderID(details.Stages[1].Jobs[0])
+	if err != nil {
+		t.Fatalf("DeployOrderID: %v", err)
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"deploy-order", "get", "svc", deployID, "-o", "json"}); err != nil {
+		t.Fatalf("deploy-order get: %v", err)
+	}
+	var order api.VMDeployOrder
+	if err := json.Unmarshal(stdout.Bytes(), &order); err != nil || strconv.Itoa(order.DeployOrderId) != deployID || len(order.DeployMachineInfo.DeployMachines) != 1 {
+		t.Errorf("deploy order JSON %s: %v", stdout, err)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/plan"
+)
+
+// planStep is a step of a plan as printed by `flowt plan run` and
+// `flowt plan status`.
+type planStep struct {
+	Step       string    `json:"step"`
+	Pipeline   string    `json:"pipeline"`
+	Needs      []string  `json:"needs"`
+	Status     string    `json:"status"`
+	RunID      string    `json:"runId,omitempty"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// planRun implements `flowt plan run`, which runs the steps of a plan in the
+// order of their needs. Progress is saved next to the plan, so running it
+// again after an interruption picks up where it stopped.
+func (r *Runner) planRun(ctx context.Context, args []string) error {
+	fs := r.flagSet("plan run", "plan run <plan.yml> [--restart] [--interval 5s]")
+	restart := fs.Bool("restart", false, "run every step again instead of resuming the saved progress")
+	interval := fs.Duration("interval", plan.DefaultInterval, "polling interval")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	p, err := plan.Load(rest[0])
+	if err != nil {
+		return err
+	}
+	statePath := plan.StatePath(rest[0])
+	state := &plan.State{}
+	if !*restart {
+		if state, err = plan.LoadState(statePath); err != nil {
+			return err
+		}
+		if len(state.Steps) > 0 {
+			fmt.Fprintf(r.Stderr, "Resuming plan %s from %s\n", p.Name, statePath)
+		}
+	}
+
+	executor := &plan.Executor{
+		Service:        r.Service,
+		OrganizationID: r.OrganizationID,
+		Interval:       *interval,
+		Save: func(state *plan.State) error {
+			return plan.SaveState(statePath, state)
+		},
+		OnChange: func(step string, s plan.StepState) {
+			line := fmt.Sprintf("[%s] %s: %s", time.Now().Format("15:04:05"), step, s.Status)
+			if s.RunID != "" {
+				line += fmt.Sprintf(" (run %s)", s.RunID)
+			}
+			if s.Error != "" {
+				line += ": " + s.Error
+			}
+			fmt.Fprintln(r.Stderr, line)
+		},
+	}
+	err = executor.Run(ctx, p, state)
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("plan %s interrupted; run it again to resume", p.Name)
+	}
+	if len(state.Steps) > 0 {
+		if werr := writePlanSteps(r.Stdout, planSteps(p, state)); werr != nil {
+			return werr
+		}
+	}
+	if err != nil {
+		return &ExitError{Code: ExitFailed, Err: err}
+	}
+	return nil
+}
+
+// planStatus implements `flowt plan status`, which shows the saved progress
+// of a plan.
+func (r *Runner) planStatus(ctx context.Context, args []string) error {
+	fs := r.flagSet("plan status", "plan status <plan.yml> [-o FORMAT]")
+	output := outputFlag(fs)
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	p, err := plan.Load(rest[0])
+	if err != nil {
+		return err
+	}
+	state, err := plan.LoadState(plan.StatePath(rest[0]))
+	if err != nil {
+		return err
+	}
+	steps := planSteps(p, state)
+	return r.printValue(*output, steps, func(w io.Writer) error {
+		return writePlanSteps(w, steps)
+	})
+}
+
+// planSteps lists the steps of a plan with how far each has got
+func planSteps(p *plan.Plan, state *plan.State) []planStep {
+	steps := make([]planStep, 0, len(p.Steps))
+	for _, step := range p.Steps {
+		s := state.Step(step.Name)
+		needs := step.Needs
+		if needs == nil {
+			needs = []string{}
+		}
+		steps = append(steps, planStep{
+			Step:       step.Name,
+			Pipeline:   step.Pipeline,
+			Needs:      needs,
+			Status:     s.Status,
+			RunID:      s.RunID,
+			StartedAt:  s.StartedAt,
+			FinishedAt: s.FinishedAt,
+			Error:      s.Error,
+		})
+	}
+	return steps
+}
+
+func writePlanSteps(w io.Writer, steps []planStep) error {
+	tw := newTable(w)
+	fmt.Fprintln(tw, "STEP\tPIPELINE\tNEEDS\tSTATUS\tRUN\tSTARTED\tDURATION\tERROR")
+	for _, s := range steps {
+		needs, run, duration := "-", "-", "-"
+		if len(s.Needs) > 0 {
+			needs = strings.Join(s.Needs, ",")
+		}
+		if s.RunID != "" {
+			run = s.RunID
+		}
+		if !s.StartedAt.IsZero() {
+			duration = formatDuration(s.StartedAt, s.FinishedAt)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Step, s.Pipeline, needs, s.Status, run, formatTime(s.StartedAt), duration, s.Error)
+	}
+	return tw.Flush()
+}
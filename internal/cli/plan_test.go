@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanRunAndStatus(t *testing.T) {
+	r, service, c, stdout, stderr := newRunner(t)
+	service.SetClock(func() time.Time {
+		c.Advance(time.Second)
+		return c.Now()
+	})
+	path := filepath.Join(t.TempDir(), "release.yml")
+	os.WriteFile(path, []byte(`
+steps:
+  - name: build
+    pipeline: svc
+    branch: release/1.0
+  - name: again
+    pipeline: svc
+    needs: [build]
+`), 0600)
+
+	err := r.Run(context.Background(), []string{"plan", "run", path, "--interval", "1ms"})
+	if code := ExitCode(err); code != ExitSuccess {
+		t.Fatalf("exit code %d, err %v\n%s", code, err, stderr)
+	}
+	if !strings.Contains(stderr.String(), "build: SUCCESS") || !strings.Contains(stderr.String(), "again: SUCCESS") {
+		t.Errorf("status changes not reported:\n%s", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "release.state.yml")); err != nil {
+		t.Errorf("progress not saved: %v", err)
+	}
+
+	stdout.Reset()
+	if err := r.Run(context.Background(), []string{"plan", "status", path}); err != nil {
+		t.Fatalf("plan status: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "STEP") || !strings.Contains(lines[2], "build") || !strings.Contains(lines[2], "SUCCESS") {
+		t.Errorf("unexpected status:\n%s", stdout)
+	}
+
+	// A resumed plan that has succeeded starts nothing
+	stderr.Reset()
+	if err := r.Run(context.Background(), []string{"plan", "run", path, "--interval", "1ms"}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Resuming plan release") || strings.Contains(stderr.String(), "RUNNING") {
+		t.Errorf("unexpected resume:\n%s", stderr)
+	}
+}
+
+func TestPlanRunFails(t *testing.T) {
+	r, _, _, _, _ := newRunner(t)
+	path := filepath.Join(t.TempDir(), "plan.yml")
+	os.WriteFile(path, []byte("steps:\n  - pipeline: dup\n"), 0600)
+
+	err := r.Run(context.Background(), []string{"plan", "run", path})
+	if code := ExitCode(err); code != ExitFailed || !strings.Contains(err.Error(), "2 pipelines are named") {
+		t.Errorf("ambiguous pipeline: exit code %d, err %v", code, err)
+	}
+}
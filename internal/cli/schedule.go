@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/schedule"
+)
+
+// scheduleAdd implements `flowt schedule add`, which schedules a run of a
+// pipeline for `flowt schedule daemon` to start.
+func (r *Runner) scheduleAdd(ctx context.Context, args []string) error {
+	fs := r.flagSet("schedule add", "schedule add <pipeline> (--at TIME | --cron EXPR) [--preset NAME] [--branch BRANCH] [--var NAME=VALUE]... [--param KEY=VALUE]...")
+	at := fs.String("at", "", "start one run at TIME: 22:00, \"2024-05-01 22:00\", RFC 3339 or +90m")
+	cron := fs.String("cron", "", "start a run whenever the cron expression EXPR matches, e.g. \"0 22 * * 1-5\"")
+	presetName := fs.String("preset", "", "run with the branches and variables of a preset saved in config.yml")
+	branch := fs.String("branch", "", "branch to build in every repository (default: the branches of the latest run, then those of the definition)")
+	var vars, extra stringList
+	fs.Var(&vars, "var", "value of a runtime variable of the pipeline as NAME=VALUE (repeatable)")
+	fs.Var(&extra, "param", "additional run parameter as KEY=VALUE (repeatable)")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if r.Schedules == "" {
+		return fmt.Errorf("no schedule file is configured")
+	}
+	if (*at == "") == (*cron == "") {
+		return fmt.Errorf("give either --at or --cron")
+	}
+
+	now := time.Now()
+	s := schedule.Schedule{
+		OrganizationID: r.OrganizationID,
+		Cron:           *cron,
+		Preset:         *presetName,
+		Branch:         *branch,
+		Variables:      vars,
+		Params:         extra,
+		CreatedAt:      now,
+	}
+	if *at != "" {
+		if s.At, err = schedule.ParseAt(*at, now); err != nil {
+			return err
+		}
+	} else if _, err := schedule.ParseCron(*cron); err != nil {
+		return err
+	} else if s.Next().IsZero() {
+		return fmt.Errorf("cron expression %q never matches", *cron)
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	s.PipelineID, s.Pipeline = pipeline.PipelineID, pipeline.Name
+	// Check the flags now rather than when the run is due; the repositories
+	// and their branches are determined then
+	if _, err := r.runParams(ctx, pipeline, s.Preset, s.Branch, s.Variables, s.Params); err != nil {
+		return err
+	}
+
+	err = schedule.Update(r.Schedules, func(store *schedule.Store) error {
+		s = store.Add(s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.Stderr, "Scheduled a run of pipeline %s, next at %s; `flowt schedule daemon` must be running then to start it\n",
+		pipeline.Name, formatTime(s.Next()))
+	fmt.Fprintln(r.Stdout, s.ID)
+	return nil
+}
+
+// scheduleEntry is a schedule as printed by `flowt schedule list`.
+type scheduleEntry struct {
+	ID         string    `json:"id"`
+	PipelineID string    `json:"pipelineId"`
+	Pipeline   string    `json:"pipeline"`
+	At         time.Time `json:"at,omitempty"`
+	Cron       string    `json:"cron,omitempty"`
+	Next       time.Time `json:"next"`
+	Status     string    `json:"status"`
+	LastAt     time.Time `json:"lastAt"`
+	LastRunID  string    `json:"lastRunId,omitempty"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// scheduleList implements `flowt schedule list`, which shows the schedules of
+// the organization and how their last runs went.
+func (r *Runner) scheduleList(ctx context.Context, args []string) error {
+	fs := r.flagSet("schedule list", "schedule list [-o FORMAT]")
+	output := outputFlag(fs)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if r.Schedules == "" {
+		return fmt.Errorf("no schedule file is configured")
+	}
+	store, err := schedule.Load(r.Schedules)
+	if err != nil {
+		return err
+	}
+
+	var entries []scheduleEntry
+	for _, s := range store.Schedules {
+		if s.OrganizationID != r.OrganizationID {
+			continue
+		}
+		entries = append(entries, scheduleEntry{
+			ID:         s.ID,
+			PipelineID: s.PipelineID,
+			Pipeline:   s.Pipeline,
+			At:         s.At,
+			Cron:       s.Cron,
+			Next:       s.Next(),
+			Status:     s.Status(),
+			LastAt:     s.LastAt,
+			LastRunID:  s.LastRunID,
+			LastError:  s.LastError,
+		})
+	}
+	return r.printValue(*output, entries, func(w io.Writer) error {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "ID\tPIPELINE\tWHEN\tNEXT\tSTATUS\tLAST\tLAST RUN")
+		for _, e := range entries {
+			when := e.Cron
+			if when == "" {
+				when = "once"
+			}
+			last := "-"
+			switch {
+			case e.LastError != "":
+				last = e.LastError
+			case e.LastRunID != "":
+				last = e.LastRunID
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.ID, e.Pipeline, when, formatTime(e.Next), e.Status, formatTime(e.LastAt), last)
+		}
+		return tw.Flush()
+	})
+}
+
+// scheduleRemove implements `flowt schedule rm`.
+func (r *Runner) scheduleRemove(ctx context.Context, args []string) error {
+	fs := r.flagSet("schedule rm", "schedule rm <id>")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if r.Schedules == "" {
+		return fmt.Errorf("no schedule file is configured")
+	}
+	return schedule.Update(r.Schedules, func(store *schedule.Store) error {
+		if s := store.Find(rest[0]); s == nil || s.OrganizationID != r.OrganizationID {
+			return fmt.Errorf("schedule %s not found", rest[0])
+		}
+		store.Remove(rest[0])
+		fmt.Fprintf(r.Stderr, "Removed schedule %s\n", rest[0])
+		return nil
+	})
+}
+
+// scheduleDaemon implements `flowt schedule daemon`, which starts the runs of
+// the schedules of the organization as they come due. Outcomes are recorded
+// in the schedules, printed and appended to a log next to the schedule file.
+func (r *Runner) scheduleDaemon(ctx context.Context, args []string) error {
+	fs := r.flagSet("schedule daemon", "schedule daemon [--interval 30s] [--once]")
+	interval := fs.Duration("interval", 30*time.Second, "how often to check for schedules that are due")
+	once := fs.Bool("once", false, "start the runs that are due and exit, e.g. from cron")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if r.Schedules == "" {
+		return fmt.Errorf("no schedule file is configured")
+	}
+
+	logPath := strings.TrimSuffix(r.Schedules, filepath.Ext(r.Schedules)) + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open schedule log: %w", err)
+	}
+	defer logFile.Close()
+	log := io.MultiWriter(r.Stderr, logFile)
+
+	if !*once {
+		fmt.Fprintf(r.Stderr, "Starting the runs scheduled in %s as they come due, logging to %s\n", r.Schedules, logPath)
+	}
+	for {
+		if err := r.startDueSchedules(ctx, log); err != nil {
+			// A schedule file being rewritten is read again next time
+			fmt.Fprintf(log, "[%s] %v\n", time.Now().Format(timeLayout), err)
+		}
+		if *once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// errNotDue is returned from the update of a schedule that another daemon
+// fired meanwhile
+var errNotDue = errors.New("no longer due")
+
+// startDueSchedules starts the runs of the schedules that are due and records
+// their outcomes. A schedule is recorded as fired before its run is started,
+// so that a run is never started twice, even if recording the outcome fails.
+func (r *Runner) startDueSchedules(ctx context.Context, log io.Writer) error {
+	store, err := schedule.Load(r.Schedules)
+	if err != nil {
+		return err
+	}
+	for _, s := range store.Schedules {
+		if s.OrganizationID != r.OrganizationID {
+			continue
+		}
+		now := time.Now()
+		due, missed := s.Due(now)
+		if !due && !missed {
+			continue
+		}
+
+		// Only this schedule is written, keeping changes made meanwhile. It is
+		// fired only if still due, as another daemon may have fired it since
+		// it was read.
+		err := schedule.Update(r.Schedules, func(store *schedule.Store) error {
+			current := store.Find(s.ID)
+			if current == nil {
+				return fmt.Errorf("schedule %s was removed", s.ID)
+			}
+			if due, missed = current.Due(now); !due && !missed {
+				return errNotDue
+			}
+			s = *current
+			current.Fire(now)
+			return nil
+		})
+		if errors.Is(err, errNotDue) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(log, "[%s] schedule %s: not started: %v\n", now.Format(timeLayout), s.ID, err)
+			continue
+		}
+
+		var runID string
+		if missed {
+			err = fmt.Errorf("missed: due at %s, more than %s before the daemon got to it", formatTime(s.Next()), schedule.Grace)
+		} else {
+			runID, err = r.startScheduled(ctx, s)
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(log, "[%s] schedule %s: pipeline %s: %v\n", now.Format(timeLayout), s.ID, s.Pipeline, err)
+		} else {
+			fmt.Fprintf(log, "[%s] schedule %s: started run %s of pipeline %s\n", now.Format(timeLayout), s.ID, runID, s.Pipeline)
+		}
+
+		recordErr := schedule.Update(r.Schedules, func(store *schedule.Store) error {
+			if current := store.Find(s.ID); current != nil {
+				current.Record(runID, err)
+			}
+			return nil
+		})
+		if recordErr != nil {
+			fmt.Fprintf(log, "[%s] schedule %s: failed to record the outcome: %v\n", now.Format(timeLayout), s.ID, recordErr)
+		}
+	}
+	return nil
+}
+
+// startScheduled starts the run of a schedule and returns its ID
+func (r *Runner) startScheduled(ctx context.Context, s schedule.Schedule) (string, error) {
+	pipeline := &api.Pipeline{PipelineID: s.PipelineID, Name: s.Pipeline}
+	params, err := r.runParams(ctx, pipeline, s.Preset, s.Branch, s.Variables, s.Params)
+	if err != nil {
+		return "", err
+	}
+	run, err := r.Service.RunPipelineContext(ctx, r.OrganizationID, s.PipelineID, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to run: %w", err)
+	}
+	return run.RunID, nil
+}
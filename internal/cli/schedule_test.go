@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api/fake"
+	"aliyun-pipelines-tui/internal/schedule"
+)
+
+func TestSchedules(t *testing.T) {
+	r, service, c, stdout, stderr := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	service.AddRun(pipelineID, c.Now().Add(-time.Hour), fake.RunOptions{})
+	dir := t.TempDir()
+	r.Schedules = filepath.Join(dir, "schedules.yml")
+
+	if err := r.Run(ctx, []string{"schedule", "add", "svc", "--at", "+1h", "--branch", "release/1.0"}); err != nil {
+		t.Fatalf("schedule add: %v", err)
+	}
+	once := strings.TrimSpace(stdout.String())
+	if err := r.Run(ctx, []string{"schedule", "add", "svc", "--cron", "0 22 * * 1-5"}); err != nil {
+		t.Fatalf("schedule add --cron: %v", err)
+	}
+	for _, args := range [][]string{
+		{"schedule", "add", "svc"},
+		{"schedule", "add", "svc", "--at", "22:00", "--cron", "@daily"},
+		{"schedule", "add", "svc", "--cron", "0 25 * * *"},
+		{"schedule", "add", "nope", "--at", "+1h"},
+		{"schedule", "add", "svc", "--at", "+1h", "--var", "oops"},
+	} {
+		if err := r.Run(ctx, args); err == nil {
+			t.Errorf("%v succeeded", args)
+		}
+	}
+
+	// Nothing is due yet
+	if err := r.Run(ctx, []string{"schedule", "daemon", "--once"}); err != nil {
+		t.Fatalf("daemon: %v", err)
+	}
+	runs, _ := service.ListPipelineRuns(testOrg, pipelineID)
+	if len(runs) != 1 {
+		t.Fatalf("%d runs before the schedule was due", len(runs))
+	}
+
+	// Move the one-off run to a minute ago
+	schedule.Update(r.Schedules, func(s *schedule.Store) error {
+		s.Find(once).At = time.Now().Add(-time.Minute)
+		return nil
+	})
+	stderr.Reset()
+	if err := r.Run(ctx, []string{"schedule", "daemon", "--once"}); err != nil {
+		t.Fatalf("daemon: %v", err)
+	}
+	runs, _ = service.ListPipelineRuns(testOrg, pipelineID)
+	if len(runs) != 2 || !strings.Contains(stderr.String(), "schedule "+once+": started run") {
+		t.Fatalf("%d runs after the schedule was due:\n%s", len(runs), stderr)
+	}
+	if log, _ := os.ReadFile(filepath.Join(dir, "schedules.log")); !strings.Contains(string(log), "started run") {
+		t.Errorf("outcome not logged:\n%s", log)
+	}
+
+	// A run is started once only
+	if err := r.Run(ctx, []string{"schedule", "daemon", "--once"}); err != nil {
+		t.Fatalf("daemon: %v", err)
+	}
+	if runs, _ = service.ListPipelineRuns(testOrg, pipelineID); len(runs) != 2 {
+		t.Errorf("%d runs after running the daemon again", len(runs))
+	}
+
+	stdout.Reset()
+	if err := r.Run(ctx, []string{"schedule", "list"}); err != nil {
+		t.Fatalf("schedule list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "STARTED") || !strings.Contains(lines[2], "0 22 * * 1-5") || !strings.Contains(lines[2], "SCHEDULED") {
+		t.Errorf("unexpected list:\n%s", stdout)
+	}
+
+	if err := r.Run(ctx, []string{"schedule", "rm", once}); err != nil {
+		t.Fatalf("schedule rm: %v", err)
+	}
+	if err := r.Run(ctx, []string{"schedule", "rm", once}); err == nil {
+		t.Errorf("removing a removed schedule succeeded")
+	}
+}
+
+func TestScheduleMissed(t *testing.T) {
+	r, service, c, _, stderr := newRunner(t)
+	pipelineID, _ := service.PipelineID("svc")
+	service.AddRun(pipelineID, c.Now().Add(-time.Hour), fake.RunOptions{})
+	r.Schedules = filepath.Join(t.TempDir(), "schedules.yml")
+
+	schedule.Update(r.Schedules, func(s *schedule.Store) error {
+		s.Add(schedule.Schedule{OrganizationID: testOrg, PipelineID: pipelineID, Pipeline: "svc", At: time.Now().Add(-time.Hour), CreatedAt: time.Now().Add(-2 * time.Hour)})
+		return nil
+	})
+	if err := r.Run(context.Background(), []string{"schedule", "daemon", "--once"}); err != nil {
+		t.Fatalf("daemon: %v", err)
+	}
+	if runs, _ := service.ListPipelineRuns(testOrg, pipelineID); len(runs) != 1 {
+		t.Errorf("a run was started an hour late")
+	}
+	store, _ := schedule.Load(r.Schedules)
+	if s := store.Schedules[0]; s.Status() != "MISSED" || !strings.Contains(stderr.String(), "missed") {
+		t.Errorf("status %s:\n%s", s.Status(), stderr)
+	}
+}
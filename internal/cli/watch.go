@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/runwatch"
+)
+
+// Exit codes of `flowt watch` and `flowt run --watch`.
+const (
+	ExitSuccess  = 0
+	ExitFailed   = 1
+	ExitCanceled = 2
+	ExitUnknown  = 3 // The run's outcome could not be determined
+)
+
+// watch implements `flowt watch`, which follows a run (by default the latest
+// one) until it finishes.
+func (r *Runner) watch(ctx context.Context, args []string) error {
+	fs := r.flagSet("watch", "watch <pipeline> [run] [--interval 5s] [--timeout 0] [--no-logs]")
+	opts := watchFlags(fs)
+	rest, err := parseArgsRange(fs, args, 1, 2)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &ExitError{Code: ExitUnknown, Err: err}
+	}
+
+	pipeline, err := r.resolvePipeline(ctx, rest[0])
+	if err != nil {
+		return &ExitError{Code: ExitUnknown, Err: err}
+	}
+	var runID string
+	if len(rest) == 2 {
+		runID = rest[1]
+	} else {
+		latest, err := r.Service.GetLatestPipelineRunContext(ctx, r.OrganizationID, pipeline.PipelineID)
+		if err != nil {
+			return &ExitError{Code: ExitUnknown, Err: fmt.Errorf("failed to get the latest run of %s: %w", pipeline.Name, err)}
+		}
+		runID = latest.RunID
+	}
+
+	return r.watchRun(ctx, pipeline, runID, opts)
+}
+
+// watchOptions are the flags shared by `flowt watch` and `flowt run --watch`.
+type watchOptions struct {
+	interval *time.Duration
+	timeout  *time.Duration
+	noLogs   *bool
+}
+
+func watchFlags(fs *flag.FlagSet) watchOptions {
+	return watchOptions{
+		interval: fs.Duration("interval", runwatch.DefaultInterval, "polling interval"),
+		timeout:  fs.Duration("timeout", 0, "give up after this long (0 waits forever)"),
+		noLogs:   fs.Bool("no-logs", false, "only print status changes, not job logs"),
+	}
+}
+
+// watchRun follows a run, printing status changes to stderr and job logs to
+// stdout, and returns an *ExitError unless the run succeeded.
+func (r *Runner) watchRun(ctx context.Context, pipeline *api.Pipeline, runID string, opts watchOptions) error {
+	if *opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *opts.timeout)
+		defer cancel()
+	}
+
+	fmt.Fprintf(r.Stderr, "Watching run %s of pipeline %s (%s)\n", runID, pipeline.Name, pipeline.PipelineID)
+	watchOpts := runwatch.Options{
+		Interval: *opts.interval,
+		OnTransition: func(t runwatch.Transition) {
+			stamp := time.Now().Format("15:04:05")
+			switch {
+			case t.Stage == "":
+				fmt.Fprintf(r.Stderr, "[%s] Run %s: %s\n", stamp, runID, t.Status)
+			case t.Job == "":
+				fmt.Fprintf(r.Stderr, "[%s] Stage %s: %s\n", stamp, t.Stage, t.Status)
+			default:
+				fmt.Fprintf(r.Stderr, "[%s] Job %s / %s: %s\n", stamp, t.Stage, t.Job, t.Status)
+			}
+		},
+		OnError: func(err error) {
+			fmt.Fprintf(r.Stderr, "Warning: %s\n", DescribeError(err))
+		},
+	}
+	if !*opts.noLogs {
+		watchOpts.OnLog = func(stage string, job api.Job, text string) {
+			prefix := job.Name + " | "
+			for _, line := range strings.SplitAfter(text, "\n") {
+				if line != "" {
+					fmt.Fprint(r.Stdout, prefix+line)
+				}
+			}
+			if !strings.HasSuffix(text, "\n") {
+				fmt.Fprintln(r.Stdout)
+			}
+		}
+	}
+
+	details, err := runwatch.Watch(ctx, r.Service, r.OrganizationID, pipeline.PipelineID, runID, watchOpts)
+	if err != nil {
+		return &ExitError{Code: ExitUnknown, Err: fmt.Errorf("failed to watch run %s of %s: %w", runID, pipeline.Name, err)}
+	}
+
+	switch runwatch.Normalize(details.Status) {
+	case "SUCCESS":
+		return nil
+	case "CANCELED":
+		return &ExitError{Code: ExitCanceled, Err: fmt.Errorf("run %s of %s was canceled", runID, pipeline.Name)}
+	default:
+		return &ExitError{Code: ExitFailed, Err: fmt.Errorf("run %s of %s finished with status %s", runID, pipeline.Name, details.Status)}
+	}
+}
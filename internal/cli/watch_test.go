@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+func TestWatchExitCodes(t *testing.T) {
+	r, service, c, stdout, stderr := newRunner(t)
+	ctx := context.Background()
+	pipelineID, _ := service.PipelineID("svc")
+	// Every look at the clock moves it on, so that runs finish quickly
+	service.SetClock(func() time.Time {
+		c.Advance(time.Second)
+		return c.Now()
+	})
+
+	succeeded, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+	err := r.Run(ctx, []string{"watch", "svc", succeeded, "--interval", "1ms"})
+	if code := ExitCode(err); code != ExitSuccess {
+		t.Errorf("successful run: exit code %d, err %v", code, err)
+	}
+	if !strings.Contains(stdout.String(), "build | compiling") {
+		t.Errorf("job log not tailed:\n%s", stdout)
+	}
+	if !strings.Contains(stderr.String(), "Run "+succeeded+": SUCCESS") {
+		t.Errorf("final status not reported:\n%s", stderr)
+	}
+
+	failed, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{FailJobs: []string{"build"}})
+	// Without a run ID the latest run is watched
+	err = r.Run(ctx, []string{"watch", "--interval", "1ms", "--no-logs", "svc"})
+	if code := ExitCode(err); code != ExitFailed || !strings.Contains(err.Error(), failed) {
+		t.Errorf("failed run: exit code %d, err %v", code, err)
+	}
+
+	canceled, _ := service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+	service.StopPipelineRun(testOrg, pipelineID, canceled)
+	if code := ExitCode(r.Run(ctx, []string{"watch", "svc", canceled, "--interval", "1ms"})); code != ExitCanceled {
+		t.Errorf("canceled run: exit code %d", code)
+	}
+
+	if code := ExitCode(r.Run(ctx, []string{"watch", "svc", "999", "--interval", "1ms"})); code != ExitUnknown {
+		t.Errorf("unknown run: exit code %d", code)
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	r, service, c, stdout, _ := newRunner(t)
+	pipelineID, _ := service.PipelineID("svc")
+	service.SetClock(func() time.Time {
+		c.Advance(time.Second)
+		return c.Now()
+	})
+	service.AddRun(pipelineID, c.Now(), fake.RunOptions{})
+
+	err := r.Run(context.Background(), []string{"run", "svc", "--watch", "--interval", "1ms"})
+	if code := ExitCode(err); code != ExitSuccess {
+		t.Errorf("exit code %d, err %v", code, err)
+	}
+	if !strings.Contains(stdout.String(), "deploy | ") {
+		t.Errorf("logs of the new run not tailed:\n%s", stdout)
+	}
+}
@@ -0,0 +1,239 @@
+// Package credentials resolves the secrets referenced from the flowt
+// configuration, so that config.yml does not have to hold them in plain text.
+//
+// A configured secret is one of:
+//
+//	env:NAME      the value of the environment variable NAME
+//	cmd:COMMAND   the first line printed by COMMAND, run by the shell
+//	enc:NAME      the secret NAME from the encrypted credential store
+//
+// Any other value is the secret itself.
+package credentials
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"aliyun-pipelines-tui/internal/fileutil"
+)
+
+// Prefixes of secret references.
+const (
+	EnvPrefix       = "env:"
+	CommandPrefix   = "cmd:"
+	EncryptedPrefix = "enc:"
+)
+
+// Names of the credential store files in the configuration directory.
+const (
+	StoreFileName = "credentials.enc"
+	KeyFileName   = "credentials.key"
+)
+
+// IsReference reports whether value refers to a secret kept elsewhere rather
+// than being the secret itself.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, EnvPrefix) ||
+		strings.HasPrefix(value, CommandPrefix) ||
+		strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// Resolve returns the secret that value refers to. The store is only opened
+// for enc: references.
+func Resolve(value string, store *Store) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimPrefix(value, EnvPrefix)
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, CommandPrefix):
+		return runCommand(strings.TrimPrefix(value, CommandPrefix))
+	case strings.HasPrefix(value, EncryptedPrefix):
+		return store.Get(strings.TrimPrefix(value, EncryptedPrefix))
+	default:
+		return value, nil
+	}
+}
+
+// runCommand runs a credential command and returns the first line of its
+// output. The command's stderr and stdin are those of flowt, so that it can
+// prompt for a passphrase.
+func runCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential command %q failed: %w", command, err)
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	line = strings.TrimRight(line, "\r")
+	if line == "" {
+		return "", fmt.Errorf("credential command %q printed nothing", command)
+	}
+	return line, nil
+}
+
+// Store is a file of named secrets encrypted with AES-256-GCM. The key is kept
+// in a separate file that only the user can read, so that config.yml and the
+// store can be backed up or shared without giving the secrets away.
+type Store struct {
+	Path    string // The encrypted secrets
+	KeyPath string // The 32 byte key, created by the first Set
+}
+
+// NewStore returns the store kept in the configuration directory dir.
+func NewStore(dir string) *Store {
+	return &Store{
+		Path:    filepath.Join(dir, StoreFileName),
+		KeyPath: filepath.Join(dir, KeyFileName),
+	}
+}
+
+// Get returns the named secret.
+func (s *Store) Get(name string) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("no credential store for secret %s", name)
+	}
+	secrets, err := s.load(false)
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in %s", name, s.Path)
+	}
+	return secret, nil
+}
+
+// Set stores the named secret, creating the store and its key if needed.
+func (s *Store) Set(name, secret string) error {
+	secrets, err := s.load(true)
+	if err != nil {
+		return err
+	}
+	secrets[name] = secret
+	return s.save(secrets)
+}
+
+// load decrypts the store. A missing store is empty; with create, a missing
+// key is generated.
+func (s *Store) load(create bool) (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if create {
+			if _, err := s.key(true); err != nil {
+				return nil, err
+			}
+		}
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential store: %w", err)
+	}
+
+	aead, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("credential store %s is corrupt", s.Path)
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s with the key in %s: %w", s.Path, s.KeyPath, err)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("credential store %s is corrupt: %w", s.Path, err)
+	}
+	return secrets, nil
+}
+
+// save encrypts secrets into the store.
+func (s *Store) save(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	aead, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return fileutil.WritePrivateFile(s.Path, aead.Seal(nonce, nonce, plaintext, nil))
+}
+
+func (s *Store) cipher(create bool) (cipher.AEAD, error) {
+	key, err := s.key(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential key in %s: %w", s.KeyPath, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// key reads the store's key, generating it if create is set and there is none.
+func (s *Store) key(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.KeyPath)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("credential key %s must be 32 bytes, not %d", s.KeyPath, len(key))
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("failed to read credential key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate credential key: %w", err)
+	}
+	if err := fileutil.WritePrivateFile(s.KeyPath, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadSecret reads a secret from r: the whole input without its trailing
+// newline.
+func ReadSecret(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	secret := string(bytes.TrimRight(data, "\r\n"))
+	if secret == "" {
+		return "", errors.New("no secret given")
+	}
+	return secret, nil
+}
@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	if _, err := store.Get("missing"); err == nil {
+		t.Error("expected an error for a secret in a missing store")
+	}
+	if err := store.Set("default.personal_access_token", "pt-123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("prod.access_key_secret", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store value reads back the same secrets
+	reopened := NewStore(dir)
+	for name, want := range map[string]string{"default.personal_access_token": "pt-123", "prod.access_key_secret": "s3cret"} {
+		if got, err := reopened.Get(name); err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	data, err := os.ReadFile(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("pt-123")) {
+		t.Error("the store holds the secret in plain text")
+	}
+	if runtime.GOOS != "windows" {
+		for _, path := range []string{store.Path, store.KeyPath} {
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("%s: mode %v, %v, want 0600", filepath.Base(path), info.Mode().Perm(), err)
+			}
+		}
+	}
+
+	// The store cannot be read with another key
+	if err := os.WriteFile(store.KeyPath, bytes.Repeat([]byte{1}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("default.personal_access_token"); err == nil || !strings.Contains(err.Error(), "decrypt") {
+		t.Errorf("expected a decryption error with the wrong key, got %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("FLOWT_TEST_TOKEN", "from-env")
+	store := NewStore(t.TempDir())
+	if err := store.Set("token", "from-store"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value, want string
+	}{
+		{"plain-token", "plain-token"},
+		{"env:FLOWT_TEST_TOKEN", "from-env"},
+		{"enc:token", "from-store"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct{ value, want string }{"cmd:printf 'from-cmd\\nignored\\n'", "from-cmd"})
+	}
+	for _, tt := range tests {
+		if got, err := Resolve(tt.value, store); err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"env:FLOWT_TEST_UNSET", "enc:missing", "cmd:exit 3"} {
+		if _, err := Resolve(value, store); err == nil {
+			t.Errorf("Resolve(%q): expected an error", value)
+		}
+	}
+}
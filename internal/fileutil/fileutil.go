@@ -0,0 +1,86 @@
+// Package fileutil holds the file handling shared by the files flowt keeps
+// under ~/.flowt: the configuration, credentials, plan states and schedules.
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WritePrivateFile replaces the file at path with data, readable and writable
+// only by the user. The file is written to a temporary file first so that it
+// is never left half written.
+func WritePrivateFile(path string, data []byte) error {
+	// Replace the target of a symlinked file, not the symlink
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	// CreateTemp already uses 0600, but be explicit about what is relied on
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Lock waits this long for a lock held by another process before giving up
+const lockTimeout = 10 * time.Second
+
+// A lock older than staleLock was left behind by a process that died while
+// holding it; locks are only held while a file is read and written again.
+const staleLock = time.Minute
+
+// Lock takes an advisory lock on the file at path, so that flowt processes
+// reading and writing the file again do not lose each other's changes. The
+// lock is the file path+".lock"; Lock waits while another process holds it.
+// unlock releases the lock.
+func Lock(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock %s: %s is held by another flowt process; remove it if none is running", path, lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,68 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWritePrivateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePrivateFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file holds %q, want new", data)
+	}
+	if info, err := os.Stat(path); runtime.GOOS != "windows" && (err != nil || info.Mode().Perm() != 0600) {
+		t.Errorf("mode %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected only the written file, found %d entries", len(entries))
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.yml")
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		close(locked)
+		unlock()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("the lock was taken twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+
+	// A lock left behind by a process that died is taken over
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	os.Chtimes(path+".lock", old, old)
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left after unlock: %v", err)
+	}
+}
@@ -0,0 +1,624 @@
+// Package mockserver implements a stand-in for the Yunxiao Flow OpenAPI.
+//
+// It serves the /oapi/v1/flow/organizations/{org}/... endpoints api.Client
+// uses, and the Codeup endpoints listing the branches and tags of a
+// repository, with the same response shapes, pagination headers and authentication
+// header, backed by any api.PipelineService (normally the in-memory fake).
+// Pointing the client's endpoint at it exercises the full HTTP code path
+// without a Yunxiao organization.
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	basePath   = "/oapi/v1/flow/organizations/{org}"
+	codeupPath = "/oapi/v1/codeup/organizations/{org}/repositories/{repositoryId}"
+)
+
+// Server is an http.Handler serving the Yunxiao Flow OpenAPI.
+type Server struct {
+	service   api.PipelineService
+	token     string
+	mux       *http.ServeMux
+	requestID atomic.Int64
+
+	// Logger, when set, receives one line per request.
+	Logger *log.Logger
+}
+
+// New returns a server backed by service. Requests must carry token in the
+// x-yunxiao-token header; an empty token accepts any non-empty value.
+func New(service api.PipelineService, token string) *Server {
+	s := &Server{service: service, token: token, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET "+basePath+"/pipelines", s.listPipelines)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/getComponentsWithoutButtons", s.listJobHistorys)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}", s.getPipeline)
+	s.mux.HandleFunc("GET "+basePath+"/pipelineGroups", s.listGroups)
+	s.mux.HandleFunc("GET "+basePath+"/pipelineGroups/pipelines", s.listGroupPipelines)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs", s.listRuns)
+	s.mux.HandleFunc("POST "+basePath+"/pipelines/{pipelineId}/runs", s.createRun)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs/latestPipelineRun", s.latestRun)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs/{runId}", s.getRun)
+	s.mux.HandleFunc("PUT "+basePath+"/pipelines/{pipelineId}/runs/{runId}", s.stopRun)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs/{runId}/job/{jobId}/log", s.jobLog)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs/{runId}/job/{jobId}/steps", s.jobSteps)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/runs/{runId}/job/{jobId}/steps/{stepIndex}/log", s.stepLog)
+	s.mux.HandleFunc("PUT "+basePath+"/pipelines/{pipelineId}/pipelineRuns/{runId}/jobs/{jobId}/{action}", s.jobAction)
+	s.mux.HandleFunc("POST "+basePath+"/pipelines/{pipelineId}/pipelineRuns/{runId}/jobs/{jobId}/operation", s.jobOperation)
+	s.mux.HandleFunc("POST "+basePath+"/pipelines/{pipelineId}/pipelineRuns/{runId}/jobs/{jobId}/{decision}", s.validate)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/deploy/{deployOrderId}", s.deployOrder)
+	s.mux.HandleFunc("GET "+basePath+"/pipelines/{pipelineId}/deploy/{deployOrderId}/machine/{machineSn}/log", s.machineLog)
+	s.mux.HandleFunc("GET "+codeupPath+"/branches", s.listRefs)
+	s.mux.HandleFunc("GET "+codeupPath+"/tags", s.listRefs)
+
+	return s
+}
+
+// ServeHTTP checks the authentication header and dispatches the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := fmt.Sprintf("mock-%06d", s.requestID.Add(1))
+	w.Header().Set("x-acs-request-id", requestID)
+	if s.Logger != nil {
+		s.Logger.Printf("%s %s %s", requestID, r.Method, r.URL.RequestURI())
+	}
+
+	token := r.Header.Get("x-yunxiao-token")
+	if token == "" || (s.token != "" && token != s.token) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "invalid or missing x-yunxiao-token", requestID)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// ---- responses ----
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      false,
+		"errorCode":    code,
+		"errorMessage": message,
+		"requestId":    requestID,
+	})
+}
+
+// writeServiceError maps an error of the backing service to an API error response.
+func writeServiceError(w http.ResponseWriter, err error) {
+	requestID := w.Header().Get("x-acs-request-id")
+	var apiErr *api.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		writeError(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, requestID)
+	case errors.Is(err, api.ErrNotFound):
+		writeError(w, http.StatusNotFound, "NotFound", err.Error(), requestID)
+	case errors.Is(err, api.ErrBadRequest):
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error(), requestID)
+	case errors.Is(err, api.ErrForbidden):
+		writeError(w, http.StatusForbidden, "Forbidden", err.Error(), requestID)
+	case errors.Is(err, api.ErrRateLimited):
+		writeError(w, http.StatusTooManyRequests, "Throttling", err.Error(), requestID)
+	default:
+		writeError(w, http.StatusInternalServerError, "SystemError", err.Error(), requestID)
+	}
+}
+
+// paginate returns the requested page of n items as a [start, end) range and
+// sets the pagination headers the OpenAPI returns.
+func paginate(w http.ResponseWriter, r *http.Request, n int) (int, int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+	if perPage < 1 || perPage > 30 {
+		perPage = 10
+	}
+	totalPages := (n + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	w.Header().Set("x-page", strconv.Itoa(page))
+	w.Header().Set("x-per-page", strconv.Itoa(perPage))
+	w.Header().Set("x-total", strconv.Itoa(n))
+	w.Header().Set("x-total-pages", strconv.Itoa(totalPages))
+	if page < totalPages {
+		w.Header().Set("x-next-page", strconv.Itoa(page+1))
+	}
+
+	start := (page - 1) * perPage
+	if start > n {
+		start = n
+	}
+	end := start + perPage
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func statusList(r *http.Request) []string {
+	if list := r.URL.Query().Get("statusList"); list != "" {
+		return strings.Split(list, ",")
+	}
+	return nil
+}
+
+// ---- handlers ----
+
+func (s *Server) listPipelines(w http.ResponseWriter, r *http.Request) {
+	pipelines, err := s.service.ListPipelinesWithStatusContext(r.Context(), r.PathValue("org"), statusList(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	start, end := paginate(w, r, len(pipelines))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, p := range pipelines[start:end] {
+		id, _ := strconv.Atoi(p.PipelineID)
+		item := map[string]interface{}{
+			"id":                id,
+			"name":              p.Name,
+			"status":            p.Status,
+			"createTime":        millis(p.CreateTime),
+			"updateTime":        millis(p.UpdateTime),
+			"modifierAccountId": p.Modifier,
+			"creator": map[string]interface{}{
+				"id":       p.Creator,
+				"username": p.CreatorName,
+			},
+		}
+		if p.LastRunStatus != "" {
+			// A run in progress has no finish time yet
+			timeField := "finishTime"
+			if p.LastRunStatus == "RUNNING" || p.LastRunStatus == "WAITING" {
+				timeField = "startTime"
+			}
+			item["lastRun"] = map[string]interface{}{
+				"status":  p.LastRunStatus,
+				timeField: millis(p.LastRunTime),
+			}
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) getPipeline(w http.ResponseWriter, r *http.Request) {
+	definition, err := s.service.GetPipelineContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// The variables are declared in the flow YAML, the repositories are listed as sources
+	flow, err := yaml.Marshal(map[string]interface{}{"variables": definition.Variables})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "InternalError", err.Error(), w.Header().Get("x-acs-request-id"))
+		return
+	}
+	sources := make([]map[string]interface{}, 0, len(definition.Sources))
+	for _, source := range definition.Sources {
+		sources = append(sources, map[string]interface{}{
+			"name": source.Name,
+			"type": source.Type,
+			"data": map[string]interface{}{"repo": source.Repo, "branch": source.Branch},
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"name": definition.Name,
+		"pipelineConfig": map[string]interface{}{
+			"flow":    string(flow),
+			"sources": sources,
+		},
+	})
+}
+
+// listRefs lists the branches or the tags of a repository. The repository ID
+// is the URL-encoded full path of the repository, which the fake accepts in
+// place of its URL.
+func (s *Server) listRefs(w http.ResponseWriter, r *http.Request) {
+	list := s.service.ListRepositoryBranchesContext
+	if strings.HasSuffix(r.URL.Path, "/tags") {
+		list = s.service.ListRepositoryTagsContext
+	}
+	refs, err := list(r.Context(), r.PathValue("org"), r.PathValue("repositoryId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	start, end := paginate(w, r, len(refs))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, ref := range refs[start:end] {
+		items = append(items, map[string]interface{}{
+			"name":   ref.Name,
+			"commit": map[string]interface{}{"id": ref.CommitID},
+		})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.service.ListPipelineGroupsContext(r.Context(), r.PathValue("org"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	start, end := paginate(w, r, len(groups))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, g := range groups[start:end] {
+		id, _ := strconv.Atoi(g.GroupID)
+		items = append(items, map[string]interface{}{"id": id, "name": g.Name})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) listGroupPipelines(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.Atoi(r.URL.Query().Get("groupId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "groupId is required", w.Header().Get("x-acs-request-id"))
+		return
+	}
+	options := map[string]interface{}{}
+	if list := r.URL.Query().Get("statusList"); list != "" {
+		options["statusList"] = list
+	}
+	if name := r.URL.Query().Get("pipelineName"); name != "" {
+		options["pipelineName"] = name
+	}
+
+	pipelines, err := s.service.ListPipelineGroupPipelinesContext(r.Context(), r.PathValue("org"), groupID, options)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	start, end := paginate(w, r, len(pipelines))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, p := range pipelines[start:end] {
+		id, _ := strconv.Atoi(p.PipelineID)
+		items = append(items, map[string]interface{}{
+			"pipelineId":   id,
+			"pipelineName": p.Name,
+			"gmtCreate":    millis(p.CreateTime),
+		})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.service.ListPipelineRunsContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	start, end := paginate(w, r, len(runs))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, run := range runs[start:end] {
+		runID, _ := strconv.Atoi(run.RunID)
+		pipelineID, _ := strconv.Atoi(run.PipelineID)
+		items = append(items, map[string]interface{}{
+			"pipelineRunId": runID,
+			"pipelineId":    pipelineID,
+			"status":        run.Status,
+			"startTime":     millis(run.StartTime),
+			"endTime":       millis(run.FinishTime),
+			"triggerMode":   fake.TriggerModeCode(run.TriggerMode),
+		})
+	}
+	writeJSON(w, items)
+}
+
+// createRun answers with the bare run ID like CreatePipelineRun does.
+func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Params string `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "invalid request body: "+err.Error(), w.Header().Get("x-acs-request-id"))
+		return
+	}
+
+	// params is a JSON document in a string; nested values are passed on as JSON text
+	params := map[string]string{}
+	if body.Params != "" {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(body.Params), &raw); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidParameter", "params is not a JSON object: "+err.Error(), w.Header().Get("x-acs-request-id"))
+			return
+		}
+		for key, value := range raw {
+			var text string
+			if err := json.Unmarshal(value, &text); err == nil {
+				params[key] = text
+			} else {
+				params[key] = string(value)
+			}
+		}
+	}
+
+	run, err := s.service.RunPipelineContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), params)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, run.RunID)
+}
+
+func (s *Server) stopRun(w http.ResponseWriter, r *http.Request) {
+	if err := s.service.StopPipelineRunContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, true)
+}
+
+func (s *Server) jobAction(w http.ResponseWriter, r *http.Request) {
+	actions := map[string]func(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId string) error{
+		"retry": s.service.RetryPipelineJobRunContext,
+		"skip":  s.service.SkipPipelineJobRunContext,
+		"stop":  s.service.StopPipelineJobRunContext,
+	}
+	action, ok := actions[r.PathValue("action")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := action(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, true)
+}
+
+func (s *Server) validate(w http.ResponseWriter, r *http.Request) {
+	decisions := map[string]func(ctx context.Context, organizationId, pipelineId, pipelineRunId, jobId, comment string) error{
+		"pass":   s.service.PassPipelineValidateContext,
+		"refuse": s.service.RefusePipelineValidateContext,
+	}
+	decide, ok := decisions[r.PathValue("decision")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidParameter", "invalid request body: "+err.Error(), w.Header().Get("x-acs-request-id"))
+			return
+		}
+	}
+	if err := decide(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId"), body.Comment); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, true)
+}
+
+func (s *Server) jobOperation(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Action string `json:"action"`
+		Data   string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "invalid request body: "+err.Error(), w.Header().Get("x-acs-request-id"))
+		return
+	}
+	if body.Action == "" {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "action is required", w.Header().Get("x-acs-request-id"))
+		return
+	}
+	// The action's params travel as a JSON string in data
+	action := api.JobAction{Type: body.Action, Data: body.Data}
+	if body.Data != "" {
+		if err := json.Unmarshal([]byte(body.Data), &action.Params); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidParameter", "invalid action data: "+err.Error(), w.Header().Get("x-acs-request-id"))
+			return
+		}
+	}
+	if err := s.service.ExecutePipelineJobActionContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId"), action); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, true)
+}
+
+func (s *Server) latestRun(w http.ResponseWriter, r *http.Request) {
+	info, err := s.service.GetLatestPipelineRunInfoContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	runID, _ := strconv.Atoi(info.RunID)
+	pipelineID, _ := strconv.Atoi(info.PipelineID)
+	sources := make([]map[string]interface{}, 0, len(info.RepositoryURLs))
+	for repo, branch := range info.RepositoryURLs {
+		sources = append(sources, map[string]interface{}{
+			"type": "codeup",
+			"data": map[string]interface{}{"repo": repo, "branch": branch},
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"pipelineRunId": runID,
+		"pipelineId":    pipelineID,
+		"status":        info.Status,
+		"triggerMode":   fake.TriggerModeCode(info.TriggerMode),
+		"createTime":    millis(info.StartTime),
+		"endTime":       millis(info.FinishTime),
+		"sources":       sources,
+	})
+}
+
+func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
+	details, err := s.service.GetPipelineRunDetailsContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	stages := make([]map[string]interface{}, 0, len(details.Stages))
+	for _, stage := range details.Stages {
+		jobs := make([]map[string]interface{}, 0, len(stage.Jobs))
+		for _, job := range stage.Jobs {
+			actions := make([]map[string]interface{}, 0, len(job.Actions))
+			for _, action := range job.Actions {
+				actions = append(actions, map[string]interface{}{
+					"type":        action.Type,
+					"displayType": action.DisplayType,
+					"data":        action.Data,
+					"disable":     action.Disable,
+					"params":      action.Params,
+					"name":        action.Name,
+					"title":       action.Title,
+					"order":       action.Order,
+				})
+			}
+			jobs = append(jobs, map[string]interface{}{
+				"id":        job.ID,
+				"jobSign":   job.JobSign,
+				"name":      job.Name,
+				"status":    job.Status,
+				"startTime": millis(job.StartTime),
+				"endTime":   millis(job.EndTime),
+				"result":    job.Result,
+				"actions":   actions,
+			})
+		}
+		stages = append(stages, map[string]interface{}{
+			"index":     stage.Index,
+			"name":      stage.Name,
+			"stageInfo": map[string]interface{}{"jobs": jobs},
+		})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"pipelineRunId": details.PipelineRunID,
+		"pipelineId":    details.PipelineID,
+		"status":        details.Status,
+		"triggerMode":   details.TriggerMode,
+		"createTime":    details.CreateTime,
+		"updateTime":    details.UpdateTime,
+		"stages":        stages,
+	})
+}
+
+func (s *Server) jobLog(w http.ResponseWriter, r *http.Request) {
+	content, err := s.service.GetPipelineJobRunLogContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"content": content, "more": false})
+}
+
+func (s *Server) jobSteps(w http.ResponseWriter, r *http.Request) {
+	steps, err := s.service.GetPipelineJobStepsContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items := make([]map[string]interface{}, 0, len(steps))
+	for _, step := range steps {
+		items = append(items, map[string]interface{}{
+			"stepIndex": step.Index,
+			"name":      step.Name,
+			"status":    step.Status,
+			"startTime": millis(step.StartTime),
+			"endTime":   millis(step.EndTime),
+		})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) stepLog(w http.ResponseWriter, r *http.Request) {
+	content, err := s.service.GetPipelineJobStepLogContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("runId"), r.PathValue("jobId"), r.PathValue("stepIndex"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"content": content, "more": false})
+}
+
+func (s *Server) listJobHistorys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	runs, err := s.service.ListPipelineJobHistorysContext(r.Context(), r.PathValue("org"), q.Get("pipelineId"), q.Get("category"), q.Get("identifier"), page, perPage)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items := make([]map[string]interface{}, 0, len(runs))
+	for _, run := range runs {
+		runID, _ := strconv.Atoi(run.RunID)
+		var executeNumber int
+		fmt.Sscanf(run.TriggerMode, "Execute #%d", &executeNumber)
+		items = append(items, map[string]interface{}{
+			"pipelineRunId": runID,
+			"status":        run.Status,
+			"executeNumber": executeNumber,
+			"identifier":    q.Get("identifier"),
+		})
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) deployOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.service.GetVMDeployOrderContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("deployOrderId"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	// api.VMDeployOrder carries the OpenAPI's JSON field names
+	writeJSON(w, order)
+}
+
+func (s *Server) machineLog(w http.ResponseWriter, r *http.Request) {
+	machineLog, err := s.service.GetVMDeployMachineLogContext(r.Context(), r.PathValue("org"), r.PathValue("pipelineId"), r.PathValue("deployOrderId"), r.PathValue("machineSn"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, machineLog)
+}
@@ -0,0 +1,394 @@
+package mockserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+const (
+	testToken = "test-token"
+	testOrg   = "org1"
+)
+
+// newTestServer serves a fake with one deploying pipeline and enough others to
+// span several pages, and returns a real client pointed at it.
+func newTestServer(t *testing.T) (*api.Client, *fake.Service, *fake.Clock, string) {
+	t.Helper()
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		t.Setenv(name, "")
+	}
+
+	service, c := fake.NewWithClock()
+	group := service.AddGroup("group")
+	p := service.AddPipeline("svc", []fake.StageSpec{
+		{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: 10 * time.Second, Log: []string{"a", "b", "c", "d"}}}},
+		{Name: "Deploy", Jobs: []fake.JobSpec{{Name: "deploy", Duration: 10 * time.Second, Deploy: &fake.DeploySpec{Machines: []string{"10.0.0.1", "10.0.0.2"}, Batches: 2}}}},
+	}, map[string]string{"https://example.com/svc.git": "master"}, group.GroupID)
+	for i := 0; i < 34; i++ {
+		service.AddPipeline(fmt.Sprintf("idle-%02d", i), nil, nil)
+	}
+
+	srv := httptest.NewServer(New(service, testToken))
+	t.Cleanup(srv.Close)
+
+	client, err := api.NewClientWithToken(srv.URL, testToken)
+	if err != nil {
+		t.Fatalf("NewClientWithToken: %v", err)
+	}
+	client.SetRetryPolicy(api.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	return client, service, c, p.PipelineID
+}
+
+func TestListingOverHTTP(t *testing.T) {
+	client, service, c, pid := newTestServer(t)
+
+	pipelines, err := client.ListPipelines(testOrg)
+	if err != nil {
+		t.Fatalf("ListPipelines: %v", err)
+	}
+	if len(pipelines) != 35 {
+		t.Errorf("got %d pipelines over several pages, want 35", len(pipelines))
+	}
+
+	groups, err := client.ListPipelineGroups(testOrg)
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("ListPipelineGroups = %v, %v", groups, err)
+	}
+	groupID, _ := strconv.Atoi(groups[0].GroupID)
+	inGroup, err := client.ListPipelineGroupPipelines(testOrg, groupID, nil)
+	if err != nil || len(inGroup) != 1 || inGroup[0].PipelineID != pid {
+		t.Errorf("ListPipelineGroupPipelines = %v, %v", inGroup, err)
+	}
+
+	for i := 0; i < 35; i++ {
+		service.AddRun(pid, c.Now().Add(-time.Duration(35-i)*time.Hour), fake.RunOptions{})
+	}
+	runs, err := client.ListPipelineRuns(testOrg, pid)
+	if err != nil {
+		t.Fatalf("ListPipelineRuns: %v", err)
+	}
+	if len(runs) != 35 || runs[0].Status != "SUCCESS" {
+		t.Errorf("got %d runs, first %+v", len(runs), runs[0])
+	}
+}
+
+func TestStatusFilterOverHTTP(t *testing.T) {
+	client, _, _, pid := newTestServer(t)
+
+	if _, err := client.RunPipeline(testOrg, pid, nil); err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+	active, err := client.ListPipelinesWithStatus(testOrg, []string{"RUNNING", "WAITING"})
+	if err != nil {
+		t.Fatalf("ListPipelinesWithStatus: %v", err)
+	}
+	if len(active) != 1 || active[0].PipelineID != pid {
+		t.Errorf("ListPipelinesWithStatus = %+v, want only %s", active, pid)
+	}
+}
+
+func TestRunLifecycleOverHTTP(t *testing.T) {
+	client, _, c, pid := newTestServer(t)
+
+	run, err := client.RunPipeline(testOrg, pid, map[string]string{
+		"runningBranchs": `{"https://example.com/svc.git":"feature/x"}`,
+	})
+	if err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+	if run.RunID == "" {
+		t.Fatal("RunPipeline returned no run ID")
+	}
+
+	details, err := client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRunDetails: %v", err)
+	}
+	if details.Status != "RUNNING" || details.Stages[0].Jobs[0].Status != "RUNNING" {
+		t.Errorf("unexpected details at start: %+v", details)
+	}
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	var lengths []int
+	for i := 0; i < 3; i++ {
+		log, err := client.GetPipelineJobRunLog(testOrg, pid, run.RunID, jobID)
+		if err != nil {
+			t.Fatalf("GetPipelineJobRunLog: %v", err)
+		}
+		lengths = append(lengths, len(log))
+		c.Advance(4 * time.Second)
+	}
+	if !(lengths[0] < lengths[1] && lengths[1] < lengths[2]) {
+		t.Errorf("log lengths %v should grow", lengths)
+	}
+
+	info, err := client.GetLatestPipelineRunInfo(testOrg, pid)
+	if err != nil {
+		t.Fatalf("GetLatestPipelineRunInfo: %v", err)
+	}
+	if info.RunID != run.RunID || info.RepositoryURLs["https://example.com/svc.git"] != "feature/x" {
+		t.Errorf("latest run info = %+v, repos %v", info.PipelineRun, info.RepositoryURLs)
+	}
+
+	if err := client.StopPipelineRun(testOrg, pid, run.RunID); err != nil {
+		t.Fatalf("StopPipelineRun: %v", err)
+	}
+	got, err := client.GetPipelineRun(testOrg, pid, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRun: %v", err)
+	}
+	if got.Status != "CANCELED" {
+		t.Errorf("status after stop = %s, want CANCELED", got.Status)
+	}
+
+	if err := client.StopPipelineRun(testOrg, pid, run.RunID); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("stopping a stopped run: err = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestJobStepsOverHTTP(t *testing.T) {
+	client, _, c, pid := newTestServer(t)
+	run, _ := client.RunPipeline(testOrg, pid, nil)
+	c.Advance(4 * time.Second)
+	details, _ := client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	steps, err := client.GetPipelineJobSteps(testOrg, pid, run.RunID, jobID)
+	if err != nil {
+		t.Fatalf("GetPipelineJobSteps: %v", err)
+	}
+	if len(steps) != 1 || steps[0].Index != "1" || steps[0].Name != "build" || steps[0].Status != "RUNNING" || steps[0].StartTime.IsZero() {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+
+	log, err := client.GetPipelineJobStepLog(testOrg, pid, run.RunID, jobID, steps[0].Index)
+	if err != nil {
+		t.Fatalf("GetPipelineJobStepLog: %v", err)
+	}
+	if log != "a\nb\n" {
+		t.Errorf("step log = %q", log)
+	}
+}
+
+func TestJobActionsOverHTTP(t *testing.T) {
+	client, _, c, pid := newTestServer(t)
+	run, _ := client.RunPipeline(testOrg, pid, nil)
+	c.Advance(4 * time.Second)
+	details, _ := client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	jobID := strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10)
+
+	if err := client.RetryPipelineJobRun(testOrg, pid, run.RunID, jobID); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("retrying a running job: err = %v, want ErrBadRequest", err)
+	}
+	if err := client.StopPipelineJobRun(testOrg, pid, run.RunID, jobID); err != nil {
+		t.Fatalf("StopPipelineJobRun: %v", err)
+	}
+	if err := client.RetryPipelineJobRun(testOrg, pid, run.RunID, jobID); err != nil {
+		t.Fatalf("RetryPipelineJobRun: %v", err)
+	}
+	details, _ = client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	if details.Status != "RUNNING" || details.Stages[0].Jobs[0].Status != "RUNNING" {
+		t.Errorf("after the retry: run %s, job %s", details.Status, details.Stages[0].Jobs[0].Status)
+	}
+}
+
+func TestValidationOverHTTP(t *testing.T) {
+	client, service, c, _ := newTestServer(t)
+	p := service.AddPipeline("release", []fake.StageSpec{
+		{Name: "Approve", Jobs: []fake.JobSpec{{Name: "approval", Validate: &fake.ValidateSpec{Description: "Ship it?", Validators: []string{"alice", "bob"}}}}},
+	}, nil)
+	run, _ := client.RunPipeline(testOrg, p.PipelineID, nil)
+	c.Advance(time.Minute)
+
+	details, err := client.GetPipelineRunDetails(testOrg, p.PipelineID, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRunDetails: %v", err)
+	}
+	job := details.Stages[0].Jobs[0]
+	gate, ok := job.ValidationGate()
+	if !ok || gate.Description != "Ship it?" || len(gate.Validators) != 2 || !gate.Allowed {
+		t.Fatalf("unexpected gate: %+v (actions %+v)", gate, job.Actions)
+	}
+
+	jobID := strconv.FormatInt(job.ID, 10)
+	if err := client.RefusePipelineValidate(testOrg, p.PipelineID, run.RunID, jobID, "not today"); err != nil {
+		t.Fatalf("RefusePipelineValidate: %v", err)
+	}
+	details, _ = client.GetPipelineRunDetails(testOrg, p.PipelineID, run.RunID)
+	if details.Status != "FAILED" {
+		t.Errorf("status after refusing = %s, want FAILED", details.Status)
+	}
+	log, _ := client.GetPipelineJobRunLog(testOrg, p.PipelineID, run.RunID, jobID)
+	if !strings.Contains(log, "Refused by demo: not today") {
+		t.Errorf("the comment did not reach the service:\n%s", log)
+	}
+}
+
+func TestVMDeployOverHTTP(t *testing.T) {
+	client, _, c, pid := newTestServer(t)
+	run, _ := client.RunPipeline(testOrg, pid, nil)
+	c.Advance(12 * time.Second)
+
+	details, err := client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRunDetails: %v", err)
+	}
+	actions := details.Stages[1].Jobs[0].Actions
+	if len(actions) != 1 || actions[0].Type != "GetVMDeployOrder" {
+		t.Fatalf("deploy job actions = %+v", actions)
+	}
+	deployID := strconv.FormatFloat(actions[0].Params["deployOrderId"].(float64), 'f', 0, 64)
+
+	order, err := client.GetVMDeployOrder(testOrg, pid, deployID)
+	if err != nil {
+		t.Fatalf("GetVMDeployOrder: %v", err)
+	}
+	machines := order.DeployMachineInfo.DeployMachines
+	if order.Status != "RUNNING" || order.TotalBatch != 2 || len(machines) != 2 {
+		t.Fatalf("unexpected deploy order: %+v", order)
+	}
+
+	log, err := client.GetVMDeployMachineLog(testOrg, pid, deployID, machines[0].MachineSn)
+	if err != nil {
+		t.Fatalf("GetVMDeployMachineLog: %v", err)
+	}
+	if log.DeployLog == "" {
+		t.Error("machine log is empty")
+	}
+}
+
+func TestJobActionOverHTTP(t *testing.T) {
+	client, service, c, _ := newTestServer(t)
+	p := service.AddPipeline("release", []fake.StageSpec{
+		{Name: "Deploy", Jobs: []fake.JobSpec{{Name: "deploy", Duration: 20 * time.Second, Deploy: &fake.DeploySpec{Machines: []string{"10.0.0.1", "10.0.0.2"}, Batches: 2, Pause: true}}}},
+	}, nil)
+	run, _ := client.RunPipeline(testOrg, p.PipelineID, nil)
+	c.Advance(time.Minute)
+
+	details, err := client.GetPipelineRunDetails(testOrg, p.PipelineID, run.RunID)
+	if err != nil {
+		t.Fatalf("GetPipelineRunDetails: %v", err)
+	}
+	job := details.Stages[0].Jobs[0]
+	var resume *api.JobAction
+	for i, action := range job.Actions {
+		if action.Type == "ResumeVMDeployOrder" {
+			resume = &job.Actions[i]
+		}
+	}
+	if resume == nil {
+		t.Fatalf("the paused deployment offers no resume: %+v", job.Actions)
+	}
+
+	jobID := strconv.FormatInt(job.ID, 10)
+	if err := client.ExecutePipelineJobAction(testOrg, p.PipelineID, run.RunID, jobID, *resume); err != nil {
+		t.Fatalf("ExecutePipelineJobAction: %v", err)
+	}
+	c.Advance(10 * time.Second)
+	details, _ = client.GetPipelineRunDetails(testOrg, p.PipelineID, run.RunID)
+	if details.Status != "SUCCESS" {
+		t.Errorf("status after resuming = %s, want SUCCESS", details.Status)
+	}
+
+	err = client.ExecutePipelineJobAction(testOrg, p.PipelineID, run.RunID, jobID, api.JobAction{Type: "ResumeVMDeployOrder"})
+	if !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("resuming a finished deployment: err = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestPipelineDefinitionOverHTTP(t *testing.T) {
+	client, service, c, pid := newTestServer(t)
+	service.SetVariables(pid, api.PipelineVariable{Name: "DEPLOY_ENV", Value: "staging", Description: "Environment", Runtime: true})
+
+	d, err := client.GetPipeline(testOrg, pid)
+	if err != nil {
+		t.Fatalf("GetPipeline: %v", err)
+	}
+	if d.Name != "svc" || len(d.Sources) != 1 || d.Sources[0].Repo != "https://example.com/svc.git" || d.Sources[0].Branch != "master" {
+		t.Errorf("definition = %+v", d)
+	}
+	vars := d.RuntimeVariables()
+	if len(vars) != 1 || vars[0].Value != "staging" || vars[0].Description != "Environment" {
+		t.Fatalf("variables = %+v", d.Variables)
+	}
+
+	params, _ := api.RunParams(map[string]string{d.Sources[0].Repo: "feature/x"}, map[string]string{"DEPLOY_ENV": "prod"})
+	run, err := client.RunPipeline(testOrg, pid, params)
+	if err != nil {
+		t.Fatalf("RunPipeline: %v", err)
+	}
+	c.Advance(time.Second)
+	details, _ := client.GetPipelineRunDetails(testOrg, pid, run.RunID)
+	log, _ := client.GetPipelineJobRunLog(testOrg, pid, run.RunID, strconv.FormatInt(details.Stages[0].Jobs[0].ID, 10))
+	if !strings.Contains(log, "DEPLOY_ENV=prod") {
+		t.Errorf("the variables did not reach the service:\n%s", log)
+	}
+	info, _ := client.GetLatestPipelineRunInfo(testOrg, pid)
+	if info.RepositoryURLs["https://example.com/svc.git"] != "feature/x" {
+		t.Errorf("branches = %v", info.RepositoryURLs)
+	}
+}
+
+func TestRepositoryRefsOverHTTP(t *testing.T) {
+	client, service, _, _ := newTestServer(t)
+	const repo = "https://example.com/svc.git"
+
+	branches, err := client.ListRepositoryBranches(testOrg, repo)
+	if err != nil {
+		t.Fatalf("ListRepositoryBranches: %v", err)
+	}
+	if len(branches) != 1 || branches[0].Name != "master" || branches[0].CommitID == "" {
+		t.Errorf("branches of a pipeline's repository = %+v", branches)
+	}
+
+	var names []string
+	for i := 0; i < 35; i++ {
+		names = append(names, fmt.Sprintf("feature/%02d", i))
+	}
+	service.SetRefs(repo, names, []string{"v1.0.0", "v1.1.0"})
+	branches, err = client.ListRepositoryBranches(testOrg, repo)
+	if err != nil || len(branches) != 35 || branches[34].Name != "feature/34" {
+		t.Errorf("branches across pages = %d, %v", len(branches), err)
+	}
+	tags, err := client.ListRepositoryTags(testOrg, repo)
+	if err != nil || len(tags) != 2 || tags[1].Name != "v1.1.0" {
+		t.Errorf("tags = %+v, %v", tags, err)
+	}
+
+	if _, err := client.ListRepositoryBranches(testOrg, "https://example.com/nope.git"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown repository: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestErrorsOverHTTP(t *testing.T) {
+	client, service, _, pid := newTestServer(t)
+
+	if _, err := client.GetPipelineRun(testOrg, pid, "999"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown run: err = %v, want ErrNotFound", err)
+	}
+
+	service.SetError("ListPipelineRuns", &api.APIError{Kind: api.ErrForbidden, StatusCode: 403, Code: "NoPermission", Message: "denied"})
+	_, err := client.ListPipelineRuns(testOrg, pid)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != api.ErrForbidden || apiErr.Code != "NoPermission" || !strings.HasPrefix(apiErr.RequestID, "mock-") {
+		t.Errorf("injected error = %#v", err)
+	}
+
+	srv := httptest.NewServer(New(service, testToken))
+	defer srv.Close()
+	other, err := api.NewClientWithToken(srv.URL, "wrong")
+	if err != nil {
+		t.Fatalf("NewClientWithToken: %v", err)
+	}
+	if _, err := other.ListPipelineGroups(testOrg); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("wrong token: err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package plan runs pipelines in an order declared in a run plan: a YAML file
+// listing the pipelines to run, the parameters to run them with and which
+// runs each one waits for, such as "run A, when it succeeds run B and C, then
+// D". The progress of a plan is saved next to it so that a plan interrupted
+// by a restart of flowt resumes where it stopped.
+//
+//	name: release-1.9
+//	onFailure: stop          # or continue: only the steps needing a failed one are skipped
+//	steps:
+//	  - pipeline: common-lib
+//	    branch: release/1.9
+//	  - name: order
+//	    pipeline: order-service
+//	    branch: release/1.9
+//	    needs: [common-lib]
+//	  - pipeline: production-release
+//	    variables:
+//	      DEPLOY_ENV: production
+//	    needs: [order]
+package plan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Failure policies of a plan
+const (
+	// Stop starts no more steps once one has failed; the rest are skipped
+	Stop = "stop"
+	// Continue skips the steps needing a failed step and runs the others
+	Continue = "continue"
+)
+
+// Plan is a set of pipeline runs and the order they are started in
+type Plan struct {
+	Name      string `yaml:"name"`
+	OnFailure string `yaml:"onFailure,omitempty"` // Stop (the default) or Continue
+	Steps     []Step `yaml:"steps"`
+}
+
+// Step is a run of a pipeline in a plan
+type Step struct {
+	Name      string            `yaml:"name,omitempty"`      // Defaults to Pipeline
+	Pipeline  string            `yaml:"pipeline"`            // Pipeline name or ID
+	Branch    string            `yaml:"branch,omitempty"`    // Branch to build in every repository
+	Branches  map[string]string `yaml:"branches,omitempty"`  // Branch by repository URL, overriding Branch
+	Variables map[string]string `yaml:"variables,omitempty"` // Values of runtime variables
+	Needs     []string          `yaml:"needs,omitempty"`     // Steps that must succeed before this one starts
+}
+
+// Load reads and checks the plan in a YAML file
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return p, nil
+}
+
+// Parse parses and checks a plan: step names must be unique, needs must name
+// steps of the plan and must not go round in a circle
+func Parse(data []byte) (*Plan, error) {
+	var p Plan
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	switch p.OnFailure {
+	case "":
+		p.OnFailure = Stop
+	case Stop, Continue:
+	default:
+		return nil, fmt.Errorf("onFailure must be %s or %s, not %q", Stop, Continue, p.OnFailure)
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("the plan has no steps")
+	}
+
+	names := make(map[string]bool)
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Pipeline == "" {
+			return nil, fmt.Errorf("step %d has no pipeline", i+1)
+		}
+		if step.Name == "" {
+			step.Name = step.Pipeline
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("there are two steps named %s; name them apart with name:", step.Name)
+		}
+		names[step.Name] = true
+	}
+	for _, step := range p.Steps {
+		for _, need := range step.Needs {
+			if !names[need] {
+				return nil, fmt.Errorf("step %s needs %s, which is not a step of the plan", step.Name, need)
+			}
+		}
+	}
+	if _, err := p.levels(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Step returns the named step
+func (p *Plan) Step(name string) (Step, bool) {
+	for _, step := range p.Steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+// Levels groups the steps by how many steps come before them: the steps
+// needing nothing, then the steps needing only those, and so on. Each level
+// keeps the order of the plan.
+func (p *Plan) Levels() [][]Step {
+	levels, _ := p.levels()
+	return levels
+}
+
+func (p *Plan) levels() ([][]Step, error) {
+	level := make(map[string]int)
+	var visit func(step Step, path []string) (int, error)
+	visit = func(step Step, path []string) (int, error) {
+		if l, ok := level[step.Name]; ok {
+			return l, nil
+		}
+		for _, name := range path {
+			if name == step.Name {
+				return 0, fmt.Errorf("the needs of the steps go round in a circle: %s", strings.Join(append(path, step.Name), " -> "))
+			}
+		}
+		l := 0
+		for _, need := range step.Needs {
+			needed, _ := p.Step(need)
+			nl, err := visit(needed, append(path, step.Name))
+			if err != nil {
+				return 0, err
+			}
+			if nl+1 > l {
+				l = nl + 1
+			}
+		}
+		level[step.Name] = l
+		return l, nil
+	}
+
+	var levels [][]Step
+	for _, step := range p.Steps {
+		if _, err := visit(step, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, step := range p.Steps {
+		l := level[step.Name]
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], step)
+	}
+	return levels, nil
+}
@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`
+name: release
+steps:
+  - pipeline: common-lib
+    branch: release/1.9
+  - name: order
+    pipeline: order-service
+    needs: [common-lib]
+  - pipeline: payment-service
+    needs: [common-lib]
+  - pipeline: production-release
+    variables: {DEPLOY_ENV: production}
+    needs: [order, payment-service]
+  - pipeline: docs
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.OnFailure != Stop {
+		t.Errorf("default failure policy = %s", p.OnFailure)
+	}
+	var levels []string
+	for _, level := range p.Levels() {
+		var names []string
+		for _, step := range level {
+			names = append(names, step.Name)
+		}
+		levels = append(levels, strings.Join(names, ","))
+	}
+	if got := strings.Join(levels, " | "); got != "common-lib,docs | order,payment-service | production-release" {
+		t.Errorf("levels = %s", got)
+	}
+	if step, ok := p.Step("production-release"); !ok || step.Variables["DEPLOY_ENV"] != "production" {
+		t.Errorf("step = %+v", step)
+	}
+}
+
+func TestParseRejectsBadPlans(t *testing.T) {
+	tests := []struct {
+		plan, want string
+	}{
+		{"steps: []", "no steps"},
+		{"onFailure: retry\nsteps: [{pipeline: a}]", "onFailure"},
+		{"steps: [{name: a}]", "has no pipeline"},
+		{"steps: [{pipeline: a}, {pipeline: a}]", "two steps named a"},
+		{"steps: [{pipeline: a, needs: [b]}]", "not a step"},
+		{"steps: [{pipeline: a, needs: [c]}, {pipeline: b, needs: [a]}, {pipeline: c, needs: [b]}]", "a -> c -> b -> a"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.plan))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error about %q", tt.plan, err, tt.want)
+		}
+	}
+}
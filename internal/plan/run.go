@@ -0,0 +1,410 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/fileutil"
+	"aliyun-pipelines-tui/internal/runwatch"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultInterval is the interval the runs of a plan are polled at, the same
+// as the TUI's refresh.
+const DefaultInterval = 5 * time.Second
+
+// Statuses of a step besides those of its run
+const (
+	StatusPending = "PENDING" // Waiting for the steps it needs
+	StatusSkipped = "SKIPPED" // Not run because a step it needs failed or the plan stopped
+)
+
+// StepState is how far a step of a plan has got
+type StepState struct {
+	PipelineID string    `yaml:"pipelineId,omitempty"`
+	RunID      string    `yaml:"runId,omitempty"`
+	Status     string    `yaml:"status"`
+	StartedAt  time.Time `yaml:"startedAt,omitempty"`
+	FinishedAt time.Time `yaml:"finishedAt,omitempty"`
+	Error      string    `yaml:"error,omitempty"` // Why the step failed to start or was skipped
+}
+
+// Finished reports whether the step has nothing more to do
+func (s StepState) Finished() bool {
+	return s.Status == StatusSkipped || runwatch.IsFinished(s.Status)
+}
+
+// Failed reports whether the step failed, or failed to start: its run
+// finished without succeeding. A step that finished is always either
+// successful, failed or skipped, so that the steps needing it can go on.
+func (s StepState) Failed() bool {
+	return s.Finished() && s.Status != "SUCCESS" && s.Status != StatusSkipped
+}
+
+// State is the progress of a plan, by step name
+type State struct {
+	Plan  string                `yaml:"plan"`
+	Steps map[string]*StepState `yaml:"steps"`
+}
+
+// Step returns the state of a step, pending if the step has not got anywhere
+func (s *State) Step(name string) StepState {
+	if state, ok := s.Steps[name]; ok && state.Status != "" {
+		return *state
+	}
+	return StepState{Status: StatusPending}
+}
+
+// StatePath returns where the progress of the plan in a file is saved:
+// release.yml is followed in release.state.yml
+func StatePath(planPath string) string {
+	return strings.TrimSuffix(planPath, filepath.Ext(planPath)) + ".state.yml"
+}
+
+// LoadState reads the progress of a plan; a plan that has not been run yet
+// has an empty state
+func LoadState(path string) (*State, error) {
+	state := &State{Steps: make(map[string]*StepState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse plan state %s: %w", path, err)
+	}
+	if state.Steps == nil {
+		state.Steps = make(map[string]*StepState)
+	}
+	return state, nil
+}
+
+// SaveState writes the progress of a plan, replacing the file at once so that
+// an interruption never leaves half of it
+func SaveState(path string, state *State) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode plan state: %w", err)
+	}
+	return fileutil.WritePrivateFile(path, data)
+}
+
+// Executor runs plans against a pipeline service.
+type Executor struct {
+	Service        api.PipelineService
+	OrganizationID string
+	Interval       time.Duration // DefaultInterval if zero
+
+	// Save, if set, is called with the state whenever it changes.
+	Save func(*State) error
+	// OnChange, if set, is called whenever the status of a step changes.
+	OnChange func(step string, state StepState)
+	// Sleep waits between polls; tests replace it. It defaults to a timer
+	// that honors ctx.
+	Sleep func(ctx context.Context, d time.Duration) error
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+}
+
+// Run runs a plan until every step has finished, starting each step once the
+// steps it needs have succeeded. It picks up from state: runs still going are
+// followed rather than started again and steps that succeeded are kept, while
+// steps that failed or were skipped are run again. Run returns an error
+// naming the steps that did not succeed, if any.
+func (e *Executor) Run(ctx context.Context, p *Plan, state *State) error {
+	if state.Steps == nil {
+		state.Steps = make(map[string]*StepState)
+	}
+	state.Plan = p.Name
+	for _, step := range p.Steps {
+		if s := state.Step(step.Name); s.Failed() || s.Status == StatusSkipped {
+			e.set(state, step.Name, StepState{Status: StatusPending})
+		} else if _, ok := state.Steps[step.Name]; !ok {
+			state.Steps[step.Name] = &StepState{Status: StatusPending}
+		}
+	}
+	if err := e.save(state); err != nil {
+		return err
+	}
+
+	// Check every pipeline exists before starting anything
+	pipelineIDs, err := e.resolve(ctx, p, state)
+	if err != nil {
+		return err
+	}
+
+	for {
+		changed := e.poll(ctx, p, state)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		advanced, err := e.advance(ctx, p, state, pipelineIDs)
+		if err != nil {
+			return err
+		}
+		if advanced {
+			changed = true
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if changed {
+			if err := e.save(state); err != nil {
+				return err
+			}
+		}
+
+		done := true
+		for _, step := range p.Steps {
+			if !state.Step(step.Name).Finished() {
+				done = false
+			}
+		}
+		if done {
+			return outcome(p, state)
+		}
+		if err := e.sleep(ctx, e.interval()); err != nil {
+			return err
+		}
+	}
+}
+
+// resolve finds the pipeline of every step, by ID or by exact name
+func (e *Executor) resolve(ctx context.Context, p *Plan, state *State) (map[string]string, error) {
+	pipelines, err := e.Service.ListPipelinesContext(ctx, e.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	ids := make(map[string]string)
+	for _, step := range p.Steps {
+		if id := state.Step(step.Name).PipelineID; id != "" {
+			ids[step.Name] = id
+			continue
+		}
+		var matches []string
+		for _, pipeline := range pipelines {
+			if pipeline.PipelineID == step.Pipeline {
+				matches = []string{pipeline.PipelineID}
+				break
+			}
+			if pipeline.Name == step.Pipeline {
+				matches = append(matches, pipeline.PipelineID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("step %s: pipeline %q not found", step.Name, step.Pipeline)
+		case 1:
+			ids[step.Name] = matches[0]
+		default:
+			return nil, fmt.Errorf("step %s: %d pipelines are named %q, use one of their IDs: %s", step.Name, len(matches), step.Pipeline, strings.Join(matches, ", "))
+		}
+	}
+	return ids, nil
+}
+
+// poll updates the steps whose runs are going and reports whether any changed.
+// A failed poll is tried again next time.
+func (e *Executor) poll(ctx context.Context, p *Plan, state *State) bool {
+	changed := false
+	for _, step := range p.Steps {
+		s := state.Step(step.Name)
+		if s.RunID == "" || s.Finished() {
+			continue
+		}
+		run, err := e.Service.GetPipelineRunContext(ctx, e.OrganizationID, s.PipelineID, s.RunID)
+		if err != nil {
+			continue
+		}
+		status := runwatch.Normalize(run.Status)
+		if status == "" || status == s.Status {
+			continue
+		}
+		s.Status = status
+		if runwatch.IsFinished(status) {
+			s.FinishedAt = e.now()
+		}
+		e.set(state, step.Name, s)
+		changed = true
+	}
+	return changed
+}
+
+// advance skips the steps that can no longer run and starts those whose
+// needs have succeeded, and reports whether any step changed
+func (e *Executor) advance(ctx context.Context, p *Plan, state *State, pipelineIDs map[string]string) (bool, error) {
+	changed := false
+	for progress := true; progress; {
+		progress = false
+		for _, step := range p.Steps {
+			if state.Step(step.Name).Status != StatusPending {
+				continue
+			}
+			if reason := e.blocked(p, state, step); reason != "" {
+				e.set(state, step.Name, StepState{Status: StatusSkipped, Error: reason})
+				progress = true
+				continue
+			}
+			ready := true
+			for _, need := range step.Needs {
+				if state.Step(need).Status != "SUCCESS" {
+					ready = false
+				}
+			}
+			if ready {
+				if err := e.start(ctx, state, step, pipelineIDs[step.Name]); err != nil {
+					return true, err
+				}
+				if ctx.Err() != nil {
+					return true, nil
+				}
+				progress = true
+			}
+		}
+		changed = changed || progress
+	}
+	return changed, nil
+}
+
+// blocked returns why a pending step will never run, if it will not
+func (e *Executor) blocked(p *Plan, state *State, step Step) string {
+	for _, need := range step.Needs {
+		if s := state.Step(need); s.Failed() || s.Status == StatusSkipped {
+			return fmt.Sprintf("%s did not succeed", need)
+		}
+	}
+	if p.OnFailure == Stop {
+		for _, other := range p.Steps {
+			if state.Step(other.Name).Failed() {
+				return fmt.Sprintf("the plan stopped after %s failed", other.Name)
+			}
+		}
+	}
+	return ""
+}
+
+// start starts the run of a step; a step that cannot be started fails. The
+// state is saved as soon as the run is started, so that a plan interrupted
+// right after follows the run when resumed rather than starting another.
+func (e *Executor) start(ctx context.Context, state *State, step Step, pipelineID string) error {
+	s := StepState{PipelineID: pipelineID, Status: "RUNNING", StartedAt: e.now()}
+	params, err := e.params(ctx, step, pipelineID)
+	var run *api.PipelineRun
+	if err == nil {
+		run, err = e.Service.RunPipelineContext(ctx, e.OrganizationID, pipelineID, params)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.Status, s.Error, s.FinishedAt = "FAILED", fmt.Sprintf("failed to start: %v", err), e.now()
+		e.set(state, step.Name, s)
+		return nil
+	}
+	s.RunID = run.RunID
+	if run.Status != "" {
+		s.Status = run.Status
+	}
+	e.set(state, step.Name, s)
+	return e.save(state)
+}
+
+// params returns the parameters of the run of a step. Like `flowt run` and
+// the TUI, the repositories are those of api.RunSources and build their
+// default branch unless the step names another.
+func (e *Executor) params(ctx context.Context, step Step, pipelineID string) (map[string]string, error) {
+	sources, _ := api.LoadRunSources(ctx, e.Service, e.OrganizationID, pipelineID)
+	if len(sources) == 0 && step.Branch != "" && len(step.Branches) == 0 {
+		return nil, fmt.Errorf("cannot determine the repositories of %s to build %s in; list them under branches:", step.Pipeline, step.Branch)
+	}
+	branches := make(map[string]string)
+	for _, source := range sources {
+		branches[source.Repo] = source.Branch
+		if step.Branch != "" {
+			branches[source.Repo] = step.Branch
+		}
+	}
+	for repo, branch := range step.Branches {
+		branches[repo] = branch
+	}
+	return api.RunParams(branches, step.Variables)
+}
+
+// set records the state of a step and reports a change of status
+func (e *Executor) set(state *State, name string, s StepState) {
+	previous := state.Step(name)
+	copied := s
+	state.Steps[name] = &copied
+	if e.OnChange != nil && (previous.Status != s.Status || previous.RunID != s.RunID) {
+		e.OnChange(name, s)
+	}
+}
+
+func (e *Executor) save(state *State) error {
+	if e.Save == nil {
+		return nil
+	}
+	return e.Save(state)
+}
+
+func (e *Executor) interval() time.Duration {
+	if e.Interval > 0 {
+		return e.Interval
+	}
+	return DefaultInterval
+}
+
+func (e *Executor) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
+
+func (e *Executor) sleep(ctx context.Context, d time.Duration) error {
+	if e.Sleep != nil {
+		return e.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// outcome returns an error naming the steps of a finished plan that did not
+// succeed, or nil if all of them did
+func outcome(p *Plan, state *State) error {
+	var failed, skipped []string
+	for _, step := range p.Steps {
+		switch s := state.Step(step.Name); {
+		case s.Status == StatusSkipped:
+			skipped = append(skipped, step.Name)
+		case s.Status != "SUCCESS":
+			failed = append(failed, step.Name)
+		}
+	}
+	if len(failed) == 0 && len(skipped) == 0 {
+		return nil
+	}
+	var parts []string
+	if len(failed) > 0 {
+		parts = append(parts, strings.Join(failed, ", ")+" failed")
+	}
+	if len(skipped) > 0 {
+		parts = append(parts, strings.Join(skipped, ", ")+" skipped")
+	}
+	return fmt.Errorf("plan %s did not succeed: %s", p.Name, strings.Join(parts, "; "))
+}
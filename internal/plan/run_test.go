@@ -0,0 +1,243 @@
+package plan
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+const org = "org"
+
+// newService returns a fake with pipelines a to e taking a minute each, of
+// which e fails, and slow taking five
+func newService(t *testing.T) (*fake.Service, *fake.Clock) {
+	t.Helper()
+	s, c := fake.NewWithClock()
+	for _, name := range []string{"a", "b", "c", "d", "e", "slow"} {
+		duration := time.Minute
+		if name == "slow" {
+			duration = 5 * time.Minute
+		}
+		s.AddPipeline(name, []fake.StageSpec{
+			{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: duration, Fail: name == "e"}}},
+		}, map[string]string{"https://example.com/" + name + ".git": "master"})
+	}
+	return s, c
+}
+
+func mustParse(t *testing.T, text string) *Plan {
+	t.Helper()
+	p, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return p
+}
+
+// statuses returns the status of each step of a plan as name=STATUS
+func statuses(p *Plan, state *State) string {
+	var parts []string
+	for _, step := range p.Steps {
+		parts = append(parts, step.Name+"="+state.Step(step.Name).Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestRunFollowsNeeds(t *testing.T) {
+	s, c := newService(t)
+	p := mustParse(t, `
+name: release
+steps:
+  - pipeline: a
+    branch: release/1.9
+  - {pipeline: b, needs: [a]}
+  - {pipeline: c, needs: [a]}
+  - {pipeline: d, needs: [b, c], variables: {DEPLOY_ENV: production}}
+`)
+
+	started := make(map[string]time.Time)
+	e := &Executor{Service: s, OrganizationID: org, Sleep: c.Sleep, Now: c.Now, Interval: 10 * time.Second,
+		OnChange: func(step string, state StepState) {
+			if state.RunID != "" && started[step].IsZero() {
+				started[step] = c.Now()
+			}
+		}}
+	state := &State{}
+	if err := e.Run(context.Background(), p, state); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := statuses(p, state); got != "a=SUCCESS b=SUCCESS c=SUCCESS d=SUCCESS" {
+		t.Errorf("statuses = %s", got)
+	}
+	if !started["b"].After(started["a"]) || !started["b"].Equal(started["c"]) || !started["d"].After(started["c"]) {
+		t.Errorf("start times = %v", started)
+	}
+
+	a := state.Step("a")
+	info, _ := s.GetLatestPipelineRunInfo(org, a.PipelineID)
+	if info.RepositoryURLs["https://example.com/a.git"] != "release/1.9" {
+		t.Errorf("a built %v", info.RepositoryURLs)
+	}
+}
+
+// lowerCaseService returns the statuses of runs in lower case
+type lowerCaseService struct{ *fake.Service }
+
+func (s lowerCaseService) GetPipelineRunContext(ctx context.Context, organizationId, pipelineId, runId string) (*api.PipelineRun, error) {
+	run, err := s.Service.GetPipelineRunContext(ctx, organizationId, pipelineId, runId)
+	if err == nil {
+		run.Status = strings.ToLower(run.Status)
+	}
+	return run, err
+}
+
+func TestRunAnyStatusCase(t *testing.T) {
+	s, c := newService(t)
+	p := mustParse(t, `
+onFailure: continue
+steps:
+  - pipeline: a
+  - {pipeline: b, needs: [a]}
+  - pipeline: e
+  - {pipeline: c, needs: [e]}
+`)
+	// Give up rather than hang if a step is never started nor skipped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	polls := 0
+	e := &Executor{Service: lowerCaseService{s}, OrganizationID: org, Now: c.Now,
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			if polls++; polls > 100 {
+				cancel()
+			}
+			return c.Sleep(ctx, d)
+		}}
+	state := &State{}
+	err := e.Run(ctx, p, state)
+	if got := statuses(p, state); got != "a=SUCCESS b=SUCCESS e=FAILED c=SKIPPED" {
+		t.Errorf("statuses = %s", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "e failed") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRunFailurePolicies(t *testing.T) {
+	const steps = `
+steps:
+  - pipeline: e
+  - {pipeline: a, needs: [e]}
+  - pipeline: b
+  - {pipeline: c, needs: [b]}
+`
+	tests := []struct {
+		policy, want string
+	}{
+		{Stop, "e=FAILED a=SKIPPED b=SUCCESS c=SKIPPED"},
+		{Continue, "e=FAILED a=SKIPPED b=SUCCESS c=SUCCESS"},
+	}
+	for _, tt := range tests {
+		s, c := newService(t)
+		p := mustParse(t, "onFailure: "+tt.policy+steps)
+		e := &Executor{Service: s, OrganizationID: org, Sleep: c.Sleep, Now: c.Now}
+		state := &State{}
+		err := e.Run(context.Background(), p, state)
+		if got := statuses(p, state); got != tt.want {
+			t.Errorf("%s: statuses = %s, want %s", tt.policy, got, tt.want)
+		}
+		if err == nil || !strings.Contains(err.Error(), "e failed") {
+			t.Errorf("%s: err = %v", tt.policy, err)
+		}
+	}
+}
+
+func TestRunResumes(t *testing.T) {
+	s, c := newService(t)
+	p := mustParse(t, `
+onFailure: continue
+steps:
+  - pipeline: slow
+  - {pipeline: b, needs: [slow]}
+  - {pipeline: e}
+`)
+	path := StatePath(filepath.Join(t.TempDir(), "release.yml"))
+	save := func(state *State) error { return SaveState(path, state) }
+
+	// The first flowt is interrupted while slow runs and e has failed
+	ctx, cancel := context.WithCancel(context.Background())
+	polls := 0
+	e := &Executor{Service: s, OrganizationID: org, Now: c.Now, Save: save, Interval: 10 * time.Second,
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			if polls++; polls == 8 {
+				cancel()
+				return ctx.Err()
+			}
+			return c.Sleep(ctx, d)
+		}}
+	if err := e.Run(ctx, p, &State{}); err != context.Canceled {
+		t.Fatalf("Run: err = %v", err)
+	}
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if got := statuses(p, state); got != "slow=RUNNING b=PENDING e=FAILED" {
+		t.Fatalf("saved statuses = %s", got)
+	}
+	runSlow := state.Step("slow").RunID
+
+	// The next one follows the run of slow, then runs b, and tries e again
+	e = &Executor{Service: s, OrganizationID: org, Now: c.Now, Save: save, Sleep: c.Sleep}
+	if err := e.Run(context.Background(), p, state); err == nil {
+		t.Error("e failed again but the plan succeeded")
+	}
+	if state.Step("slow").RunID != runSlow || state.Step("b").Status != "SUCCESS" {
+		t.Errorf("after resuming: %s, run of slow %s, was %s", statuses(p, state), state.Step("slow").RunID, runSlow)
+	}
+	if runs, _ := s.ListPipelineRuns(org, state.Step("slow").PipelineID); len(runs) != 1 {
+		t.Errorf("slow ran %d times, want 1", len(runs))
+	}
+	if runs, _ := s.ListPipelineRuns(org, state.Step("e").PipelineID); len(runs) != 2 {
+		t.Errorf("e ran %d times, want 2", len(runs))
+	}
+}
+
+func TestRunSavesStartedRuns(t *testing.T) {
+	s, c := newService(t)
+	p := mustParse(t, "steps: [{pipeline: a}, {pipeline: b}]")
+
+	// The run of a is saved before b is started, so that a flowt killed in
+	// between follows it when resumed
+	var first string
+	save := func(state *State) error {
+		if first == "" && state.Step("a").RunID != "" {
+			first = statuses(p, state)
+		}
+		return nil
+	}
+	e := &Executor{Service: s, OrganizationID: org, Now: c.Now, Save: save, Sleep: c.Sleep}
+	if err := e.Run(context.Background(), p, &State{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if first != "a=RUNNING b=PENDING" {
+		t.Errorf("first saved with the run of a: %s", first)
+	}
+}
+
+func TestRunUnknownPipeline(t *testing.T) {
+	s, c := newService(t)
+	p := mustParse(t, "steps: [{pipeline: a}, {pipeline: nope, needs: [a]}]")
+	e := &Executor{Service: s, OrganizationID: org, Sleep: c.Sleep, Now: c.Now}
+	err := e.Run(context.Background(), p, &State{})
+	if err == nil || !strings.Contains(err.Error(), `pipeline "nope" not found`) {
+		t.Errorf("err = %v", err)
+	}
+	if runs, _ := s.ListPipelineRuns(org, "1001"); len(runs) != 0 {
+		t.Error("a step started although the plan names an unknown pipeline")
+	}
+}
@@ -0,0 +1,67 @@
+// Package preset holds named run presets: parameter sets a pipeline is run
+// with again and again, such as a release branch with a hotfix flag. Presets
+// are kept in config.yml under the name of the pipeline they belong to and are
+// used by the run dialog of the terminal UI and by `flowt run --preset`.
+package preset
+
+import "sort"
+
+// Preset is a named set of run parameters for a pipeline
+type Preset struct {
+	Name      string            `yaml:"name"`
+	Branch    string            `yaml:"branch,omitempty"`    // Branch to build in every repository
+	Branches  map[string]string `yaml:"branches,omitempty"`  // Branch by repository URL, overriding Branch
+	Variables map[string]string `yaml:"variables,omitempty"` // Values of runtime variables
+}
+
+// Presets are the presets of every pipeline, by pipeline name
+type Presets map[string][]Preset
+
+// For returns the presets of a pipeline, sorted by name
+func (ps Presets) For(pipeline string) []Preset {
+	presets := append([]Preset(nil), ps[pipeline]...)
+	sort.SliceStable(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// Find returns the named preset of a pipeline
+func (ps Presets) Find(pipeline, name string) (Preset, bool) {
+	for _, p := range ps[pipeline] {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Save adds a preset to a pipeline, replacing the one of the same name
+func (ps Presets) Save(pipeline string, p Preset) {
+	for i, existing := range ps[pipeline] {
+		if existing.Name == p.Name {
+			ps[pipeline][i] = p
+			return
+		}
+	}
+	ps[pipeline] = append(ps[pipeline], p)
+}
+
+// Names returns the names of presets
+func Names(presets []Preset) []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// BranchFor returns the branch the preset builds in a repository, or fallback
+// if it does not say
+func (p Preset) BranchFor(repo, fallback string) string {
+	if branch := p.Branches[repo]; branch != "" {
+		return branch
+	}
+	if p.Branch != "" {
+		return p.Branch
+	}
+	return fallback
+}
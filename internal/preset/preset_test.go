@@ -0,0 +1,40 @@
+package preset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	ps := Presets{}
+	ps.Save("release", Preset{Name: "hotfix", Branch: "hotfix/1.8", Variables: map[string]string{"HOTFIX": "true"}})
+	ps.Save("release", Preset{Name: "canary", Branch: "release/1.9"})
+	ps.Save("release", Preset{Name: "hotfix", Branch: "hotfix/1.8.1"})
+
+	if got := strings.Join(Names(ps.For("release")), ","); got != "canary,hotfix" {
+		t.Errorf("presets of release = %s", got)
+	}
+	if len(ps.For("other")) != 0 {
+		t.Error("a pipeline without presets has some")
+	}
+	p, ok := ps.Find("release", "hotfix")
+	if !ok || p.Branch != "hotfix/1.8.1" || p.Variables != nil {
+		t.Errorf("saving a preset again does not replace it: %+v", p)
+	}
+	if _, ok := ps.Find("release", "nope"); ok {
+		t.Error("found a preset that does not exist")
+	}
+}
+
+func TestBranchFor(t *testing.T) {
+	p := Preset{Branch: "release/1.9", Branches: map[string]string{"https://example.com/web.git": "main"}}
+	if got := p.BranchFor("https://example.com/web.git", "master"); got != "main" {
+		t.Errorf("repository branch = %s", got)
+	}
+	if got := p.BranchFor("https://example.com/api.git", "master"); got != "release/1.9" {
+		t.Errorf("preset branch = %s", got)
+	}
+	if got := (Preset{}).BranchFor("https://example.com/api.git", "master"); got != "master" {
+		t.Errorf("fallback = %s", got)
+	}
+}
@@ -0,0 +1,287 @@
+// Package runwatch follows a pipeline run until it finishes, reporting the
+// status transitions of the run, its stages and jobs, and the new output of
+// each job's log as it is written.
+package runwatch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+// DefaultInterval is the polling interval, the same as the TUI's log refresh.
+const DefaultInterval = 5 * time.Second
+
+// DefaultMaxErrors is the number of consecutive failed polls after which
+// Watch gives up.
+const DefaultMaxErrors = 5
+
+// Transition is a change of status of the run, a stage or a job. Stage is
+// empty for the run itself and Job is empty for a stage.
+type Transition struct {
+	Stage    string
+	Job      string
+	Previous string // Empty when the status is first seen
+	Status   string
+}
+
+// Options configure Watch. Only the callbacks of interest need to be set.
+type Options struct {
+	Interval  time.Duration // DefaultInterval if zero
+	MaxErrors int           // DefaultMaxErrors if zero
+
+	// OnTransition is called for every status change, in pipeline order.
+	OnTransition func(Transition)
+	// OnLog is called with complete lines newly appended to a job's log.
+	OnLog func(stage string, job api.Job, text string)
+	// OnError is called when a poll fails but Watch keeps going.
+	OnError func(error)
+
+	// Sleep waits between polls; it defaults to a timer and is replaced in tests.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Normalize returns a run, stage or job status in the upper case the
+// predicates below and their callers compare it in.
+func Normalize(status string) string {
+	return strings.ToUpper(strings.TrimSpace(status))
+}
+
+// IsFinished reports whether a run or job status is final, in any case.
+func IsFinished(status string) bool {
+	switch Normalize(status) {
+	case "SUCCESS", "FAILED", "CANCELED", "SKIPPED":
+		return true
+	}
+	return false
+}
+
+// HasStarted reports whether a job status means it has (or had) a log, in
+// any case.
+func HasStarted(status string) bool {
+	switch Normalize(status) {
+	case "", "INIT", "QUEUED", "WAITING":
+		return false
+	}
+	return true
+}
+
+// StageStatus derives the status of a stage from the statuses of its jobs. A
+// stage whose jobs have all finished, none failed or canceled, succeeded, even
+// if some or all of them were skipped.
+func StageStatus(stage api.Stage) string {
+	counts := make(map[string]int)
+	finished := 0
+	for _, job := range stage.Jobs {
+		counts[Normalize(job.Status)]++
+		if IsFinished(job.Status) {
+			finished++
+		}
+	}
+	switch {
+	case len(stage.Jobs) == 0:
+		return "INIT"
+	case counts["RUNNING"] > 0:
+		return "RUNNING"
+	case counts["FAILED"] > 0:
+		return "FAILED"
+	case counts["CANCELED"] > 0:
+		return "CANCELED"
+	case finished == len(stage.Jobs):
+		return "SUCCESS"
+	case finished > 0:
+		// Some jobs are done and the rest are about to start
+		return "RUNNING"
+	default:
+		return Normalize(stage.Jobs[0].Status)
+	}
+}
+
+// jobLog tracks how much of a job's log has been reported.
+type jobLog struct {
+	offset int
+	done   bool
+}
+
+// Tail tracks how much of each job's log has been seen, so that a log fetched
+// again yields only its new text. Use NewTail to create one.
+type Tail struct {
+	logs map[int64]*jobLog
+}
+
+// NewTail returns a Tail that has seen nothing yet.
+func NewTail() *Tail {
+	return &Tail{logs: make(map[int64]*jobLog)}
+}
+
+// Done reports whether the whole log of the job has been seen, so that it
+// need not be fetched again.
+func (t *Tail) Done(jobID int64) bool {
+	state := t.logs[jobID]
+	return state != nil && state.done
+}
+
+// Reset forgets what has been seen of the job's log, for a job that runs
+// again after it was retried.
+func (t *Tail) Reset(jobID int64) {
+	delete(t.logs, jobID)
+}
+
+// Next returns the part of content, the job's whole log as fetched now, that
+// has not been returned before. Unless the job has finished, a trailing partial
+// line is held back until it is complete. reset is set when the log was
+// replaced rather than appended to, in which case text starts from the top.
+func (t *Tail) Next(jobID int64, content string, finished bool) (text string, reset bool) {
+	state := t.logs[jobID]
+	if state == nil {
+		state = &jobLog{}
+		t.logs[jobID] = state
+	}
+	if state.done {
+		return "", false
+	}
+
+	if len(content) < state.offset {
+		state.offset = 0
+		reset = true
+	}
+	text = content[state.offset:]
+	if !finished {
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			text = text[:i+1]
+		} else {
+			text = ""
+		}
+	}
+	state.offset += len(text)
+	state.done = finished
+	return text, reset
+}
+
+// Watch polls the run until it finishes and returns its final details. It
+// returns early with an error when ctx is done or when MaxErrors consecutive
+// polls fail.
+func Watch(ctx context.Context, service api.PipelineService, organizationID, pipelineID, runID string, opts Options) (*api.PipelineRunDetails, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.MaxErrors <= 0 {
+		opts.MaxErrors = DefaultMaxErrors
+	}
+	if opts.Sleep == nil {
+		opts.Sleep = sleep
+	}
+
+	statuses := make(map[string]string) // Last status by "", stage or stage/job key
+	logs := NewTail()
+	failures := 0
+
+	for {
+		details, err := service.GetPipelineRunDetailsContext(ctx, organizationID, pipelineID, runID)
+		if err == nil {
+			failures = 0
+			finished := IsFinished(details.Status)
+			report(details, statuses, opts.OnTransition)
+			if err := tailLogs(ctx, service, organizationID, pipelineID, runID, details, finished, logs, opts); err != nil {
+				return nil, err
+			}
+			if finished {
+				return details, nil
+			}
+		} else {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			failures++
+			if failures >= opts.MaxErrors {
+				return nil, fmt.Errorf("giving up after %d failed attempts to get run %s: %w", failures, runID, err)
+			}
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+		}
+
+		if err := opts.Sleep(ctx, opts.Interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// report calls onTransition for each status that changed since the last poll.
+func report(details *api.PipelineRunDetails, statuses map[string]string, onTransition func(Transition)) {
+	emit := func(key string, t Transition) {
+		t.Status = Normalize(t.Status)
+		if t.Status == "" || statuses[key] == t.Status {
+			return
+		}
+		// Stages and jobs that have not started yet are not worth reporting
+		if t.Stage != "" && statuses[key] == "" && !HasStarted(t.Status) {
+			return
+		}
+		t.Previous = statuses[key]
+		statuses[key] = t.Status
+		if onTransition != nil {
+			onTransition(t)
+		}
+	}
+
+	finished := IsFinished(details.Status)
+	if !finished {
+		emit("", Transition{Status: details.Status})
+	}
+	for _, stage := range details.Stages {
+		emit(stage.Name, Transition{Stage: stage.Name, Status: StageStatus(stage)})
+		for _, job := range stage.Jobs {
+			emit(stage.Name+"/"+strconv.FormatInt(job.ID, 10), Transition{Stage: stage.Name, Job: job.Name, Status: job.Status})
+		}
+	}
+	// The final status of the run comes after those of its stages and jobs
+	if finished {
+		emit("", Transition{Status: details.Status})
+	}
+}
+
+// tailLogs reports the new lines of the log of each started job. A job's
+// trailing partial line is held back until the job has finished.
+func tailLogs(ctx context.Context, service api.PipelineService, organizationID, pipelineID, runID string, details *api.PipelineRunDetails, runFinished bool, logs *Tail, opts Options) error {
+	for _, stage := range details.Stages {
+		for _, job := range stage.Jobs {
+			if !HasStarted(job.Status) || logs.Done(job.ID) {
+				continue
+			}
+
+			content, err := service.GetPipelineJobRunLogContext(ctx, organizationID, pipelineID, runID, strconv.FormatInt(job.ID, 10))
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// Logs are best effort; the next poll tries again
+				if opts.OnError != nil {
+					opts.OnError(fmt.Errorf("failed to get the log of job %s: %w", job.Name, err))
+				}
+				continue
+			}
+
+			text, _ := logs.Next(job.ID, content, IsFinished(job.Status) || runFinished)
+			if text != "" && opts.OnLog != nil {
+				opts.OnLog(stage.Name, job, text)
+			}
+		}
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
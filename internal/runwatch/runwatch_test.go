@@ -0,0 +1,213 @@
+package runwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+const org = "org"
+
+func newService(t *testing.T) (*fake.Service, *fake.Clock, string) {
+	t.Helper()
+	s, c := fake.NewWithClock()
+	p := s.AddPipeline("svc", []fake.StageSpec{
+		{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: 10 * time.Second, Log: []string{"a", "b", "c", "d", "e"}}}},
+		{Name: "Test", Jobs: []fake.JobSpec{
+			{Name: "unit", Duration: 10 * time.Second},
+			{Name: "e2e", Duration: 20 * time.Second},
+		}},
+	}, nil)
+	return s, c, p.PipelineID
+}
+
+func TestWatchReportsTransitionsAndLogs(t *testing.T) {
+	s, c, pid := newService(t)
+	runID, _ := s.AddRun(pid, c.Now(), fake.RunOptions{})
+
+	var transitions []string
+	logs := make(map[string]string)
+	details, err := Watch(context.Background(), s, org, pid, runID, Options{
+		Interval: 3 * time.Second,
+		Sleep:    c.Sleep,
+		OnTransition: func(tr Transition) {
+			transitions = append(transitions, fmt.Sprintf("%s/%s:%s", tr.Stage, tr.Job, tr.Status))
+		},
+		OnLog: func(stage string, job api.Job, text string) {
+			if !strings.HasSuffix(text, "\n") {
+				t.Errorf("partial line reported for %s: %q", job.Name, text)
+			}
+			logs[job.Name] += text
+		},
+	})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if details.Status != "SUCCESS" {
+		t.Errorf("final status = %s", details.Status)
+	}
+
+	want := []string{
+		"/:RUNNING",
+		"Build/:RUNNING", "Build/build:RUNNING",
+		"Build/:SUCCESS", "Build/build:SUCCESS", "Test/:RUNNING", "Test/unit:RUNNING", "Test/e2e:RUNNING",
+		"Test/unit:SUCCESS",
+		"Test/:SUCCESS", "Test/e2e:SUCCESS", "/:SUCCESS",
+	}
+	if strings.Join(transitions, " ") != strings.Join(want, " ") {
+		t.Errorf("transitions:\n got %v\nwant %v", transitions, want)
+	}
+
+	// The pieces reported while the job ran add up to the whole log
+	full, _ := s.GetPipelineJobRunLog(org, pid, runID, fmt.Sprint(details.Stages[0].Jobs[0].ID))
+	if logs["build"] != full {
+		t.Errorf("tailed log:\n%q\nwant\n%q", logs["build"], full)
+	}
+	if !strings.Contains(logs["e2e"], "finished successfully") {
+		t.Errorf("e2e log = %q", logs["e2e"])
+	}
+}
+
+func TestWatchFailedAndCanceledRuns(t *testing.T) {
+	s, c, pid := newService(t)
+	failed, _ := s.AddRun(pid, c.Now(), fake.RunOptions{FailJobs: []string{"unit"}})
+	details, err := Watch(context.Background(), s, org, pid, failed, Options{Sleep: c.Sleep})
+	if err != nil || details.Status != "FAILED" {
+		t.Errorf("failed run: status %v, err %v", details, err)
+	}
+
+	canceled, _ := s.AddRun(pid, c.Now(), fake.RunOptions{})
+	sleeps := 0
+	details, err = Watch(context.Background(), s, org, pid, canceled, Options{
+		Interval: time.Second,
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			if sleeps++; sleeps == 2 {
+				s.StopPipelineRun(org, pid, canceled)
+			}
+			return c.Sleep(ctx, d)
+		},
+	})
+	if err != nil || details.Status != "CANCELED" {
+		t.Errorf("canceled run: status %v, err %v", details, err)
+	}
+}
+
+func TestWatchGivesUpAfterRepeatedErrors(t *testing.T) {
+	s, c, pid := newService(t)
+	runID, _ := s.AddRun(pid, c.Now(), fake.RunOptions{})
+	injected := errors.New("boom")
+	s.SetError("GetPipelineRunDetails", injected)
+
+	var warnings int
+	_, err := Watch(context.Background(), s, org, pid, runID, Options{
+		MaxErrors: 3,
+		Sleep:     c.Sleep,
+		OnError:   func(error) { warnings++ },
+	})
+	if !errors.Is(err, injected) || warnings != 2 {
+		t.Errorf("err = %v after %d warnings", err, warnings)
+	}
+}
+
+func TestWatchStopsWhenContextIsDone(t *testing.T) {
+	s, c, pid := newService(t)
+	runID, _ := s.AddRun(pid, c.Now(), fake.RunOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Watch(ctx, s, org, pid, runID, Options{Interval: time.Millisecond}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestIsFinished(t *testing.T) {
+	for status, want := range map[string]bool{
+		"SUCCESS": true, "failed": true, "CANCELED": true, "SKIPPED": true,
+		"RUNNING": false, "WAITING": false, "": false,
+	} {
+		if got := IsFinished(status); got != want {
+			t.Errorf("IsFinished(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestHasStarted(t *testing.T) {
+	for status, want := range map[string]bool{
+		"RUNNING": true, "success": true, "FAILED": true,
+		"INIT": false, "queued": false, "Waiting": false, "": false,
+	} {
+		if got := HasStarted(status); got != want {
+			t.Errorf("HasStarted(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestStageStatus(t *testing.T) {
+	jobs := func(statuses ...string) api.Stage {
+		var stage api.Stage
+		for _, status := range statuses {
+			stage.Jobs = append(stage.Jobs, api.Job{Status: status})
+		}
+		return stage
+	}
+	for _, tc := range []struct {
+		stage api.Stage
+		want  string
+	}{
+		{jobs(), "INIT"},
+		{jobs("INIT", "INIT"), "INIT"},
+		{jobs("SUCCESS", "RUNNING"), "RUNNING"},
+		{jobs("SUCCESS", "INIT"), "RUNNING"},
+		{jobs("SUCCESS", "FAILED"), "FAILED"},
+		{jobs("CANCELED", "SUCCESS"), "CANCELED"},
+		{jobs("SUCCESS", "SUCCESS"), "SUCCESS"},
+		{jobs("SUCCESS", "SKIPPED"), "SUCCESS"},
+		{jobs("SKIPPED", "SKIPPED"), "SUCCESS"},
+		{jobs("SKIPPED", "INIT"), "RUNNING"},
+		{jobs("success", "Running"), "RUNNING"},
+		{jobs("success", "skipped"), "SUCCESS"},
+		{jobs("queued"), "QUEUED"},
+	} {
+		if got := StageStatus(tc.stage); got != tc.want {
+			t.Errorf("StageStatus(%v) = %s, want %s", tc.stage.Jobs, got, tc.want)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	tail := NewTail()
+	steps := []struct {
+		content   string
+		finished  bool
+		want      string
+		wantReset bool
+	}{
+		{"one\ntw", false, "one\n", false},
+		{"one\ntwo\nthr", false, "two\n", false},
+		{"one\ntwo\nthr", false, "", false},
+		{"new\n", false, "new\n", true}, // Replaced by a shorter log
+		{"new\nlast", true, "last", false},
+		{"new\nlast\nmore\n", true, "", false},
+	}
+	for i, step := range steps {
+		text, reset := tail.Next(1, step.content, step.finished)
+		if text != step.want || reset != step.wantReset {
+			t.Errorf("step %d: Next = %q, %v, want %q, %v", i, text, reset, step.want, step.wantReset)
+		}
+	}
+	if !tail.Done(1) || tail.Done(2) {
+		t.Errorf("Done(1) = %v, Done(2) = %v, want true, false", tail.Done(1), tail.Done(2))
+	}
+
+	// A retried job's log is followed again from its start
+	tail.Reset(1)
+	if text, _ := tail.Next(1, "retry\n", false); tail.Done(1) || text != "retry\n" {
+		t.Errorf("after Reset: Next = %q, Done = %v", text, tail.Done(1))
+	}
+}
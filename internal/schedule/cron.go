@@ -0,0 +1,152 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression: minute, hour, day of month, month and day
+// of week, each a list of values, ranges ("1-5") and steps ("*/15", "8-18/2").
+// Days of week run from 0 (Sunday) to 6, with 7 also meaning Sunday. The
+// descriptors @hourly, @daily, @weekly, @monthly and @yearly are accepted too.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // Bit i is set if value i matches
+	domAny, dowAny                bool   // The day fields were "*"
+}
+
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	c := &Cron{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	var err error
+	for i, f := range []struct {
+		bits     *uint64
+		name     string
+		min, max int
+	}{
+		{&c.minute, "minute", 0, 59},
+		{&c.hour, "hour", 0, 23},
+		{&c.dom, "day of month", 1, 31},
+		{&c.month, "month", 1, 12},
+		{&c.dow, "day of week", 0, 7},
+	} {
+		if *f.bits, err = parseCronField(fields[i], f.min, f.max); err != nil {
+			return nil, fmt.Errorf("cron expression %q: %s: %w", expr, f.name, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	return c, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			loText, hiText, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(loText, min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(hiText, min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("range %q goes backwards", rng)
+			}
+		default:
+			v, err := cronValue(rng, min, max)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v // "5/10" means from 5 to the end every 10
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(text string, min, max int) (int, error) {
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", text)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("%d is not between %d and %d", v, min, max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that the expression matches, in the
+// location of t, or the zero time if there is none within five years (e.g.
+// for "0 0 31 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule for days: when both day fields are
+// restricted, a day matching either of them matches
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
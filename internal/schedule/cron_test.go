@@ -0,0 +1,49 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 5, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 22 * * *", time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2024, 5, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, 5, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8-18/4 * * *", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{"5,45 10 * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, time.UTC)},
+		// With both day fields restricted, either matches: the 15th or a Monday
+		{"0 0 15 * 1", time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronRejectsBadExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
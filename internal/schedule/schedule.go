@@ -0,0 +1,230 @@
+// Package schedule keeps pipeline runs that flowt starts later: once at a
+// given time ("run the release at 22:00 tonight") or repeatedly on a cron
+// expression, without touching the triggers of the pipeline in Yunxiao.
+// Schedules are kept in a YAML file next to config.yml and started by
+// `flowt schedule daemon`, which records the outcome of each run.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"aliyun-pipelines-tui/internal/fileutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Grace is how late a run may still be started, e.g. after the daemon was
+// down at the time. Runs missed by longer are recorded as missed, not started.
+const Grace = 15 * time.Minute
+
+// Schedule is a pipeline run to start at a time or on a cron expression
+type Schedule struct {
+	ID             string    `yaml:"id"`
+	OrganizationID string    `yaml:"organizationId"`
+	PipelineID     string    `yaml:"pipelineId"`
+	Pipeline       string    `yaml:"pipeline"`       // Name of the pipeline, for display
+	At             time.Time `yaml:"at,omitempty"`   // Time of a one-off run
+	Cron           string    `yaml:"cron,omitempty"` // Cron expression of a repeated run
+	Preset         string    `yaml:"preset,omitempty"`
+	Branch         string    `yaml:"branch,omitempty"`
+	Variables      []string  `yaml:"variables,omitempty"` // NAME=VALUE, as given to --var
+	Params         []string  `yaml:"params,omitempty"`    // KEY=VALUE, as given to --param
+	CreatedAt      time.Time `yaml:"createdAt"`
+
+	// Outcome of the last time the schedule came due
+	LastAt    time.Time `yaml:"lastAt,omitempty"`
+	LastRunID string    `yaml:"lastRunId,omitempty"`
+	LastError string    `yaml:"lastError,omitempty"`
+	Done      bool      `yaml:"done,omitempty"` // A one-off run that has come due
+}
+
+// Next returns when the schedule is next due, or the zero time if it never
+// will be again
+func (s Schedule) Next() time.Time {
+	if s.Cron == "" {
+		if s.Done {
+			return time.Time{}
+		}
+		return s.At
+	}
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	after := s.CreatedAt
+	if s.LastAt.After(after) {
+		after = s.LastAt
+	}
+	return c.Next(after.Local())
+}
+
+// Due reports whether the schedule should start its run at now, or has
+// missed it by more than Grace
+func (s Schedule) Due(now time.Time) (due, missed bool) {
+	next := s.Next()
+	if next.IsZero() || next.After(now) {
+		return false, false
+	}
+	if now.Sub(next) > Grace {
+		return false, true
+	}
+	return true, false
+}
+
+// Fire records that the schedule came due at now, before its run is started,
+// so that it is not due again even if flowt stops before the outcome is
+// recorded. A one-off schedule is done then.
+func (s *Schedule) Fire(now time.Time) {
+	s.LastAt, s.LastRunID, s.LastError = now, "", ""
+	if s.Cron == "" {
+		s.Done = true
+	}
+}
+
+// Record records the outcome of the schedule having fired: the run it
+// started, or why it did not start one
+func (s *Schedule) Record(runID string, err error) {
+	s.LastRunID, s.LastError = runID, ""
+	if err != nil {
+		s.LastError = err.Error()
+	}
+}
+
+// Status sums up the schedule: SCHEDULED, or the outcome of a one-off run.
+// STARTING is a run being started, or one whose outcome was never recorded.
+func (s Schedule) Status() string {
+	switch {
+	case !s.Done:
+		return "SCHEDULED"
+	case s.LastRunID != "":
+		return "STARTED"
+	case strings.HasPrefix(s.LastError, "missed"):
+		return "MISSED"
+	case s.LastError != "":
+		return "FAILED"
+	default:
+		return "STARTING"
+	}
+}
+
+// Store is the file the schedules are kept in
+type Store struct {
+	Schedules []Schedule `yaml:"schedules"`
+}
+
+// Load reads the schedules in a file; a file that does not exist yet holds
+// none
+func Load(path string) (*Store, error) {
+	store := &Store{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedules: %w", err)
+	}
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse schedules %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Save writes the schedules, replacing the file at once so that the daemon
+// never reads half of it
+func (s *Store) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedules: %w", err)
+	}
+	return fileutil.WritePrivateFile(path, data)
+}
+
+// Update loads the schedules in a file, changes them with fn and saves them
+// unless fn fails. The file is locked meanwhile, so that the changes made by
+// other flowt processes, such as `flowt schedule add` while the daemon runs,
+// are kept.
+func Update(path string, fn func(*Store) error) error {
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	store, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(store); err != nil {
+		return err
+	}
+	return store.Save(path)
+}
+
+// Add adds a schedule, giving it the next free ID, and returns it
+func (s *Store) Add(schedule Schedule) Schedule {
+	next := 1
+	for _, existing := range s.Schedules {
+		if id, err := strconv.Atoi(existing.ID); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+	schedule.ID = strconv.Itoa(next)
+	s.Schedules = append(s.Schedules, schedule)
+	return schedule
+}
+
+// Remove removes the schedule with an ID and reports whether there was one
+func (s *Store) Remove(id string) bool {
+	for i, schedule := range s.Schedules {
+		if schedule.ID == id {
+			s.Schedules = append(s.Schedules[:i], s.Schedules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the schedule with an ID
+func (s *Store) Find(id string) *Schedule {
+	for i := range s.Schedules {
+		if s.Schedules[i].ID == id {
+			return &s.Schedules[i]
+		}
+	}
+	return nil
+}
+
+// ParseAt parses the time of a one-off run relative to now: "22:00" (today,
+// or tomorrow once past), "2024-05-01 22:00", RFC 3339, or "+90m" from now.
+// Times are local.
+func ParseAt(text string, now time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "+") {
+		d, err := time.ParseDuration(text[1:])
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid time %q: expected a duration such as +90m", text)
+		}
+		return now.Add(d).Truncate(time.Second), nil
+	}
+	if t, err := time.ParseInLocation("15:04", text, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			if !t.After(now) {
+				return time.Time{}, fmt.Errorf("%s is in the past", text)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use 22:00, \"2006-01-02 22:00\", RFC 3339 or +90m", text)
+}
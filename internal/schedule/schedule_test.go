@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseAt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 20, 30, 0, 0, time.Local)
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"22:00", time.Date(2024, 5, 1, 22, 0, 0, 0, time.Local)},
+		{"08:15", time.Date(2024, 5, 2, 8, 15, 0, 0, time.Local)},
+		{"2024-05-03 07:00", time.Date(2024, 5, 3, 7, 0, 0, 0, time.Local)},
+		{"+90m", time.Date(2024, 5, 1, 22, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAt(tt.text, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseAt(%q) = %v, %v; want %v", tt.text, got, err, tt.want)
+		}
+	}
+	for _, text := range []string{"2024-04-30 22:00", "tonight", "+-1h", "25:00"} {
+		if _, err := ParseAt(text, now); err == nil {
+			t.Errorf("ParseAt(%q) succeeded", text)
+		}
+	}
+}
+
+func TestDueAndRecord(t *testing.T) {
+	at := time.Date(2024, 5, 1, 22, 0, 0, 0, time.Local)
+	once := Schedule{At: at, CreatedAt: at.Add(-time.Hour)}
+	if due, missed := once.Due(at.Add(-time.Minute)); due || missed {
+		t.Errorf("due before its time")
+	}
+	if due, _ := once.Due(at.Add(time.Minute)); !due {
+		t.Errorf("not due after its time")
+	}
+	if _, missed := once.Due(at.Add(Grace + time.Minute)); !missed {
+		t.Errorf("not missed long after its time")
+	}
+	once.Fire(at)
+	if once.Status() != "STARTING" {
+		t.Errorf("status %s while the run is started", once.Status())
+	}
+	once.Record("42", nil)
+	if due, _ := once.Due(at.Add(time.Minute)); due || once.Status() != "STARTED" {
+		t.Errorf("one-off schedule due again after its run, status %s", once.Status())
+	}
+
+	daily := Schedule{Cron: "0 22 * * *", CreatedAt: at.Add(-time.Hour)}
+	daily.Fire(at.Add(time.Second))
+	daily.Record("", errors.New("failed to run"))
+	if next := daily.Next(); !next.Equal(at.AddDate(0, 0, 1)) || daily.Status() != "SCHEDULED" {
+		t.Errorf("next = %v, status %s", next, daily.Status())
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.yml")
+	for i := 0; i < 3; i++ {
+		err := Update(path, func(s *Store) error {
+			s.Add(Schedule{Pipeline: "svc", Cron: "@daily"})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	Update(path, func(s *Store) error {
+		if !s.Remove("2") || s.Remove("2") {
+			t.Errorf("remove")
+		}
+		return nil
+	})
+	Update(path, func(s *Store) error {
+		if added := s.Add(Schedule{Pipeline: "svc"}); added.ID != "4" {
+			t.Errorf("new ID %s, want 4", added.ID)
+		}
+		return errors.New("changed my mind")
+	})
+
+	store, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Schedules) != 2 || store.Find("3") == nil || store.Find("4") != nil {
+		t.Errorf("schedules = %+v", store.Schedules)
+	}
+}
+
+func TestConcurrentUpdatesKeepEachOther(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.yml")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Update(path, func(s *Store) error {
+				s.Add(Schedule{Pipeline: "svc"})
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if store, _ := Load(path); len(store.Schedules) != 10 {
+		t.Errorf("%d schedules kept of 10 added", len(store.Schedules))
+	}
+}
@@ -0,0 +1,483 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/batch"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var (
+	// Pipelines marked in the pipeline table for a batch run, by pipeline ID
+	markedPipelines = make(map[string]api.Pipeline)
+
+	// State of the summary of a batch run
+	isBatchViewActive bool
+	batchTable        *tview.Table
+	batchHeader       *tview.TextView
+	batchCancel       context.CancelFunc // Stops the live refresh of the runs
+	batchRows         []*batchRow
+	batchStarted      time.Time
+)
+
+// batchTarget is a pipeline of a batch run with the repositories and runtime
+// variables its run form would ask for
+type batchTarget struct {
+	pipeline api.Pipeline
+	repos    []repoBranch
+	vars     []api.PipelineVariable
+}
+
+// batchRow is a pipeline of a batch run and how its run is going
+type batchRow struct {
+	pipeline api.Pipeline
+	branch   string // Branches built, for display
+	runID    string
+	status   string // QUEUED until its run is started, then the status of the run
+	err      error  // Why the run could not be started, or the last refresh failed
+}
+
+// toggleMark marks a pipeline for a batch run, or unmarks it, and reports
+// whether it is marked now
+func toggleMark(p api.Pipeline) bool {
+	if _, ok := markedPipelines[p.PipelineID]; ok {
+		delete(markedPipelines, p.PipelineID)
+		return false
+	}
+	markedPipelines[p.PipelineID] = p
+	return true
+}
+
+// markedList returns the marked pipelines sorted by name
+func markedList() []api.Pipeline {
+	pipelines := make([]api.Pipeline, 0, len(markedPipelines))
+	for _, p := range markedPipelines {
+		pipelines = append(pipelines, p)
+	}
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].Name < pipelines[j].Name })
+	return pipelines
+}
+
+// batchVariables returns the runtime variables of the pipelines of a batch,
+// each name once with the default of the first pipeline declaring it
+func batchVariables(targets []batchTarget) []api.PipelineVariable {
+	var vars []api.PipelineVariable
+	for _, t := range targets {
+		for _, v := range t.vars {
+			if !isDeclared(vars, v.Name) {
+				vars = append(vars, v)
+			}
+		}
+	}
+	return vars
+}
+
+// batchParams returns the parameters a pipeline of a batch is run with: the
+// branch in every repository, or each repository's default if it is empty,
+// and the values of the variables the pipeline declares. It also returns the
+// branches, for display.
+func batchParams(t batchTarget, branch string, values map[string]string) (map[string]string, string, error) {
+	branches := make(map[string]string)
+	var shown []string
+	for _, r := range t.repos {
+		branches[r.repo] = r.branch
+		if branch != "" {
+			branches[r.repo] = branch
+		}
+		if !containsString(shown, branches[r.repo]) {
+			shown = append(shown, branches[r.repo])
+		}
+	}
+	envs := make(map[string]string)
+	for _, v := range t.vars {
+		if value, ok := values[v.Name]; ok {
+			envs[v.Name] = value
+		}
+	}
+	params, err := api.RunParams(branches, envs)
+	return params, strings.Join(shown, ", "), err
+}
+
+// containsString reports whether s is among list
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// batchSummary counts the runs of a batch by status, e.g.
+// "2 RUNNING, 1 SUCCESS, 1 FAILED TO START"
+func batchSummary(rows []*batchRow) string {
+	counts := make(map[string]int)
+	var statuses []string
+	for _, r := range rows {
+		status := r.displayStatus()
+		if counts[status] == 0 {
+			statuses = append(statuses, status)
+		}
+		counts[status]++
+	}
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("[%s]%d %s[-]", batchStatusColor(status).String(), counts[status], status)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayStatus is the status shown for a pipeline of a batch
+func (r *batchRow) displayStatus() string {
+	if r.runID == "" && r.err != nil {
+		return "FAILED TO START"
+	}
+	return r.status
+}
+
+// finished reports whether the row no longer changes
+func (r *batchRow) finished() bool {
+	return (r.runID == "" && r.err != nil) || runwatch.IsFinished(r.status)
+}
+
+// batchStatusColor colors the status of a pipeline of a batch
+func batchStatusColor(status string) tcell.Color {
+	switch status {
+	case "QUEUED":
+		return tcell.ColorGray
+	case "FAILED TO START":
+		return tcell.ColorRed
+	}
+	return getStatusColor(status)
+}
+
+// showBatchRunDialog asks for the parameters of a batch run of the marked
+// pipelines, after discovering their repositories and variables, and starts it
+func showBatchRunDialog(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	pipelines := markedList()
+	if len(pipelines) == 0 {
+		ShowModal("No Pipelines Marked", "Mark the pipelines to run with Space, then press R to run them all with the same parameters.", []string{"OK"}, nil)
+		return
+	}
+
+	go func() {
+		// Discover the pipelines as the run form of each would, a few at a time
+		targets := make([]batchTarget, len(pipelines))
+		sem := make(chan struct{}, batch.DefaultWorkers)
+		var wg sync.WaitGroup
+		for i, p := range pipelines {
+			wg.Add(1)
+			go func(i int, p api.Pipeline) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				repos, vars := loadRunForm(context.Background(), apiClient, orgId, p.PipelineID)
+				targets[i] = batchTarget{pipeline: p, repos: repos, vars: vars}
+			}(i, p)
+		}
+		wg.Wait()
+
+		app.QueueUpdateDraw(func() {
+			showBatchParamsDialog(app, apiClient, orgId, targets)
+		})
+	}()
+}
+
+// showBatchParamsDialog shows one form for all pipelines of a batch: a branch
+// built in every repository and the runtime variables any of them declares
+func showBatchParamsDialog(app *tview.Application, apiClient api.PipelineService, orgId string, targets []batchTarget) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Run %d Pipelines", len(targets)))
+	form.SetBackgroundColor(tcell.ColorDefault)
+
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.pipeline.Name
+	}
+	form.AddTextView("Pipelines:", strings.Join(names, ", "), 60, 2, false, false)
+
+	branchField := tview.NewInputField().SetLabel("Branch:").SetFieldWidth(40).
+		SetPlaceholder("each repository's last branch")
+	form.AddFormItem(branchField)
+
+	vars := batchVariables(targets)
+	varFields := make([]*tview.InputField, len(vars))
+	for i, v := range vars {
+		varFields[i] = tview.NewInputField().SetLabel(v.Name + ":").SetText(v.Value).SetFieldWidth(40)
+		if v.Secret {
+			varFields[i].SetMaskCharacter('*')
+		}
+		form.AddFormItem(varFields[i])
+	}
+
+	workersField := tview.NewInputField().SetLabel("Parallel Starts:").
+		SetText(strconv.Itoa(batch.DefaultWorkers)).SetFieldWidth(4).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+	form.AddFormItem(workersField)
+
+	closeForm := func() {
+		mainPagesGlobal.RemovePage("batch_input")
+		app.SetFocus(pipelineTableGlobal)
+	}
+	form.AddButton("Run All", func() {
+		branch := strings.TrimSpace(branchField.GetText())
+		values := make(map[string]string)
+		for i, v := range vars {
+			values[v.Name] = varFields[i].GetText()
+		}
+		workers, _ := strconv.Atoi(workersField.GetText())
+
+		requests := make([]batch.Request, len(targets))
+		rows := make([]*batchRow, len(targets))
+		for i, t := range targets {
+			params, branches, err := batchParams(t, branch, values)
+			if err != nil {
+				ShowModal("Error", fmt.Sprintf("Failed to prepare parameters of %s: %v", t.pipeline.Name, err), []string{"OK"}, nil)
+				return
+			}
+			requests[i] = batch.Request{PipelineID: t.pipeline.PipelineID, Params: params}
+			rows[i] = &batchRow{pipeline: t.pipeline, branch: valueOr(branches, "-"), status: "QUEUED"}
+		}
+
+		mainPagesGlobal.RemovePage("batch_input")
+		markedPipelines = make(map[string]api.Pipeline)
+		showBatchView(app, apiClient, orgId, rows)
+		startBatch(app, apiClient, orgId, requests, workers)
+	})
+	form.AddButton("Cancel", closeForm)
+	form.SetCancelFunc(closeForm)
+
+	form.SetButtonBackgroundColor(tcell.ColorDefault)
+	form.SetButtonTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorDefault)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetLabelColor(tcell.ColorWhite)
+
+	mainPagesGlobal.AddPage("batch_input", form, true, true)
+	app.SetFocus(form)
+}
+
+// fillBatchTable shows the pipelines of a batch run, a row per pipeline,
+// keeping the selected row
+func fillBatchTable(table *tview.Table, rows []*batchRow) {
+	row, _ := table.GetSelection()
+	table.Clear()
+	for col, title := range []string{"Pipeline", "Branch", "Run", "Status", "Message"} {
+		table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+	for i, r := range rows {
+		message := ""
+		if r.err != nil {
+			message = describeError(r.err)
+		}
+		status := r.displayStatus()
+		cells := []string{r.pipeline.Name, r.branch, valueOr(r.runID, "-"), status, message}
+		for col, text := range cells {
+			cell := tview.NewTableCell(tview.Escape(text)).SetExpansion(1)
+			if col == 3 {
+				cell.SetTextColor(batchStatusColor(status))
+			}
+			table.SetCell(i+1, col, cell)
+		}
+	}
+	if row < 1 {
+		row = 1
+	}
+	if row > len(rows) {
+		row = len(rows)
+	}
+	table.Select(row, 0)
+}
+
+// updateBatchHeader shows how far the batch run has got
+func updateBatchHeader() {
+	if batchHeader == nil {
+		return
+	}
+	batchHeader.SetText(fmt.Sprintf("Batch run of %d pipelines | %s | Started: %s | Updated: %s",
+		len(batchRows), batchSummary(batchRows), batchStarted.Format("15:04:05"), time.Now().Format("15:04:05")))
+}
+
+// showBatchView opens the summary of a batch run: a row per pipeline with the
+// run it started and its status, refreshed until every run has finished
+func showBatchView(app *tview.Application, apiClient api.PipelineService, orgId string, rows []*batchRow) {
+	batchRows, batchStarted = rows, time.Now()
+
+	batchHeader = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignLeft)
+	batchHeader.SetBackgroundColor(tcell.ColorDefault)
+
+	batchTable = tview.NewTable().SetBorders(false).SetFixed(1, 0).SetSelectable(true, false)
+	batchTable.SetBorder(true).SetTitle("Batch Run").SetBackgroundColor(tcell.ColorDefault)
+	batchTable.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+
+	help := tview.NewTextView().
+		SetText("Keys: j/k=move, Enter=stage graph, R=refresh, q=back to pipelines, Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	help.SetBackgroundColor(tcell.ColorDefault)
+
+	page := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(batchHeader, 1, 1, false).
+		AddItem(batchTable, 0, 1, true).
+		AddItem(help, 1, 1, false)
+
+	closeView := func() {
+		isBatchViewActive = false
+		stopBatchRefresh()
+		mainPagesGlobal.RemovePage("batch")
+		mainPagesGlobal.SwitchToPage("pipelines")
+		app.SetFocus(pipelineTableGlobal)
+	}
+	batchTable.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(batchRows) || batchRows[row-1].runID == "" {
+			return
+		}
+		// Follow the run in the stage graph, coming back here when it is closed
+		r := batchRows[row-1]
+		currentPipelineIDForRun, currentPipelineName = r.pipeline.PipelineID, r.pipeline.Name
+		currentRunID, currentRunStatus = r.runID, r.status
+		isRunGraphActive = true
+		runGraphBack = func() {
+			mainPagesGlobal.SwitchToPage("batch")
+			app.SetFocus(batchTable)
+			startBatchRefresh(app, apiClient, orgId)
+		}
+		stopBatchRefresh()
+		runGraphView.SetDetails(nil)
+		updateRunGraphHeader(nil, nil)
+		mainPagesGlobal.SwitchToPage("run_graph")
+		app.SetFocus(runGraphView)
+		startRunGraphRefresh(app, apiClient, orgId)
+	})
+	batchTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			closeView()
+			return nil
+		case 'R':
+			startBatchRefresh(app, apiClient, orgId)
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	fillBatchTable(batchTable, batchRows)
+	updateBatchHeader()
+	isBatchViewActive = true
+	mainPagesGlobal.AddPage("batch", page, true, false)
+	mainPagesGlobal.SwitchToPage("batch")
+	app.SetFocus(batchTable)
+}
+
+// startBatch starts the runs of a batch, a few at a time, showing each run as
+// it starts, then follows them until they finish
+func startBatch(app *tview.Application, apiClient api.PipelineService, orgId string, requests []batch.Request, workers int) {
+	rows := batchRows
+	go func() {
+		batch.Run(context.Background(), apiClient, orgId, requests, workers, func(i int, result batch.Result) {
+			app.QueueUpdateDraw(func() {
+				if result.Err != nil {
+					rows[i].err = result.Err
+				} else {
+					rows[i].runID, rows[i].status = result.Run.RunID, valueOr(result.Run.Status, "RUNNING")
+				}
+				if isBatchViewActive && batchTable != nil && rows[0] == batchRows[0] {
+					fillBatchTable(batchTable, batchRows)
+					updateBatchHeader()
+				}
+			})
+		})
+		app.QueueUpdateDraw(func() {
+			if isBatchViewActive && !isRunGraphActive && rows[0] == batchRows[0] {
+				startBatchRefresh(app, apiClient, orgId)
+			}
+		})
+	}()
+}
+
+// startBatchRefresh reloads the status of the runs of the batch every 5
+// seconds until all of them have finished or the summary is closed
+func startBatchRefresh(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	stopBatchRefresh()
+	ctx, cancel := context.WithCancel(context.Background())
+	batchCancel = cancel
+
+	// Work on copies; the rows are only updated on the UI goroutine
+	type poll struct {
+		index             int
+		pipelineID, runID string
+	}
+	var polls []poll
+	for i, r := range batchRows {
+		if r.runID != "" && !r.finished() {
+			polls = append(polls, poll{i, r.pipeline.PipelineID, r.runID})
+		}
+	}
+	rows := batchRows
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for len(polls) > 0 {
+			runs := make([]*api.PipelineRun, len(polls))
+			errs := make([]error, len(polls))
+			for i, p := range polls {
+				runs[i], errs[i] = apiClient.GetPipelineRunContext(ctx, orgId, p.pipelineID, p.runID)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			var pending []poll
+			for i, p := range polls {
+				if errs[i] != nil || !runwatch.IsFinished(runs[i].Status) {
+					pending = append(pending, p)
+				}
+			}
+			current := polls
+			app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || batchTable == nil {
+					return
+				}
+				for i, p := range current {
+					rows[p.index].err = errs[i]
+					if errs[i] == nil {
+						rows[p.index].status = runs[i].Status
+					}
+				}
+				fillBatchTable(batchTable, rows)
+				updateBatchHeader()
+			})
+			polls = pending
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopBatchRefresh stops the live refresh of the batch summary
+func stopBatchRefresh() {
+	if batchCancel != nil {
+		batchCancel()
+		batchCancel = nil
+	}
+}
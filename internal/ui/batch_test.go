@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+func TestBatchParams(t *testing.T) {
+	targets := []batchTarget{
+		{
+			pipeline: api.Pipeline{Name: "order-service"},
+			repos:    []repoBranch{{repo: "https://example.com/order.git", branch: "master"}},
+			vars:     []api.PipelineVariable{{Name: "DEPLOY_ENV", Value: "staging"}},
+		},
+		{
+			pipeline: api.Pipeline{Name: "release"},
+			repos: []repoBranch{
+				{repo: "https://example.com/order.git", branch: "release/1.8"},
+				{repo: "https://example.com/pay.git", branch: "main"},
+			},
+			vars: []api.PipelineVariable{{Name: "SKIP_TESTS", Value: "false"}, {Name: "DEPLOY_ENV", Value: "production"}},
+		},
+	}
+
+	vars := batchVariables(targets)
+	if len(vars) != 2 || vars[0].Name != "DEPLOY_ENV" || vars[0].Value != "staging" || vars[1].Name != "SKIP_TESTS" {
+		t.Errorf("variables = %+v", vars)
+	}
+
+	values := map[string]string{"DEPLOY_ENV": "prod", "SKIP_TESTS": "true"}
+	params, branches, err := batchParams(targets[0], "release/1.9", values)
+	if err != nil {
+		t.Fatalf("batchParams: %v", err)
+	}
+	if params["runningBranchs"] != `{"https://example.com/order.git":"release/1.9"}` || params["envs"] != `{"DEPLOY_ENV":"prod"}` || branches != "release/1.9" {
+		t.Errorf("params = %v, branches = %s", params, branches)
+	}
+
+	// Without a branch every repository builds its own
+	params, branches, _ = batchParams(targets[1], "", values)
+	if params["runningBranchs"] != `{"https://example.com/order.git":"release/1.8","https://example.com/pay.git":"main"}` || branches != "release/1.8, main" {
+		t.Errorf("params = %v, branches = %s", params, branches)
+	}
+}
+
+func TestBatchSummary(t *testing.T) {
+	rows := []*batchRow{
+		{runID: "1", status: "RUNNING"},
+		{runID: "2", status: "SUCCESS"},
+		{runID: "3", status: "RUNNING", err: errors.New("timeout")},
+		{status: "QUEUED", err: errors.New("forbidden")},
+		{status: "QUEUED"},
+	}
+	got := batchSummary(rows)
+	for _, want := range []string{"2 RUNNING", "1 SUCCESS", "1 FAILED TO START", "1 QUEUED"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary %q lacks %q", got, want)
+		}
+	}
+	for i, want := range []bool{false, true, false, true, false} {
+		if rows[i].finished() != want {
+			t.Errorf("row %d finished = %v", i, !want)
+		}
+	}
+}
+
+func TestToggleMark(t *testing.T) {
+	markedPipelines = make(map[string]api.Pipeline)
+	defer func() { markedPipelines = make(map[string]api.Pipeline) }()
+
+	toggleMark(api.Pipeline{PipelineID: "2", Name: "web"})
+	toggleMark(api.Pipeline{PipelineID: "1", Name: "api"})
+	if toggleMark(api.Pipeline{PipelineID: "3", Name: "db"}) != true || toggleMark(api.Pipeline{PipelineID: "3", Name: "db"}) != false {
+		t.Error("toggling a mark twice does not unmark")
+	}
+	var names []string
+	for _, p := range markedList() {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "api,web" {
+		t.Errorf("marked = %v", names)
+	}
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// branchRef is a branch or a tag offered by the branch picker
+type branchRef struct {
+	name string
+	tag  bool
+}
+
+// label is how the ref is shown in the drop-down
+func (r branchRef) label() string {
+	if r.tag {
+		return r.name + " (tag)"
+	}
+	return r.name
+}
+
+// matchRefs returns the refs fuzzy-matching what was typed, those containing
+// it as is first. Nothing is offered once the text names a ref exactly, so
+// that a filled-in field does not keep a drop-down open.
+func matchRefs(refs []branchRef, query string) []branchRef {
+	var contains, fuzzy []branchRef
+	for _, r := range refs {
+		switch {
+		case r.name == query:
+			return nil
+		case strings.Contains(strings.ToLower(r.name), strings.ToLower(query)):
+			contains = append(contains, r)
+		case fuzzyMatch(query, r.name):
+			fuzzy = append(fuzzy, r)
+		}
+	}
+	return append(contains, fuzzy...)
+}
+
+// branchPicker offers the branches and tags of a repository in the
+// autocomplete drop-down of a branch field of the run form. Until they are
+// loaded, or if they cannot be, the field takes any text.
+type branchPicker struct {
+	refs    []branchRef // nil until loaded
+	entries []branchRef // Refs of the drop-down shown
+}
+
+// has reports whether the refs are loaded and one of them is named name
+func (p *branchPicker) has(name string) bool {
+	for _, r := range p.refs {
+		if r.name == name {
+			return true
+		}
+	}
+	return p.refs == nil
+}
+
+// attach makes field offer the refs as they are typed
+func (p *branchPicker) attach(field *tview.InputField) {
+	field.SetAutocompleteStyles(tcell.ColorDefault,
+		tcell.StyleDefault.Foreground(tcell.ColorWhite),
+		tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+	field.SetAutocompleteFunc(func(text string) []string {
+		p.entries = matchRefs(p.refs, strings.TrimSpace(text))
+		labels := make([]string, len(p.entries))
+		for i, r := range p.entries {
+			labels[i] = r.label()
+		}
+		return labels
+	})
+	field.SetAutocompletedFunc(func(text string, index int, source int) bool {
+		if source == tview.AutocompletedNavigate || index < 0 || index >= len(p.entries) {
+			return false // Moving through the drop-down leaves the text alone
+		}
+		field.SetText(p.entries[index].name)
+		return true
+	})
+}
+
+// loadRefs lists the branches and then the tags of a repository. Tags are
+// optional: failing to list them leaves just the branches.
+func loadRefs(apiClient api.PipelineService, orgId, repo string) ([]branchRef, error) {
+	branches, err := apiClient.ListRepositoryBranches(orgId, repo)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]branchRef, 0, len(branches))
+	for _, b := range branches {
+		refs = append(refs, branchRef{name: b.Name})
+	}
+	if tags, err := apiClient.ListRepositoryTags(orgId, repo); err == nil {
+		for _, t := range tags {
+			refs = append(refs, branchRef{name: t.Name, tag: true})
+		}
+	}
+	return refs, nil
+}
+
+// startBranchPickers loads the refs of the repositories of the run form into
+// the pickers of their branch fields
+func startBranchPickers(app *tview.Application, apiClient api.PipelineService, orgId string, repos []repoBranch, fields []*tview.InputField, pickers []*branchPicker) {
+	for i, r := range repos {
+		go func(field *tview.InputField, picker *branchPicker, repo string) {
+			refs, err := loadRefs(apiClient, orgId, repo)
+			if err != nil || len(refs) == 0 {
+				return // The field stays free text
+			}
+			app.QueueUpdateDraw(func() {
+				picker.refs = refs
+				if field.HasFocus() {
+					field.Autocomplete()
+				}
+			})
+		}(fields[i], pickers[i], r.repo)
+	}
+}
+
+// unknownBranches lists the branches entered in the run form that their
+// repository is known not to have, e.g. "order-service: relase/1.8"
+func unknownBranches(repos []repoBranch, branches map[string]string, pickers []*branchPicker) []string {
+	var unknown []string
+	for i, r := range repos {
+		if !pickers[i].has(branches[r.repo]) {
+			unknown = append(unknown, fmt.Sprintf("%s: %s", r.name, branches[r.repo]))
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchRefs(t *testing.T) {
+	refs := []branchRef{
+		{name: "master"},
+		{name: "release/1.8"},
+		{name: "feature/rel-notes"},
+		{name: "hotfix/1.8.1"},
+		{name: "v1.8.0", tag: true},
+	}
+	labels := func(refs []branchRef) string {
+		var l []string
+		for _, r := range refs {
+			l = append(l, r.label())
+		}
+		return strings.Join(l, ",")
+	}
+
+	tests := []struct {
+		query, want string
+	}{
+		{"", "master,release/1.8,feature/rel-notes,hotfix/1.8.1,v1.8.0 (tag)"},
+		{"rel", "release/1.8,feature/rel-notes"},
+		{"1.8", "release/1.8,hotfix/1.8.1,v1.8.0 (tag)"},
+		{"hf181", "hotfix/1.8.1"},
+		{"master", ""},
+		{"nope", ""},
+	}
+	for _, tt := range tests {
+		if got := labels(matchRefs(refs, tt.query)); got != tt.want {
+			t.Errorf("matchRefs(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownBranches(t *testing.T) {
+	repos := []repoBranch{
+		{name: "order-service", repo: "https://example.com/order-service.git"},
+		{name: "web", repo: "https://example.com/web.git"},
+	}
+	pickers := []*branchPicker{
+		{refs: []branchRef{{name: "master"}, {name: "v1.0.0", tag: true}}},
+		{}, // Not loaded: any branch goes
+	}
+
+	branches := map[string]string{repos[0].repo: "v1.0.0", repos[1].repo: "whatever"}
+	if unknown := unknownBranches(repos, branches, pickers); len(unknown) != 0 {
+		t.Errorf("unknown = %v, want none", unknown)
+	}
+	branches[repos[0].repo] = "mastr"
+	if unknown := unknownBranches(repos, branches, pickers); strings.Join(unknown, ";") != "order-service: mastr" {
+		t.Errorf("unknown = %v", unknown)
+	}
+}
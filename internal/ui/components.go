@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"aliyun-pipelines-tui/internal/ansi"
 	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/preset"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -48,6 +53,20 @@ func SetBookmarkFunctions(toggleBookmark func(string) bool, isBookmarked func(st
 	globalBookmarks = bookmarks
 }
 
+// SetProfileFunctions sets the configured profiles and the function that
+// connects to the organization of one of them
+func SetProfileFunctions(profiles []string, current string, switchProfile func(name string) (api.PipelineService, string, error)) {
+	globalProfiles = profiles
+	globalCurrentProfile = current
+	globalSwitchProfile = switchProfile
+}
+
+// SetPresets sets the run presets offered by the run dialog. Presets saved
+// from the dialog are added to presets and written with the config.
+func SetPresets(presets preset.Presets) {
+	globalPresets = presets
+}
+
 // OpenInEditor opens the given text content in the configured editor
 func OpenInEditor(content string, app *tview.Application) error {
 	if globalEditorCmd == "" {
@@ -182,6 +201,14 @@ var (
 	globalSaveConfig     func() error
 	globalBookmarks      []string
 
+	// Global profile (organization) switching
+	globalProfiles       []string
+	globalCurrentProfile string
+	globalSwitchProfile  func(string) (api.PipelineService, string, error)
+
+	// Run presets by pipeline name, saved along with the rest of the config
+	globalPresets preset.Presets
+
 	// Maps to store references for table rows
 	pipelineRowMap = make(map[int]*api.Pipeline)
 	groupRowMap    = make(map[int]*api.PipelineGroup)
@@ -190,6 +217,7 @@ var (
 	currentRunID            string
 	currentPipelineIDForRun string
 	currentPipelineName     string
+	currentLogJobID         int64  // Job whose log is shown, or 0 for every job of the run
 	currentRunStatus        string // Current run status for status bar
 	isLogViewActive         bool
 	isRunHistoryActive      bool
@@ -224,15 +252,17 @@ var (
 	allPipelinesCache        []api.Pipeline // Cache for all pipelines (no status filter)
 	allPipelinesCacheLoaded  bool           // Whether the cache has been loaded
 	allPipelinesCacheLoading bool           // Whether cache loading is in progress
+	pipelineCacheGeneration  int            // Incremented when the cache is discarded, e.g. on profile switch
 
 	// Progressive loading state for logs
-	isLogLoadingInProgress bool   // Whether log loading is in progress
-	logLoadingCurrentJob   int    // Current job being loaded (1-based)
-	logLoadingTotalJobs    int    // Total number of jobs to load
-	logLoadingComplete     bool   // Whether log loading is complete
-	logLoadingError        error  // Error during log loading
-	originalRunStatus      string // Original status from run history (to prevent overwriting)
-	preserveOriginalStatus bool   // Whether to preserve the original status
+	isLogLoadingInProgress bool      // Whether log loading is in progress
+	logLoadingCurrentJob   int       // Current job being loaded (1-based)
+	logLoadingTotalJobs    int       // Total number of jobs to load
+	logLoadingComplete     bool      // Whether log loading is complete
+	logLoadingError        error     // Error during log loading
+	logUpdatedAt           time.Time // When the logs were last loaded
+	originalRunStatus      string    // Original status from run history (to prevent overwriting)
+	preserveOriginalStatus bool      // Whether to preserve the original status
 
 	// Vim-style search state for log view
 	logSearchActive     bool              // Whether search mode is active
@@ -271,6 +301,18 @@ func ShowModal(title, text string, buttons []string, doneFunc func(buttonIndex i
 	appGlobal.SetFocus(modal)
 }
 
+// describeError formats an error for display. Yunxiao API failures get a hint
+// on how to fix them appended.
+func describeError(err error) string {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		if hint := apiErr.Hint(); hint != "" {
+			return fmt.Sprintf("%v. %s", err, hint)
+		}
+	}
+	return err.Error()
+}
+
 // HideModal removes the modal dialog.
 func HideModal() {
 	if mainPagesGlobal == nil || appGlobal == nil {
@@ -283,6 +325,21 @@ func HideModal() {
 	if isLogViewActive && logViewTextView != nil {
 		// If log view is active, restore focus to log view
 		appGlobal.SetFocus(logViewTextView)
+	} else if isDeployViewActive && deployTable != nil {
+		// The deployment view is opened from the run graph, which stays active
+		appGlobal.SetFocus(deployTable)
+	} else if isRunGraphActive && runGraphView != nil {
+		// The run graph is opened from the run history, which stays active
+		appGlobal.SetFocus(runGraphView)
+	} else if isBatchViewActive && batchTable != nil {
+		// The summary of a batch run is opened from the pipeline list
+		appGlobal.SetFocus(batchTable)
+	} else if isDashboardActive && dashboardTable != nil {
+		// The dashboard is opened from the pipeline list
+		appGlobal.SetFocus(dashboardTable)
+	} else if isPlanViewActive && planGraph != nil {
+		// The plan view is opened from the pipeline list
+		appGlobal.SetFocus(planGraph)
 	} else if isRunHistoryActive && runHistoryTable != nil {
 		// If run history is active, restore focus to run history table
 		appGlobal.SetFocus(runHistoryTable)
@@ -314,7 +371,7 @@ func getStatusColor(status string) tcell.Color {
 		return tcell.ColorRed
 	case "FAILED":
 		return tcell.ColorRed
-	case "CANCELED":
+	case "CANCELED", "PENDING", "SKIPPED":
 		return tcell.ColorGray
 	default:
 		return tcell.ColorWhite
@@ -362,10 +419,19 @@ func updateLogStatusBar() {
 	}
 
 	// Build instructions part
-	instructionsPart := " | Press '/' to search, 'f'/'b' page down/up, 'd'/'u' half-page, 'r' refresh, 'X' stop, 'q' return, 'e' edit, 'v' pager"
+	colorsHint := "'c' plain text"
+	if !logShowColors {
+		colorsHint = "'c' colors"
+	}
+	instructionsPart := " | Press '/' to search, 'Tab' jobs, 'J'/'K' next/prev job, 'z' fold steps, 'f'/'b' page down/up, 'd'/'u' half-page, 'r' refresh, 'X' stop, 'q' return, 'e' edit, 'v' pager, " + colorsHint
+
+	var updatedPart string
+	if !logUpdatedAt.IsZero() {
+		updatedPart = " | Updated: " + logUpdatedAt.Format("15:04:05")
+	}
 
 	// Combine all parts
-	statusText = statusPart + loadingPart + autoRefreshPart + instructionsPart
+	statusText = statusPart + loadingPart + updatedPart + autoRefreshPart + instructionsPart
 	statusColor = tcell.ColorDefault
 
 	logStatusBar.SetText(statusText)
@@ -386,7 +452,7 @@ func getAutoRefreshStatus() string {
 }
 
 // updatePipelineTable filters and updates the pipeline table widget.
-func updatePipelineTable(table *tview.Table, app *tview.Application, _ *tview.InputField, apiClient *api.Client, orgId string) {
+func updatePipelineTable(table *tview.Table, app *tview.Application, _ *tview.InputField, apiClient api.PipelineService, orgId string) {
 	pipelineTableGlobal = table // Update global reference
 
 	var title string
@@ -419,6 +485,15 @@ func updatePipelineTable(table *tview.Table, app *tview.Application, _ *tview.In
 		title += fmt.Sprintf(" (%d pipelines)", len(allPipelines))
 	}
 
+	if len(markedPipelines) > 0 {
+		title += fmt.Sprintf(" [%d marked]", len(markedPipelines))
+	}
+
+	// Show which organization is listed when there are several to choose from
+	if len(globalProfiles) > 1 && globalCurrentProfile != "" {
+		title = globalCurrentProfile + " | " + title
+	}
+
 	table.SetTitle(title)
 
 	// Set table headers - with bookmark column
@@ -551,11 +626,14 @@ func updatePipelineTable(table *tview.Table, app *tview.Application, _ *tview.In
 				SetBackgroundColor(tcell.ColorDefault)
 			table.SetCell(row, 0, bookmarkCell)
 
-			// Column 1: Pipeline Name
+			// Column 1: Pipeline Name, checked when marked for a batch run
 			nameCell := tview.NewTableCell(pipelineCopy.Name).
 				SetTextColor(tcell.ColorWhite).
 				SetAlign(tview.AlignLeft).
 				SetBackgroundColor(tcell.ColorDefault)
+			if _, marked := markedPipelines[pipelineCopy.PipelineID]; marked {
+				nameCell.SetText("✓ " + pipelineCopy.Name).SetTextColor(tcell.ColorGreen)
+			}
 			table.SetCell(row, 1, nameCell)
 		}
 	}
@@ -639,87 +717,184 @@ func updateGroupTable(table *tview.Table, app *tview.Application) {
 	}
 }
 
-// showRunPipelineDialog shows a dialog to collect branch information and run the pipeline
-func showRunPipelineDialog(selectedPipeline *api.Pipeline, app *tview.Application, apiClient *api.Client, orgId string) {
-	// First, try to get the latest run to pre-fill branch information and extract repository URLs
-	var defaultBranch string = "master" // Default branch name
-	var repositoryURLs map[string]string = make(map[string]string)
-
-	// Try to get latest run information to extract branch and repository information from previous run
+// showRunPipelineDialog shows a dialog to collect the run parameters and run the pipeline
+func showRunPipelineDialog(selectedPipeline *api.Pipeline, app *tview.Application, apiClient api.PipelineService, orgId string) {
+	// Discover the repositories and variables of the pipeline; the latest run
+	// tells which branches were built last
 	go func() {
-		latestRunInfo, err := apiClient.GetLatestPipelineRunInfo(orgId, selectedPipeline.PipelineID)
-		if err == nil && latestRunInfo != nil && len(latestRunInfo.RepositoryURLs) > 0 {
-			// Use repository information from the latest run
-			repositoryURLs = latestRunInfo.RepositoryURLs
-			// Use the first repository's branch as default
-			for _, branch := range latestRunInfo.RepositoryURLs {
-				defaultBranch = branch
-				break
-			}
-		}
+		repos, vars := loadRunForm(context.Background(), apiClient, orgId, selectedPipeline.PipelineID)
 
 		app.QueueUpdateDraw(func() {
-			showBranchInputDialog(selectedPipeline, app, apiClient, orgId, defaultBranch, repositoryURLs)
+			showRunParamsDialog(selectedPipeline, app, apiClient, orgId, repos, vars)
 		})
 	}()
 }
 
-// showBranchInputDialog shows an input dialog for branch selection
-func showBranchInputDialog(selectedPipeline *api.Pipeline, app *tview.Application, apiClient *api.Client, orgId, defaultBranch string, repositoryURLs map[string]string) {
-	// Create a form for branch input
+// repoBranch is a repository of a pipeline and the branch to build in it
+type repoBranch struct {
+	name   string // Short name shown in the form, e.g. "order-service"
+	repo   string // Repository URL
+	branch string
+}
+
+// loadRunForm returns the repositories and runtime variables the run form of
+// a pipeline asks for: the repositories of api.LoadRunSources, as `flowt run`
+// builds them, and the runtime variables of the definition.
+func loadRunForm(ctx context.Context, apiClient api.PipelineService, orgId, pipelineID string) ([]repoBranch, []api.PipelineVariable) {
+	sources, definition := api.LoadRunSources(ctx, apiClient, orgId, pipelineID)
+	var repos []repoBranch
+	for _, source := range sources {
+		name := source.Name
+		if name == "" {
+			name = repoName(source.Repo)
+		}
+		repos = append(repos, repoBranch{name: name, repo: source.Repo, branch: source.Branch})
+	}
+	var vars []api.PipelineVariable
+	if definition != nil {
+		vars = definition.RuntimeVariables()
+	}
+	return repos, vars
+}
+
+// repoName returns the short name of a repository URL, e.g. "order-service"
+// for https://codeup.aliyun.com/demo/order-service.git
+func repoName(repoURL string) string {
+	name := strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// showRunParamsDialog shows a form with a branch field per repository and a
+// field per runtime variable, filled with their defaults or with a preset of
+// the pipeline, and runs the pipeline with the values entered. The branch
+// fields offer the repository's branches and tags as they are typed.
+func showRunParamsDialog(selectedPipeline *api.Pipeline, app *tview.Application, apiClient api.PipelineService, orgId string, repos []repoBranch, vars []api.PipelineVariable) {
 	form := tview.NewForm()
 	form.SetBorder(true).SetTitle(fmt.Sprintf("Run Pipeline: %s", selectedPipeline.Name))
 	form.SetBackgroundColor(tcell.ColorDefault)
 
-	// Add branch input field
-	branchInput := ""
-	form.AddInputField("Branch Name:", defaultBranch, 30, nil, func(text string) {
-		branchInput = text
-	})
+	branchFields := make([]*tview.InputField, len(repos))
+	pickers := make([]*branchPicker, len(repos))
+	varFields := make([]*tview.InputField, len(vars))
+	extraVars := make(map[string]string) // Variables set by a preset but not declared by the pipeline
+
+	presets := globalPresets.For(selectedPipeline.Name)
+	if len(presets) > 0 {
+		options := append([]string{"(none)"}, preset.Names(presets)...)
+		form.AddDropDown("Preset:", options, 0, func(option string, index int) {
+			// Fill the fields with the preset, or with the defaults for (none)
+			chosen := preset.Preset{}
+			if index > 0 {
+				chosen = presets[index-1]
+			}
+			for i, r := range repos {
+				if branchFields[i] != nil {
+					branchFields[i].SetText(chosen.BranchFor(r.repo, r.branch))
+				}
+			}
+			for i, v := range vars {
+				value, ok := chosen.Variables[v.Name]
+				if !ok {
+					value = v.Value
+				}
+				if varFields[i] != nil {
+					varFields[i].SetText(value)
+				}
+			}
+			for name := range extraVars {
+				delete(extraVars, name)
+			}
+			for name, value := range chosen.Variables {
+				if !isDeclared(vars, name) {
+					extraVars[name] = value
+				}
+			}
+		})
+	}
 
-	// Add buttons
-	form.AddButton("Run", func() {
-		if branchInput == "" {
-			branchInput = defaultBranch
+	for i, r := range repos {
+		branchFields[i] = tview.NewInputField().SetLabel(fmt.Sprintf("Branch (%s):", r.name)).SetText(r.branch).SetFieldWidth(40)
+		pickers[i] = &branchPicker{}
+		pickers[i].attach(branchFields[i])
+		form.AddFormItem(branchFields[i])
+	}
+	for i, v := range vars {
+		varFields[i] = tview.NewInputField().SetLabel(v.Name + ":").SetText(v.Value).SetFieldWidth(40)
+		if v.Secret {
+			varFields[i].SetMaskCharacter('*')
 		}
+		form.AddFormItem(varFields[i])
+	}
+	if len(repos) == 0 && len(vars) == 0 {
+		form.AddTextView("Parameters:", "The pipeline runs with its defaults", 40, 1, false, false)
+	}
 
-		// Hide the form
-		mainPagesGlobal.RemovePage("branch_input")
-
-		// Prepare parameters for the pipeline run using the correct format
-		// Build runningBranchs map with repository URLs from latest run
-		runningBranchs := make(map[string]string)
-
-		if len(repositoryURLs) > 0 {
-			// Use repository URLs from the latest run
-			for repoUrl := range repositoryURLs {
-				runningBranchs[repoUrl] = branchInput
+	// values returns the branches and variables entered in the form
+	values := func() (map[string]string, map[string]string) {
+		branches := make(map[string]string)
+		for i, r := range repos {
+			branches[r.repo] = strings.TrimSpace(branchFields[i].GetText())
+			if branches[r.repo] == "" {
+				branches[r.repo] = r.branch // An emptied field keeps its default
 			}
-		} else {
-			// Fallback: use a placeholder repository URL
-			// This should be replaced with actual repository detection logic
-			runningBranchs["https://gitlab.example.com/default/repo.git"] = branchInput
 		}
+		envs := make(map[string]string)
+		for name, value := range extraVars {
+			envs[name] = value
+		}
+		for i, v := range vars {
+			envs[v.Name] = varFields[i].GetText()
+		}
+		return branches, envs
+	}
 
-		// Convert runningBranchs to JSON string
-		runningBranchsJSON, err := json.Marshal(runningBranchs)
+	closeForm := func() {
+		mainPagesGlobal.RemovePage("branch_input")
+		app.SetFocus(pipelineTableGlobal)
+	}
+
+	// Add buttons
+	form.AddButton("Run", func() {
+		branches, envs := values()
+		params, err := api.RunParams(branches, envs)
 		if err != nil {
 			ShowModal("Error", fmt.Sprintf("Failed to prepare parameters: %v", err), []string{"OK"}, nil)
 			return
 		}
+		run := func() {
+			// Hide the form
+			mainPagesGlobal.RemovePage("branch_input")
 
-		params := map[string]string{
-			"runningBranchs": string(runningBranchsJSON),
+			// Run the pipeline
+			runPipelineWithBranch(selectedPipeline, app, apiClient, orgId, params, branches)
 		}
 
-		// Run the pipeline
-		runPipelineWithBranch(selectedPipeline, app, apiClient, orgId, params, repositoryURLs)
-	})
-
-	form.AddButton("Cancel", func() {
-		mainPagesGlobal.RemovePage("branch_input")
-		app.SetFocus(pipelineTableGlobal)
+		// A typo would start a run on a branch that does not exist
+		if unknown := unknownBranches(repos, branches, pickers); len(unknown) > 0 {
+			ShowModal("Unknown Branch",
+				fmt.Sprintf("No such branch or tag in the repository:\n%s\n\nRun anyway?", strings.Join(unknown, "\n")),
+				[]string{"Run", "Cancel"}, func(buttonIndex int, buttonLabel string) {
+					if buttonLabel == "Run" {
+						run()
+					} else {
+						app.SetFocus(form)
+					}
+				})
+			return
+		}
+		run()
 	})
+	if globalPresets != nil {
+		form.AddButton("Save Preset", func() {
+			branches, envs := values()
+			showSavePresetDialog(app, form, selectedPipeline.Name, newPreset(branches, envs))
+		})
+	}
+	form.AddButton("Cancel", closeForm)
+	form.SetCancelFunc(closeForm)
 
 	// Set form styling
 	form.SetButtonBackgroundColor(tcell.ColorDefault)
@@ -731,10 +906,86 @@ func showBranchInputDialog(selectedPipeline *api.Pipeline, app *tview.Applicatio
 	// Add the form to pages and show it
 	mainPagesGlobal.AddPage("branch_input", form, true, true)
 	app.SetFocus(form)
+
+	// Offer the branches and tags of each repository as they are typed
+	startBranchPickers(app, apiClient, orgId, repos, branchFields, pickers)
+}
+
+// isDeclared reports whether a variable of the given name is among vars
+func isDeclared(vars []api.PipelineVariable, name string) bool {
+	for _, v := range vars {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newPreset makes a preset of the branches and variables of the run form. A
+// branch shared by every repository is saved once, for all of them.
+func newPreset(branches, envs map[string]string) preset.Preset {
+	p := preset.Preset{}
+	for _, branch := range branches {
+		if p.Branch == "" {
+			p.Branch = branch
+		} else if branch != p.Branch {
+			p.Branch, p.Branches = "", branches
+			break
+		}
+	}
+	if len(envs) > 0 {
+		p.Variables = envs
+	}
+	return p
+}
+
+// showSavePresetDialog asks for a name and saves the preset under it for the
+// pipeline, replacing a preset of the same name, then returns to the run form
+func showSavePresetDialog(app *tview.Application, runForm *tview.Form, pipelineName string, p preset.Preset) {
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Save Preset: %s", pipelineName))
+	form.SetBackgroundColor(tcell.ColorDefault)
+
+	name := ""
+	form.AddInputField("Preset Name:", "", 30, nil, func(text string) {
+		name = strings.TrimSpace(text)
+	})
+	back := func() {
+		mainPagesGlobal.RemovePage("save_preset")
+		app.SetFocus(runForm)
+	}
+	form.AddButton("Save", func() {
+		if name == "" {
+			return
+		}
+		p.Name = name
+		globalPresets.Save(pipelineName, p)
+		back()
+		if globalSaveConfig != nil {
+			if err := globalSaveConfig(); err != nil {
+				ShowModal("Error", fmt.Sprintf("Failed to save preset: %v", err), []string{"OK"}, func(int, string) { app.SetFocus(runForm) })
+				return
+			}
+		}
+		ShowModal("Preset Saved", fmt.Sprintf("Preset %s of %s saved.\nIt is offered the next time the pipeline is run.", name, pipelineName), []string{"OK"}, func(int, string) {
+			app.SetFocus(runForm)
+		})
+	})
+	form.AddButton("Cancel", back)
+	form.SetCancelFunc(back)
+
+	form.SetButtonBackgroundColor(tcell.ColorDefault)
+	form.SetButtonTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorDefault)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetLabelColor(tcell.ColorWhite)
+
+	mainPagesGlobal.AddPage("save_preset", form, true, true)
+	app.SetFocus(form)
 }
 
 // runPipelineWithBranch executes the pipeline with the specified branch parameters
-func runPipelineWithBranch(selectedPipeline *api.Pipeline, app *tview.Application, apiClient *api.Client, orgId string, params map[string]string, repositoryURLs map[string]string) {
+func runPipelineWithBranch(selectedPipeline *api.Pipeline, app *tview.Application, apiClient api.PipelineService, orgId string, params map[string]string, repositoryURLs map[string]string) {
 	currentPipelineIDForRun = selectedPipeline.PipelineID
 
 	go func() { // Run in goroutine to avoid blocking UI
@@ -765,6 +1016,7 @@ func runPipelineWithBranch(selectedPipeline *api.Pipeline, app *tview.Applicatio
 				logText += fmt.Sprintf("Repository: %s\n", repoInfo)
 			}
 			if logViewTextView != nil {
+				resetLogJobs(0)
 				logViewTextView.SetText(logText)
 				mainPagesGlobal.SwitchToPage("logs")
 				app.SetFocus(logViewTextView)
@@ -774,7 +1026,7 @@ func runPipelineWithBranch(selectedPipeline *api.Pipeline, app *tview.Applicatio
 		runResponse, err := apiClient.RunPipeline(orgId, selectedPipeline.PipelineID, params)
 		if err != nil {
 			app.QueueUpdateDraw(func() {
-				ShowModal("Error", fmt.Sprintf("Failed to run pipeline: %v", err), []string{"OK"}, nil)
+				ShowModal("Error", fmt.Sprintf("Failed to run pipeline: %s", describeError(err)), []string{"OK"}, nil)
 			})
 			return
 		}
@@ -784,6 +1036,7 @@ func runPipelineWithBranch(selectedPipeline *api.Pipeline, app *tview.Applicatio
 		preserveOriginalStatus = false // Allow status updates for newly created runs
 		isLogViewActive = true
 		isNewlyCreatedRun = true // Mark this as a newly created run
+		resetLogViewContext()
 
 		app.QueueUpdateDraw(func() {
 			logText := fmt.Sprintf("Pipeline '%s' triggered successfully!\nRun ID: %s\nBranch: %s\n",
@@ -814,10 +1067,45 @@ var (
 	pipelineFinished     bool // Whether pipeline has finished
 	// Track if current log view is for a newly created run or running historical run
 	isNewlyCreatedRun bool // Whether this is a newly created run (should auto-refresh)
+	// Cancellation of API requests made on behalf of the log view
+	logViewCtx    context.Context    // Lives as long as the log view is open
+	logViewCancel context.CancelFunc // Cancels every request of the log view
+	logLoadCancel context.CancelFunc // Cancels the log load currently in progress
 )
 
+// resetLogViewContext cancels requests left over from a previous log view and
+// starts a fresh context for the one being opened
+func resetLogViewContext() {
+	cancelLogViewRequests()
+	logViewCtx, logViewCancel = context.WithCancel(context.Background())
+}
+
+// cancelLogViewRequests cancels all in-flight requests of the log view
+func cancelLogViewRequests() {
+	if logViewCancel != nil {
+		logViewCancel()
+		logViewCancel = nil
+	}
+	logLoadCancel = nil
+	isLogLoadingInProgress = false
+}
+
+// newLogLoadContext cancels a log load that is still running and returns the
+// context for the next one, so refresh ticks never stack up concurrent loads
+func newLogLoadContext() context.Context {
+	if logLoadCancel != nil {
+		logLoadCancel()
+	}
+	if logViewCtx == nil || logViewCtx.Err() != nil {
+		resetLogViewContext()
+	}
+	var ctx context.Context
+	ctx, logLoadCancel = context.WithCancel(logViewCtx)
+	return ctx
+}
+
 // startLogAutoRefresh starts automatic log fetching and refreshing every 5 seconds
-func startLogAutoRefresh(app *tview.Application, apiClient *api.Client, orgId, pipelineName, branchInfo, repoInfo string) {
+func startLogAutoRefresh(app *tview.Application, apiClient api.PipelineService, orgId, pipelineName, branchInfo, repoInfo string) {
 	// Stop any existing refresh ticker
 	stopLogAutoRefresh()
 
@@ -905,7 +1193,7 @@ func stopLogAutoRefresh() {
 
 // fetchAndDisplayLogs fetches and displays the current logs for the running pipeline
 // This function now uses progressive loading to show logs as they are fetched
-func fetchAndDisplayLogs(app *tview.Application, apiClient *api.Client, orgId, pipelineName, branchInfo, repoInfo string) {
+func fetchAndDisplayLogs(app *tview.Application, apiClient api.PipelineService, orgId, pipelineName, branchInfo, repoInfo string) {
 	if currentRunID == "" || currentPipelineIDForRun == "" {
 		return
 	}
@@ -915,42 +1203,13 @@ func fetchAndDisplayLogs(app *tview.Application, apiClient *api.Client, orgId, p
 		return
 	}
 
-	// Store search state before updating logs
-	wasSearchActive := logSearchActive
-	searchQuery := logSearchQuery
-
-	// Clear search state temporarily during log update
-	if logSearchActive {
-		logSearchActive = false
-		logOriginalText = ""
-	}
-
-	// Start progressive log loading
+	// Start progressive log loading; an active search is repeated on the
+	// new text as it arrives
 	startProgressiveLogLoading(app, apiClient, orgId, pipelineName, branchInfo, repoInfo)
-
-	// Note: Search state will be restored in the final update of startProgressiveLogLoading
-	// if the search was active before the update
-	if wasSearchActive && searchQuery != "" {
-		// Schedule search restoration after log loading completes
-		go func() {
-			// Wait for log loading to complete
-			for isLogLoadingInProgress {
-				time.Sleep(100 * time.Millisecond)
-			}
-
-			// Restore search state
-			app.QueueUpdateDraw(func() {
-				if isLogViewActive && logViewTextView != nil {
-					logOriginalText = logViewTextView.GetText(false)
-					performLogSearch(searchQuery, app)
-				}
-			})
-		}()
-	}
 }
 
 // getVMDeploymentLogs fetches logs for VM deployment jobs
-func getVMDeploymentLogs(apiClient *api.Client, orgId, pipelineIdStr, runIdStr string, job api.Job) (string, error) {
+func getVMDeploymentLogs(ctx context.Context, apiClient api.PipelineService, orgId, pipelineIdStr, runIdStr string, job api.Job) (string, error) {
 	var logs strings.Builder
 
 	// Extract deployOrderId from job actions
@@ -969,9 +1228,9 @@ func getVMDeploymentLogs(apiClient *api.Client, orgId, pipelineIdStr, runIdStr s
 	}
 
 	// Get VM deployment order details
-	deployOrder, err := apiClient.GetVMDeployOrder(orgId, pipelineIdStr, deployOrderId)
+	deployOrder, err := apiClient.GetVMDeployOrderContext(ctx, orgId, pipelineIdStr, deployOrderId)
 	if err != nil {
-		logs.WriteString(fmt.Sprintf("Error fetching VM deploy order %s: %v\n", deployOrderId, err))
+		logs.WriteString(fmt.Sprintf("Error fetching VM deploy order %s: %s\n", deployOrderId, describeError(err)))
 		logs.WriteString("Unable to retrieve deployment details at this time.\n")
 		return logs.String(), nil
 	}
@@ -987,15 +1246,18 @@ func getVMDeploymentLogs(apiClient *api.Client, orgId, pipelineIdStr, runIdStr s
 		logs.WriteString("No machines found in this deployment.\n")
 	} else {
 		for i, machine := range deployOrder.DeployMachineInfo.DeployMachines {
+			if ctx.Err() != nil {
+				return logs.String(), ctx.Err()
+			}
 			logs.WriteString(fmt.Sprintf("[yellow]Machine #%d: %s (SN: %s)[-]\n", i+1, machine.IP, machine.MachineSn))
 			logs.WriteString(fmt.Sprintf("[yellow]Machine Status: %s, Client Status: %s[-]\n", machine.Status, machine.ClientStatus))
 			logs.WriteString(fmt.Sprintf("[yellow]Batch: %d[-]\n", machine.BatchNum))
 			logs.WriteString("[yellow]" + strings.Repeat(".", 30) + "[-]\n")
 
 			// Get machine deployment log
-			machineLog, err := apiClient.GetVMDeployMachineLog(orgId, pipelineIdStr, deployOrderId, machine.MachineSn)
+			machineLog, err := apiClient.GetVMDeployMachineLogContext(ctx, orgId, pipelineIdStr, deployOrderId, machine.MachineSn)
 			if err != nil {
-				logs.WriteString(fmt.Sprintf("Error fetching machine log for %s: %v\n", machine.MachineSn, err))
+				logs.WriteString(fmt.Sprintf("Error fetching machine log for %s: %s\n", machine.MachineSn, describeError(err)))
 			} else {
 				if machineLog.DeployBeginTime != "" {
 					logs.WriteString(fmt.Sprintf("Deploy Begin Time: %s\n", machineLog.DeployBeginTime))
@@ -1013,7 +1275,7 @@ func getVMDeploymentLogs(apiClient *api.Client, orgId, pipelineIdStr, runIdStr s
 				if machineLog.DeployLog == "" {
 					logs.WriteString("No deployment logs available for this machine.\n")
 				} else {
-					logs.WriteString(machineLog.DeployLog)
+					logs.WriteString(ansi.ToTview(machineLog.DeployLog))
 					if !strings.HasSuffix(machineLog.DeployLog, "\n") {
 						logs.WriteString("\n")
 					}
@@ -1026,6 +1288,17 @@ func getVMDeploymentLogs(apiClient *api.Client, orgId, pipelineIdStr, runIdStr s
 	return logs.String(), nil
 }
 
+// isVMDeployJob reports whether the job deploys to VMs, whose log is built
+// from its deploy order rather than fetched
+func isVMDeployJob(job api.Job) bool {
+	for _, action := range job.Actions {
+		if action.Type == "GetVMDeployOrder" {
+			return true
+		}
+	}
+	return false
+}
+
 // extractDeployOrderIdFromActions extracts deployOrderId from job actions array
 // This is a local implementation for UI use
 func extractDeployOrderIdFromActions(actions []api.JobAction) (string, error) {
@@ -1084,21 +1357,9 @@ func extractDeployOrderIdFromActions(actions []api.JobAction) (string, error) {
 }
 
 // startProgressiveLogLoading starts loading logs progressively job by job
-func startProgressiveLogLoading(app *tview.Application, apiClient *api.Client, orgId, pipelineName, branchInfo, repoInfo string) {
-	// Reset loading state
-	isLogLoadingInProgress = true
-	logLoadingCurrentJob = 0
-	logLoadingTotalJobs = 0
-	logLoadingComplete = false
-	logLoadingError = nil
-
-	// Initialize log display with header
-	app.QueueUpdateDraw(func() {
-		if !isLogViewActive || logViewTextView == nil {
-			return
-		}
-
-		// Build initial header
+func startProgressiveLogLoading(app *tview.Application, apiClient api.PipelineService, orgId, pipelineName, branchInfo, repoInfo string) {
+	// runHeader describes the pipeline run at the top of the log
+	runHeader := func() string {
 		var logText strings.Builder
 		logText.WriteString(fmt.Sprintf("Pipeline: %s\n", pipelineName))
 		logText.WriteString(fmt.Sprintf("Run ID: %s\n", currentRunID))
@@ -1106,207 +1367,249 @@ func startProgressiveLogLoading(app *tview.Application, apiClient *api.Client, o
 		if repoInfo != "" {
 			logText.WriteString(fmt.Sprintf("Repository: %s\n", repoInfo))
 		}
-		logText.WriteString(fmt.Sprintf("Last Updated: %s\n", time.Now().Format("2006-01-02 15:04:05")))
-		logText.WriteString(strings.Repeat("=", 80) + "\n\n")
-		logText.WriteString("Loading pipeline run details...\n")
-
-		logViewTextView.SetText(logText.String())
-		updateLogStatusBar()
-	})
+		return logText.String()
+	}
 
 	// Start loading in a goroutine
 	go func() {
+		// The loading state belongs to the UI goroutine: it is reset there,
+		// which also tells the load which run to fetch
+		type loadStart struct {
+			ctx               context.Context
+			pipelineID, runID string
+		}
+		started := make(chan loadStart, 1)
+		app.QueueUpdateDraw(func() {
+			// Reset loading state
+			isLogLoadingInProgress = true
+			logLoadingCurrentJob = 0
+			logLoadingTotalJobs = 0
+			logLoadingComplete = false
+			logLoadingError = nil
+			ctx := newLogLoadContext()
+			viewCtx := logViewCtx
+			logStepLoader = func(section logJobSection) {
+				go loadLogJobSteps(viewCtx, app, apiClient, orgId, section)
+			}
+			started <- loadStart{ctx: ctx, pipelineID: currentPipelineIDForRun, runID: currentRunID}
+
+			// Initialize log display with header
+			if !isLogViewActive || logViewTextView == nil {
+				return
+			}
+
+			// A refresh keeps showing what was loaded until it has more
+			if len(logJobSections) == 0 {
+				logHeaderText = runHeader() + strings.Repeat("=", 80) + "\n\n"
+				logFooterText = "Loading pipeline run details...\n"
+			}
+			renderLogView()
+			updateLogStatusBar()
+		})
+		start := <-started
+		ctx, pipelineID, runID := start.ctx, start.pipelineID, start.runID
+
 		// Step 1: Get pipeline run details to obtain job list
-		runDetails, err := apiClient.GetPipelineRunDetails(orgId, currentPipelineIDForRun, currentRunID)
+		runDetails, err := apiClient.GetPipelineRunDetailsContext(ctx, orgId, pipelineID, runID)
+		if ctx.Err() != nil {
+			// The log view was closed or a newer load superseded this one
+			return
+		}
 		if err != nil {
-			logLoadingError = err
-			isLogLoadingInProgress = false
 			app.QueueUpdateDraw(func() {
+				logLoadingError = err
+				isLogLoadingInProgress = false
 				if !isLogViewActive || logViewTextView == nil {
 					return
 				}
 
-				var logText strings.Builder
-				logText.WriteString(fmt.Sprintf("Pipeline: %s\n", pipelineName))
-				logText.WriteString(fmt.Sprintf("Run ID: %s\n", currentRunID))
-				logText.WriteString(fmt.Sprintf("Branch: %s\n", branchInfo))
-				if repoInfo != "" {
-					logText.WriteString(fmt.Sprintf("Repository: %s\n", repoInfo))
-				}
-				logText.WriteString(fmt.Sprintf("Last Updated: %s\n", time.Now().Format("2006-01-02 15:04:05")))
-				logText.WriteString(strings.Repeat("=", 80) + "\n\n")
-				logText.WriteString(fmt.Sprintf("Error fetching pipeline run details: %v\n\n", err))
-				logText.WriteString("Note: Log fetching may require additional parameters or the pipeline may still be initializing.\n")
-
-				logViewTextView.SetText(logText.String())
+				logHeaderText = runHeader() + strings.Repeat("=", 80) + "\n\n"
+				logFooterText = fmt.Sprintf("Error fetching pipeline run details: %s\n\n", describeError(err)) +
+					"Note: Log fetching may require additional parameters or the pipeline may still be initializing.\n"
+				logJobSections = nil
+				renderLogView()
 				updateLogStatusBar()
 			})
 			return
 		}
 
-		// Update status if not preserving original status
-		if !preserveOriginalStatus {
-			currentRunStatus = runDetails.Status
-		}
+		runFinished := runwatch.IsFinished(runDetails.Status)
 
-		// Count total jobs
-		totalJobs := 0
-		for _, stage := range runDetails.Stages {
-			totalJobs += len(stage.Jobs)
-		}
-		logLoadingTotalJobs = totalJobs
-
-		// Update initial display with run details
+		// Update initial display with run details, and take what this round
+		// loads from the sections there rather than sharing them
+		fetches := make(chan []logJobFetch, 1)
 		app.QueueUpdateDraw(func() {
-			if !isLogViewActive || logViewTextView == nil {
+			if ctx.Err() != nil || !isLogViewActive || logViewTextView == nil {
+				fetches <- nil
 				return
 			}
 
-			var logText strings.Builder
-			logText.WriteString(fmt.Sprintf("Pipeline: %s\n", pipelineName))
-			logText.WriteString(fmt.Sprintf("Run ID: %s\n", currentRunID))
-			logText.WriteString(fmt.Sprintf("Branch: %s\n", branchInfo))
-			if repoInfo != "" {
-				logText.WriteString(fmt.Sprintf("Repository: %s\n", repoInfo))
+			// Update status if not preserving original status
+			if !preserveOriginalStatus {
+				currentRunStatus = runDetails.Status
+			}
+
+			// Collect the jobs, keeping the log already received for each job
+			// so that only what is new has to be added to it
+			previous := make(map[int64]logJobSection)
+			for _, section := range logJobSections {
+				previous[section.job.ID] = section
+			}
+			var sections []logJobSection
+			for _, stage := range runDetails.Stages {
+				for _, job := range stage.Jobs {
+					section := previous[job.ID]
+					if section.complete && !runwatch.IsFinished(job.Status) {
+						// The job was retried: its log starts over
+						logTail.Reset(job.ID)
+						section = logJobSection{}
+					}
+					section.stage, section.job, section.number = stage, job, len(sections)+1
+					sections = append(sections, section)
+				}
 			}
-			logText.WriteString(fmt.Sprintf("Last Updated: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+			totalJobs := len(sections)
+			logLoadingTotalJobs = totalJobs
+
+			var logText strings.Builder
+			logText.WriteString(runHeader())
 			logText.WriteString("=" + strings.Repeat("=", 80) + "\n\n")
 			logText.WriteString(fmt.Sprintf("Pipeline Run Logs - Run ID: %s\n", currentRunID))
 			logText.WriteString(fmt.Sprintf("Pipeline ID: %s\n", currentPipelineIDForRun))
 			logText.WriteString(fmt.Sprintf("Status: %s\n", runDetails.Status))
 			logText.WriteString("=" + strings.Repeat("=", 80) + "\n\n")
+			logHeaderText = logText.String()
+			logJobSections = sections
+
+			// Show the failed job straight away unless the user picked one
+			if !logJobChosen {
+				if failed := firstFailedLogJob(sections); failed != 0 {
+					currentLogJobID = failed
+				}
+			}
 
 			if totalJobs == 0 {
-				logText.WriteString("No jobs found in this pipeline run.\n")
+				logFooterText = "No jobs found in this pipeline run.\n"
 				isLogLoadingInProgress = false
 				logLoadingComplete = true
-			} else {
-				logText.WriteString(fmt.Sprintf("Found %d jobs to load. Loading logs progressively...\n\n", totalJobs))
+			} else if len(previous) == 0 {
+				logFooterText = fmt.Sprintf("Found %d jobs to load. Loading logs progressively...\n\n", totalJobs)
 			}
 
-			logViewTextView.SetText(logText.String())
-			logViewTextView.ScrollToEnd()
+			renderLogView()
 			updateLogStatusBar()
+			// Step 2 loads the job shown first
+			fetches <- logJobFetches(sections, currentLogJobID)
 		})
 
-		if totalJobs == 0 {
+		var jobs []logJobFetch
+		select {
+		case jobs = <-fetches:
+		case <-ctx.Done():
+			return
+		}
+		if len(jobs) == 0 {
 			return
 		}
 
 		// Step 2: Load logs for each job progressively
-		currentJobIndex := 0
-		for _, stage := range runDetails.Stages {
-			if len(stage.Jobs) > 0 {
-				// Add stage header
-				app.QueueUpdateDraw(func() {
-					if !isLogViewActive || logViewTextView == nil {
-						return
-					}
-
-					currentText := logViewTextView.GetText(false)
-					currentText += fmt.Sprintf("[yellow]Stage: %s (%s)[-]\n", stage.Name, stage.Index)
-					currentText += "-" + strings.Repeat("-", 60) + "\n\n"
-
-					logViewTextView.SetText(currentText)
-					logViewTextView.ScrollToEnd()
-				})
+		for n, fetch := range jobs {
+			if ctx.Err() != nil {
+				return
 			}
+			i, job := fetch.index, fetch.job
 
-			for _, job := range stage.Jobs {
-				currentJobIndex++
-				logLoadingCurrentJob = currentJobIndex
-
-				// Update progress
-				app.QueueUpdateDraw(func() {
-					updateLogStatusBar()
-				})
-
-				// Add job header
-				app.QueueUpdateDraw(func() {
-					if !isLogViewActive || logViewTextView == nil {
-						return
-					}
-
-					currentText := logViewTextView.GetText(false)
-					currentText += fmt.Sprintf("[yellow]Job #%d: %s (ID: %d)[-]\n", currentJobIndex, job.Name, job.ID)
-					currentText += fmt.Sprintf("[yellow]Job Sign: %s[-]\n", job.JobSign)
-					currentText += fmt.Sprintf("[yellow]Status: %s[-]\n", job.Status)
-					if !job.StartTime.IsZero() {
-						currentText += fmt.Sprintf("[yellow]Start Time: %s[-]\n", job.StartTime.Format("2006-01-02 15:04:05"))
-					}
-					if !job.EndTime.IsZero() {
-						currentText += fmt.Sprintf("[yellow]End Time: %s[-]\n", job.EndTime.Format("2006-01-02 15:04:05"))
-					}
-					currentText += "[yellow]" + strings.Repeat("=", 50) + "[-]\n"
-
-					logViewTextView.SetText(currentText)
-					logViewTextView.ScrollToEnd()
-				})
-
-				// Fetch logs for this specific job
-				var jobLogs string
-				var jobErr error
+			// Update progress
+			app.QueueUpdateDraw(func() {
+				logLoadingCurrentJob = n + 1
+				updateLogStatusBar()
+			})
 
-				// Check if this job has GetVMDeployOrder action
-				hasVMDeployAction := false
-				for _, action := range job.Actions {
-					if action.Type == "GetVMDeployOrder" {
-						hasVMDeployAction = true
-						break
-					}
+			// Jobs that have not started have no log yet, and a log fetched
+			// after its job finished cannot change any more
+			if !runwatch.HasStarted(job.Status) || fetch.complete {
+				if !fetch.loaded {
+					app.QueueUpdateDraw(func() {
+						if ctx.Err() != nil || !isLogViewActive || logViewTextView == nil || i >= len(logJobSections) {
+							return
+						}
+						logJobSections[i].loaded = true
+						renderLogView()
+					})
 				}
+				continue
+			}
+			finished := runwatch.IsFinished(job.Status) || runFinished
+			firstLoad := !fetch.loaded
 
-				if hasVMDeployAction {
-					// Handle VM deployment job with full implementation
-					jobLogs, jobErr = getVMDeploymentLogs(apiClient, orgId, currentPipelineIDForRun, currentRunID, job)
-				} else {
-					// Regular job - fetch logs
-					jobIdStr := fmt.Sprintf("%d", job.ID)
-					jobLogs, jobErr = apiClient.GetPipelineJobRunLog(orgId, currentPipelineIDForRun, currentRunID, jobIdStr)
-				}
+			// Fetch logs for this specific job
+			var jobLogs string
+			var jobErr error
 
-				// Add job logs to display
-				app.QueueUpdateDraw(func() {
-					if !isLogViewActive || logViewTextView == nil {
-						return
-					}
+			hasVMDeployAction := isVMDeployJob(job)
 
-					currentText := logViewTextView.GetText(false)
+			if hasVMDeployAction {
+				// Handle VM deployment job with full implementation
+				jobLogs, jobErr = getVMDeploymentLogs(ctx, apiClient, orgId, pipelineID, runID, job)
+			} else {
+				// Regular job - the OpenAPI returns the whole log each time, of
+				// which only the lines not received yet are added below
+				jobIdStr := fmt.Sprintf("%d", job.ID)
+				jobLogs, jobErr = apiClient.GetPipelineJobRunLogContext(ctx, orgId, pipelineID, runID, jobIdStr)
+			}
 
-					if jobErr != nil {
-						currentText += fmt.Sprintf("Error fetching logs for job %s: %v\n", fmt.Sprintf("%d", job.ID), jobErr)
-					} else if jobLogs == "" {
-						currentText += "No logs available for this job.\n"
-					} else {
-						currentText += jobLogs
-						if !strings.HasSuffix(jobLogs, "\n") {
-							currentText += "\n"
-						}
+			// Add the new part of the job's log to the display
+			app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || !isLogViewActive || logViewTextView == nil || i >= len(logJobSections) {
+					return
+				}
+				section := &logJobSections[i]
+				section.loaded = true
+				section.err = jobErr
+				switch {
+				case jobErr != nil:
+					// Keep what was received; the next refresh tries again
+				case hasVMDeployAction:
+					// Deployment details are rebuilt on every fetch
+					section.log = jobLogs
+					section.markup = true
+					section.complete = finished
+				default:
+					text, reset := logTail.Next(job.ID, jobLogs, finished)
+					if reset {
+						section.log = ""
 					}
+					section.log += text
+					section.complete = logTail.Done(job.ID)
+				}
+				renderLogView()
+			})
 
-					currentText += "\n" + strings.Repeat("=", 80) + "\n\n"
-
-					logViewTextView.SetText(currentText)
-					logViewTextView.ScrollToEnd()
-				})
-
-				// Small delay to make progressive loading visible
+			// Small delay to make progressive loading visible
+			if firstLoad {
 				time.Sleep(100 * time.Millisecond)
 			}
 		}
 
-		// Mark loading as complete
-		isLogLoadingInProgress = false
-		logLoadingComplete = true
-
 		// Final update
 		app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			// Mark loading as complete
+			isLogLoadingInProgress = false
+			logLoadingComplete = true
 			if !isLogViewActive || logViewTextView == nil {
 				return
 			}
 
-			currentText := logViewTextView.GetText(false)
-			currentText += fmt.Sprintf("Total jobs processed: %d\n", currentJobIndex)
+			// The progress is in the status bar, leaving the end of the text
+			// to the logs
+			logFooterText = ""
+			logUpdatedAt = time.Now()
+
+			// Keep the steps of the job shown up to date
+			requestLogJobSteps()
 
 			// Handle delayed auto-refresh stop logic
 			if !preserveOriginalStatus {
@@ -1327,15 +1630,14 @@ func startProgressiveLogLoading(app *tview.Application, apiClient *api.Client, o
 				}
 			}
 
-			logViewTextView.SetText(currentText)
-			logViewTextView.ScrollToEnd()
+			renderLogView()
 			updateLogStatusBar()
 		})
 	}()
 }
 
 // updateRunHistoryTable updates the run history table for a specific pipeline
-func updateRunHistoryTable(table *tview.Table, app *tview.Application, apiClient *api.Client, orgId, pipelineId, pipelineName string) {
+func updateRunHistoryTable(table *tview.Table, app *tview.Application, apiClient api.PipelineService, orgId, pipelineId, pipelineName string) {
 	table.Clear()
 
 	// Update title with pagination info
@@ -1361,7 +1663,7 @@ func updateRunHistoryTable(table *tview.Table, app *tview.Application, apiClient
 	runs, err := apiClient.ListPipelineRuns(orgId, pipelineId)
 	if err != nil {
 		// Show error message
-		cell := tview.NewTableCell(fmt.Sprintf("Error fetching runs: %v", err)).
+		cell := tview.NewTableCell(fmt.Sprintf("Error fetching runs: %s", describeError(err))).
 			SetTextColor(tcell.ColorRed).
 			SetAlign(tview.AlignCenter).
 			SetBackgroundColor(tcell.ColorDefault)
@@ -1506,7 +1808,7 @@ func updateRunHistoryTable(table *tview.Table, app *tview.Application, apiClient
 
 // startProgressivePipelineLoading starts loading pipelines progressively page by page
 // It uses cached data for all pipelines view to avoid repeated server requests
-func startProgressivePipelineLoading(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient *api.Client, orgId string) {
+func startProgressivePipelineLoading(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient api.PipelineService, orgId string) {
 	// For group pipelines, always load from server since they're not cached
 	if currentViewMode == "pipelines_in_group" && selectedGroupID != "" {
 		startProgressivePipelineLoadingFromServer(table, app, searchInput, apiClient, orgId)
@@ -1537,7 +1839,7 @@ func startProgressivePipelineLoading(table *tview.Table, app *tview.Application,
 }
 
 // loadPipelinesFromCache loads pipelines from the cache immediately
-func loadPipelinesFromCache(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient *api.Client, orgId string) {
+func loadPipelinesFromCache(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient api.PipelineService, orgId string) {
 	// Reset loading state
 	isPipelineLoadingInProgress = false
 	pipelineLoadingComplete = true
@@ -1557,7 +1859,7 @@ func loadPipelinesFromCache(table *tview.Table, app *tview.Application, searchIn
 }
 
 // waitForCacheAndLoad waits for cache loading to complete and then loads data
-func waitForCacheAndLoad(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient *api.Client, orgId string) {
+func waitForCacheAndLoad(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient api.PipelineService, orgId string) {
 	// Show loading state
 	isPipelineLoadingInProgress = true
 	pipelineLoadingComplete = false
@@ -1601,11 +1903,12 @@ func waitForCacheAndLoad(table *tview.Table, app *tview.Application, searchInput
 }
 
 // loadAllPipelinesCacheProgressively loads all pipelines into cache for the first time
-func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient *api.Client, orgId string) {
+func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient api.PipelineService, orgId string) {
 	// Mark cache as loading
 	allPipelinesCacheLoading = true
 	allPipelinesCacheLoaded = false
 	allPipelinesCache = []api.Pipeline{}
+	generation := pipelineCacheGeneration
 
 	// Reset loading state
 	isPipelineLoadingInProgress = true
@@ -1622,6 +1925,11 @@ func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Applicati
 	go func() {
 		// Define callback function for each page
 		callback := func(pipelines []api.Pipeline, currentPage, totalPages int, isComplete bool) error {
+			// Stop loading pipelines of an organization that is no longer shown
+			if generation != pipelineCacheGeneration {
+				return errStaleCache
+			}
+
 			// Update loading state
 			pipelineLoadingCurrentPage = currentPage
 			pipelineLoadingTotalPages = totalPages
@@ -1662,6 +1970,9 @@ func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Applicati
 
 		// Handle any errors
 		if err != nil {
+			if generation != pipelineCacheGeneration {
+				return
+			}
 			allPipelinesCacheLoading = false
 			allPipelinesCacheLoaded = false
 			isPipelineLoadingInProgress = false
@@ -1680,7 +1991,7 @@ func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Applicati
 					table.SetCell(0, col, cell)
 				}
 
-				cell := tview.NewTableCell(fmt.Sprintf("Error loading pipelines: %v", err)).
+				cell := tview.NewTableCell(fmt.Sprintf("Error loading pipelines: %s", describeError(err))).
 					SetTextColor(tcell.ColorRed).
 					SetAlign(tview.AlignCenter)
 				table.SetCell(1, 0, cell)
@@ -1693,7 +2004,7 @@ func loadAllPipelinesCacheProgressively(table *tview.Table, app *tview.Applicati
 }
 
 // startProgressivePipelineLoadingFromServer loads pipelines directly from server (for filtered views)
-func startProgressivePipelineLoadingFromServer(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient *api.Client, orgId string) {
+func startProgressivePipelineLoadingFromServer(table *tview.Table, app *tview.Application, searchInput *tview.InputField, apiClient api.PipelineService, orgId string) {
 	// Reset loading state
 	isPipelineLoadingInProgress = true
 	pipelineLoadingCurrentPage = 0
@@ -1905,7 +2216,7 @@ func startProgressivePipelineLoadingFromServer(table *tview.Table, app *tview.Ap
 						table.SetCell(0, col, cell)
 					}
 
-					cell := tview.NewTableCell(fmt.Sprintf("Error loading pipelines: %v", finalErr)).
+					cell := tview.NewTableCell(fmt.Sprintf("Error loading pipelines: %s", describeError(finalErr))).
 						SetTextColor(tcell.ColorRed).
 						SetAlign(tview.AlignCenter)
 					table.SetCell(1, 0, cell)
@@ -1919,7 +2230,7 @@ func startProgressivePipelineLoadingFromServer(table *tview.Table, app *tview.Ap
 }
 
 // NewMainView creates the main layout for the application.
-func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tview.Primitive {
+func NewMainView(app *tview.Application, apiClient api.PipelineService, orgId string) tview.Primitive {
 	// Force default background color for primitives to handle potential InputField empty background issue
 	tview.Styles.PrimitiveBackgroundColor = tcell.ColorDefault
 
@@ -1969,7 +2280,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 
 	// Help info
 	helpInfo := tview.NewTextView().
-		SetText("Keys: j/k=move, Enter=run history, r=run, a=toggle running/all, b=toggle bookmarks, B=bookmark, Ctrl+G=groups, /=search, q=back, Q=quit").
+		SetText("Keys: j/k=move, Enter=run history, r=run, Space=mark, R=run marked, C=clear marks, D=active runs, P=run plan, a=toggle running/all, b=toggle bookmarks, B=bookmark, Ctrl+G=groups, O=switch org, /=search, q=back, Q=quit").
 		SetTextAlign(tview.AlignLeft).
 		SetTextColor(tcell.ColorGray)
 	helpInfo.SetBackgroundColor(tcell.ColorDefault)
@@ -2001,7 +2312,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 
 	// Group help info
 	groupHelpInfo := tview.NewTextView().
-		SetText("Keys: j/k=move, Enter=select group, /=search, q=back to all pipelines, Q=quit").
+		SetText("Keys: j/k=move, Enter=select group, O=switch org, /=search, q=back to all pipelines, Q=quit").
 		SetTextAlign(tview.AlignLeft).
 		SetTextColor(tcell.ColorGray)
 	groupHelpInfo.SetBackgroundColor(tcell.ColorDefault)
@@ -2027,10 +2338,16 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		SetTextColor(tcell.ColorWhite)
 	logStatusBar.SetBackgroundColor(tcell.ColorDefault)
 
+	// Job list next to the log, selecting the job whose log is shown
+	logJobTable = newLogJobTable()
+	logBody = tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(logJobTable, 32, 0, false).
+		AddItem(logViewTextView, 0, 1, true)
+
 	// Create log page with status bar
 	logPage = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(logViewTextView, 0, 1, true). // TextView takes most space, is focus target
-		AddItem(logStatusBar, 1, 1, false)    // Status bar takes 1 line, not focusable
+		AddItem(logBody, 0, 1, true).      // Job list and log text take most space, the text is the focus target
+		AddItem(logStatusBar, 1, 1, false) // Status bar takes 1 line, not focusable
 
 	// Run History View elements
 	runHistoryTable = tview.NewTable().SetBorders(false).SetSelectable(true, false)
@@ -2042,7 +2359,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 
 	// Run history help info
 	runHistoryHelpInfo := tview.NewTextView().
-		SetText("Keys: j/k=move, Enter=view logs, r=run pipeline, X=stop run, [/]=prev/next page, 0=first page, q=back to pipelines, Q=quit").
+		SetText("Keys: j/k=move, Enter=view logs, g=stage graph, r=run pipeline, X=stop run, [/]=prev/next page, 0=first page, q=back to pipelines, Q=quit").
 		SetTextAlign(tview.AlignLeft).
 		SetTextColor(tcell.ColorGray)
 	runHistoryHelpInfo.SetBackgroundColor(tcell.ColorDefault)
@@ -2051,11 +2368,32 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		AddItem(runHistoryTable, 0, 1, true).
 		AddItem(runHistoryHelpInfo, 1, 1, false)
 
+	// Run graph view elements
+	runGraphHeader = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignLeft)
+	runGraphHeader.SetBackgroundColor(tcell.ColorDefault)
+
+	runGraphView = newRunGraph()
+	runGraphView.SetBorder(true).SetTitle("Stages").SetBackgroundColor(tcell.ColorDefault)
+
+	runGraphHelpInfo := tview.NewTextView().
+		SetText("Keys: h/l=stage, j/k=job, Enter=job log, L=full log, a=approve/reject gate, d=deployment, o=job actions, r=retry job, s=skip job, x=stop job, R=refresh, q=back to run history, Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	runGraphHelpInfo.SetBackgroundColor(tcell.ColorDefault)
+
+	runGraphPage := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(runGraphHeader, 1, 1, false).
+		AddItem(runGraphView, 0, 1, true).
+		AddItem(runGraphHelpInfo, 1, 1, false)
+
 	// Main pages
 	mainPages := tview.NewPages().
 		AddPage("pipelines", pipelineListFlexView, true, true).
 		AddPage("groups", groupListFlexView, true, false).
 		AddPage("run_history", runHistoryPage, true, false).
+		AddPage("run_graph", runGraphPage, true, false).
 		AddPage("logs", logPage, true, false) // Log page, initially not visible
 	mainPagesGlobal = mainPages // Set global reference for modals
 
@@ -2065,7 +2403,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 	// Initial population of the group table
 	if fetchErrGroups != nil {
 		groupTable.Clear()
-		cell := tview.NewTableCell(fmt.Sprintf("Error fetching groups: %v", fetchErrGroups)).
+		cell := tview.NewTableCell(fmt.Sprintf("Error fetching groups: %s", describeError(fetchErrGroups))).
 			SetTextColor(tcell.ColorRed).
 			SetAlign(tview.AlignCenter)
 		groupTable.SetCell(0, 0, cell)
@@ -2124,6 +2462,36 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 				}
 			}
 			return nil
+		case ' ': // Mark or unmark the pipeline for a batch run
+			if rowCount > 1 && currentRow > 0 {
+				if selectedPipeline, ok := pipelineRowMap[currentRow]; ok && selectedPipeline != nil {
+					toggleMark(*selectedPipeline)
+					updatePipelineTable(pipelineTable, app, searchInput, apiClient, orgId)
+					if currentRow+1 < pipelineTable.GetRowCount() {
+						pipelineTable.Select(currentRow+1, 0)
+					}
+				}
+			}
+			return nil
+		case 'R': // Run the marked pipelines
+			showBatchRunDialog(app, apiClient, orgId)
+			return nil
+		case 'C': // Clear the marks
+			if len(markedPipelines) > 0 {
+				markedPipelines = make(map[string]api.Pipeline)
+				updatePipelineTable(pipelineTable, app, searchInput, apiClient, orgId)
+			}
+			return nil
+		case 'D': // Dashboard of the runs going on
+			showDashboard(app, apiClient, orgId)
+			return nil
+		case 'P': // Back to the run plan
+			if activePlan == nil {
+				ShowModal("No Plan", "No run plan is running. Start flowt with -plan FILE to run one.", []string{"OK"}, nil)
+				return nil
+			}
+			showPlanView(app)
+			return nil
 		case 'a': // Toggle status filter
 			showOnlyRunningWaiting = !showOnlyRunningWaiting
 			startProgressivePipelineLoading(pipelineTable, app, searchInput, apiClient, orgId)
@@ -2341,7 +2709,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 									err := apiClient.StopPipelineRun(orgId, currentPipelineIDForRun, selectedRun.RunID)
 									app.QueueUpdateDraw(func() {
 										if err != nil {
-											ShowModal("Error", fmt.Sprintf("Failed to stop pipeline run: %v", err), []string{"OK"}, nil)
+											ShowModal("Error", fmt.Sprintf("Failed to stop pipeline run: %s", describeError(err)), []string{"OK"}, nil)
 										} else {
 											ShowModal("Success", "Pipeline run stop request sent successfully.", []string{"OK"}, func(buttonIndex int, buttonLabel string) {
 												// Refresh the run history table to show updated status
@@ -2385,6 +2753,22 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 				}
 			}
 			return nil
+		case 'g':
+			// Show the stages and jobs of the selected run as a graph
+			if rowCount > 1 && currentRow > 0 {
+				if selectedRun, ok := runHistoryRowMap[currentRow]; ok && selectedRun != nil {
+					currentRunID = selectedRun.RunID
+					currentRunStatus = selectedRun.Status
+					isRunGraphActive = true
+					runGraphBack = nil
+					runGraphView.SetDetails(nil)
+					updateRunGraphHeader(nil, nil)
+					mainPages.SwitchToPage("run_graph")
+					app.SetFocus(runGraphView)
+					startRunGraphRefresh(app, apiClient, orgId)
+				}
+			}
+			return nil
 		case 'r': // Run pipeline
 			// Find the pipeline object for the current pipeline
 			var selectedPipeline *api.Pipeline
@@ -2404,11 +2788,13 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 			if rowCount > 1 && currentRow > 0 {
 				if selectedRun, ok := runHistoryRowMap[currentRow]; ok && selectedRun != nil {
 					currentRunID = selectedRun.RunID
+					resetLogJobs(0)
 					currentRunStatus = selectedRun.Status  // Initialize status from selected run
 					originalRunStatus = selectedRun.Status // Store original status
 					preserveOriginalStatus = true          // Preserve the original status for historical runs
 					isLogViewActive = true
 					isNewlyCreatedRun = false // Mark this as a historical run
+					resetLogViewContext()
 
 					// Switch to log view and start progressive log loading
 					go func() {
@@ -2448,6 +2834,90 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		return event
 	})
 
+	// --- Event Handlers for runGraphView ---
+	// openJobLog shows the log of one job of the run, or of every job if job is nil
+	openJobLog := func(job *api.Job) {
+		stopRunGraphRefresh()
+		if job != nil {
+			resetLogJobs(job.ID)
+		} else {
+			resetLogJobs(0)
+			logJobChosen = true // The whole log was asked for, even if a job failed
+		}
+		preserveOriginalStatus = false // Let the log view follow the run status
+		isLogViewActive = true
+		isNewlyCreatedRun = false
+		resetLogViewContext()
+		logViewTextView.SetText(fmt.Sprintf("Fetching logs for run %s...", currentRunID))
+		updateLogStatusBar()
+		mainPages.SwitchToPage("logs")
+		app.SetFocus(logViewTextView)
+		go startLogAutoRefresh(app, apiClient, orgId, currentPipelineName, "N/A", "")
+	}
+	runGraphView.SetSelectedFunc(func(stage api.Stage, job api.Job) {
+		openJobLog(&job)
+	})
+	closeRunGraph := func() {
+		isRunGraphActive = false
+		stopRunGraphRefresh()
+		if back := runGraphBack; back != nil {
+			runGraphBack = nil
+			back()
+			return
+		}
+		mainPages.SwitchToPage("run_history")
+		app.SetFocus(runHistoryTable)
+	}
+	runGraphView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			closeRunGraph()
+			return nil
+		case 'L':
+			openJobLog(nil)
+			return nil
+		case 'R':
+			startRunGraphRefresh(app, apiClient, orgId)
+			return nil
+		case 'a':
+			// Approve or reject the manual validation the selected job waits on
+			if _, job, ok := runGraphView.Selection(); ok {
+				showValidationDialog(app, apiClient, orgId, job, func() {
+					startRunGraphRefresh(app, apiClient, orgId)
+				})
+			}
+			return nil
+		case 'd':
+			// Follow the batches and machines of the selected VM deploy job
+			if _, job, ok := runGraphView.Selection(); ok {
+				showDeployView(app, apiClient, orgId, job)
+			}
+			return nil
+		case 'o':
+			// Choose from the actions the selected job offers, e.g. resuming a deployment
+			if stage, job, ok := runGraphView.Selection(); ok {
+				showJobActionsMenu(app, apiClient, orgId, stage, job, func() {
+					startRunGraphRefresh(app, apiClient, orgId)
+				})
+			}
+			return nil
+		case 'r', 's', 'x':
+			// Retry, skip or stop the selected job
+			if stage, job, ok := runGraphView.Selection(); ok {
+				confirmJobAction(app, apiClient, orgId, jobActions[event.Rune()], stage, job, func() {
+					// The run may be going again, so follow it
+					startRunGraphRefresh(app, apiClient, orgId)
+				})
+			}
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			closeRunGraph()
+			return nil
+		}
+		return event
+	})
+
 	// --- Event Handlers for logViewTextView ---
 	logViewTextView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		// Handle vim-style search navigation first
@@ -2481,6 +2951,22 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 				startLogSearch(app)
 			}
 			return nil
+		case 'J':
+			// Show the next job's log
+			moveLogJob(1)
+			return nil
+		case 'K':
+			// Show the previous job's log
+			moveLogJob(-1)
+			return nil
+		case 'S':
+			// Search the job shown or every job
+			logSearchAllJobs = !logSearchAllJobs
+			if logSearchActive && logSearchQuery != "" {
+				performLogSearch(logSearchQuery, app)
+			}
+			updateLogSearchStatusBar()
+			return nil
 		case 'n':
 			// Next search match (only if search is active)
 			if logSearchActive {
@@ -2493,6 +2979,16 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 				prevLogSearchMatch(app)
 			}
 			return nil
+		case 'c':
+			// Show the logs' ANSI colors or plain text
+			logShowColors = !logShowColors
+			renderLogView()
+			updateLogStatusBar()
+			return nil
+		case 'z':
+			// Fold the steps that passed, or unfold every step
+			toggleLogStepsFolded()
+			return nil
 		case 'f':
 			// Page down (same as Ctrl+F)
 			logViewTextView.InputHandler()(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone), nil)
@@ -2543,7 +3039,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 									err := apiClient.StopPipelineRun(orgId, currentPipelineIDForRun, currentRunID)
 									app.QueueUpdateDraw(func() {
 										if err != nil {
-											ShowModal("Error", fmt.Sprintf("Failed to stop pipeline run: %v", err), []string{"OK"}, nil)
+											ShowModal("Error", fmt.Sprintf("Failed to stop pipeline run: %s", describeError(err)), []string{"OK"}, nil)
 										} else {
 											ShowModal("Success", "Pipeline run stop request sent successfully.", []string{"OK"}, func(buttonIndex int, buttonLabel string) {
 												// Stop auto-refresh since we terminated the run
@@ -2570,7 +3066,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		case 'e':
 			// Open logs in editor
 			if logViewTextView != nil {
-				logContent := logViewTextView.GetText(false)
+				logContent := logPlainText(logViewTextView.GetText(false))
 				if logContent != "" {
 					err := OpenInEditor(logContent, app)
 					if err != nil {
@@ -2582,7 +3078,7 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		case 'v':
 			// Open logs in pager
 			if logViewTextView != nil {
-				logContent := logViewTextView.GetText(false)
+				logContent := logPlainText(logViewTextView.GetText(false))
 				if logContent != "" {
 					err := OpenInPager(logContent, app)
 					if err != nil {
@@ -2599,13 +3095,19 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 			}
 			// Exit log view
 			isLogViewActive = false
-			// Stop auto-refresh when leaving log view
+			// Stop auto-refresh and abandon in-flight requests when leaving log view
 			stopLogAutoRefresh()
+			cancelLogViewRequests()
 			// Exit search mode if active
 			if logSearchActive {
 				exitLogSearch(app)
 			}
-			if isRunHistoryActive {
+			if isRunGraphActive {
+				// Return to the run graph if the log was opened from there
+				mainPages.SwitchToPage("run_graph")
+				app.SetFocus(runGraphView)
+				startRunGraphRefresh(app, apiClient, orgId)
+			} else if isRunHistoryActive {
 				// Return to run history if we came from there
 				mainPages.SwitchToPage("run_history")
 				app.SetFocus(runHistoryTable)
@@ -2619,6 +3121,10 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 
 		// Handle special keys
 		switch event.Key() {
+		case tcell.KeyTab:
+			// Move to the job list
+			app.SetFocus(logJobTable)
+			return nil
 		case tcell.KeyCtrlF:
 			// Page down
 			logViewTextView.InputHandler()(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone), nil)
@@ -2635,13 +3141,19 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 			}
 			// Exit log view
 			isLogViewActive = false
-			// Stop auto-refresh when leaving log view
+			// Stop auto-refresh and abandon in-flight requests when leaving log view
 			stopLogAutoRefresh()
+			cancelLogViewRequests()
 			// Exit search mode if active
 			if logSearchActive {
 				exitLogSearch(app)
 			}
-			if isRunHistoryActive {
+			if isRunGraphActive {
+				// Return to the run graph if the log was opened from there
+				mainPages.SwitchToPage("run_graph")
+				app.SetFocus(runGraphView)
+				startRunGraphRefresh(app, apiClient, orgId)
+			} else if isRunHistoryActive {
 				// Return to run history if we came from there
 				mainPages.SwitchToPage("run_history")
 				app.SetFocus(runHistoryTable)
@@ -2656,6 +3168,40 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 		return event
 	})
 
+	// --- Event Handlers for logJobTable ---
+	logJobTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := logJobTable.GetSelection()
+		if index, ok := logStepRowMap[row]; ok && (event.Key() == tcell.KeyEnter || event.Rune() == ' ') {
+			// Fold or unfold the selected step
+			toggleLogStep(index)
+			scrollToLogStep(index)
+			return nil
+		}
+		switch event.Key() {
+		case tcell.KeyTab, tcell.KeyEnter:
+			// Back to the log of the selected job
+			app.SetFocus(logViewTextView)
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyHome, tcell.KeyEnd, tcell.KeyPgUp, tcell.KeyPgDn:
+			return event
+		}
+		switch event.Rune() {
+		case 'j', 'k', 'g', 'G':
+			// Move through the jobs, showing each job's log
+			return event
+		case 'l':
+			app.SetFocus(logViewTextView)
+			return nil
+		}
+		// Every other key works as it does in the log
+		if capture := logViewTextView.GetInputCapture(); capture != nil {
+			if capture(event) == nil {
+				return nil
+			}
+		}
+		return event
+	})
+
 	// Global keybindings on mainPages
 	mainPages.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		currentPage, _ := mainPages.GetFrontPage()
@@ -2673,6 +3219,12 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 				startLogSearch(app)
 				return nil
 			}
+		case 'O':
+			// Switch organization from the pipeline and group lists, but not while typing a search
+			if focused := app.GetFocus(); focused == pipelineTable || focused == groupTable {
+				showProfileSwitcher(app)
+				return nil
+			}
 		}
 
 		// Handle special keys
@@ -2729,6 +3281,98 @@ func NewMainView(app *tview.Application, apiClient *api.Client, orgId string) tv
 	return mainPages
 }
 
+// errStaleCache stops loading pipelines into a cache that has been discarded
+var errStaleCache = errors.New("pipeline cache discarded")
+
+// showProfileSwitcher shows the configured profiles and switches to the selected one
+func showProfileSwitcher(app *tview.Application) {
+	if globalSwitchProfile == nil || len(globalProfiles) < 2 {
+		ShowModal("Switch Organization", "Only one organization is configured.\n\nAdd more under profiles: in ~/.flowt/config.yml", []string{"OK"}, nil)
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle("Switch Organization").SetBackgroundColor(tcell.ColorDefault)
+	list.SetMainTextColor(tcell.ColorWhite)
+	list.SetSelectedBackgroundColor(tcell.ColorGray)
+	for i, name := range globalProfiles {
+		name := name
+		label := name
+		if name == globalCurrentProfile {
+			label += " (current)"
+			list.SetCurrentItem(i)
+		}
+		list.AddItem(label, "", 0, func() {
+			HideModal()
+			if name != globalCurrentProfile {
+				switchProfile(app, name)
+			}
+		})
+	}
+	list.SetDoneFunc(HideModal)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		}
+		return event
+	})
+
+	// Center the list over the current page
+	width := 40
+	for _, name := range globalProfiles {
+		if len(name)+16 > width {
+			width = len(name) + 16
+		}
+	}
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(list, len(globalProfiles)+2, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	mainPagesGlobal.AddPage("modal", modal, true, true)
+	app.SetFocus(list)
+}
+
+// switchProfile connects to the organization of the named profile and
+// rebuilds the main view for it, discarding the cached pipelines
+func switchProfile(app *tview.Application, name string) {
+	ShowModal("Switch Organization", fmt.Sprintf("Connecting to %s...", name), nil, nil)
+
+	go func() {
+		service, orgId, err := globalSwitchProfile(name)
+		app.QueueUpdateDraw(func() {
+			HideModal()
+			if err != nil {
+				ShowModal("Error", fmt.Sprintf("Failed to switch to %s: %s", name, describeError(err)), []string{"OK"}, nil)
+				return
+			}
+
+			globalCurrentProfile = name
+			stopLogAutoRefresh()
+			cancelLogViewRequests()
+
+			// Pipelines of the previous organization must not be shown or reused
+			pipelineCacheGeneration++
+			allPipelinesCache = nil
+			markedPipelines = make(map[string]api.Pipeline)
+			allPipelinesCacheLoaded = false
+			allPipelinesCacheLoading = false
+
+			// NewMainView installs its own application input capture; keep the caller's
+			capture := app.GetInputCapture()
+			mainView := NewMainView(app, service, orgId)
+			app.SetInputCapture(capture)
+			app.SetRoot(mainView, true)
+		})
+	}()
+}
+
 // startLogSearch initiates vim-style search in log view
 func startLogSearch(app *tview.Application) {
 	if logViewTextView == nil || logPage == nil {
@@ -2783,7 +3427,7 @@ func startLogSearch(app *tview.Application) {
 	// Add search input to log page
 	logPage.Clear()
 	logPage.AddItem(logSearchInput, 1, 1, true)
-	logPage.AddItem(logViewTextView, 0, 1, false)
+	logPage.AddItem(logBody, 0, 1, false)
 	logPage.AddItem(logStatusBar, 1, 1, false)
 
 	app.SetFocus(logSearchInput)
@@ -2802,24 +3446,17 @@ func performLogSearch(query string, app *tview.Application) {
 		logOriginalText = text
 	}
 
-	// Find all matches (case-insensitive)
-	logSearchMatches = []int{}
-	queryLower := strings.ToLower(query)
-	textLower := strings.ToLower(text)
-
-	start := 0
-	for {
-		idx := strings.Index(textLower[start:], queryLower)
-		if idx == -1 {
-			break
-		}
-		logSearchMatches = append(logSearchMatches, start+idx)
-		start = start + idx + 1
-	}
-
+	findLogSearchMatches(query)
 	if len(logSearchMatches) > 0 {
+		// Start at the first match in the job shown, if it has one
 		logSearchCurrentIdx = 0
-		highlightLogSearchMatches(text, query, app)
+		for i, jobID := range logSearchMatchJobs {
+			if jobID == currentLogJobID {
+				logSearchCurrentIdx = i
+				break
+			}
+		}
+		showLogSearchMatch(app)
 	} else {
 		logSearchCurrentIdx = -1
 		// Show original text if no matches
@@ -2829,40 +3466,42 @@ func performLogSearch(query string, app *tview.Application) {
 	updateLogSearchStatusBar()
 }
 
-// highlightLogSearchMatches highlights all search matches in the log text
-func highlightLogSearchMatches(text, query string, app *tview.Application) {
-	if len(logSearchMatches) == 0 {
-		return
+// findLogSearchMatches finds every case-insensitive match of the query in the
+// text shown, or in the text of every job when searching all jobs
+func findLogSearchMatches(query string) {
+	texts, jobs := logSearchTargets()
+	if texts == nil {
+		texts = []string{logOriginalText}
 	}
 
-	// Create highlighted text
-	var result strings.Builder
-	lastEnd := 0
-
-	for i, matchPos := range logSearchMatches {
-		// Add text before this match
-		result.WriteString(text[lastEnd:matchPos])
-
-		// Add highlighted match
-		if i == logSearchCurrentIdx {
-			// Current match: gray background with gold foreground
-			result.WriteString("[gold:gray]")
-			result.WriteString(text[matchPos : matchPos+len(query)])
-			result.WriteString("[-:-]")
-		} else {
-			// Other matches: gray background with white foreground
-			result.WriteString("[white:gray]")
-			result.WriteString(text[matchPos : matchPos+len(query)])
-			result.WriteString("[-:-]")
+	logSearchMatches = []int{}
+	logSearchMatchJobs = nil
+	queryLower := strings.ToLower(query)
+	for i, target := range texts {
+		// Match the text as it reads, not the style tags coloring it
+		targetLower := strings.ToLower(logPlainText(target))
+		start := 0
+		for {
+			idx := strings.Index(targetLower[start:], queryLower)
+			if idx == -1 {
+				break
+			}
+			logSearchMatches = append(logSearchMatches, start+idx)
+			if jobs != nil {
+				logSearchMatchJobs = append(logSearchMatchJobs, jobs[i])
+			}
+			start = start + idx + 1
 		}
-
-		lastEnd = matchPos + len(query)
 	}
+}
 
-	// Add remaining text
-	result.WriteString(text[lastEnd:])
+// highlightLogSearchMatches highlights all search matches in the log text
+func highlightLogSearchMatches(text, query string, app *tview.Application) {
+	if len(logSearchMatches) == 0 {
+		return
+	}
 
-	logViewTextView.SetText(result.String())
+	logViewTextView.SetText(highlightedLogText(text, query))
 
 	// Scroll to current match if there is one
 	if logSearchCurrentIdx >= 0 && logSearchCurrentIdx < len(logSearchMatches) {
@@ -2870,6 +3509,24 @@ func highlightLogSearchMatches(text, query string, app *tview.Application) {
 	}
 }
 
+// highlightedLogText returns the log text with the search matches in the job
+// shown highlighted, the current match in gold
+func highlightedLogText(text, query string) string {
+	// Matches in jobs other than the one shown are highlighted when shown
+	var matches []int
+	current := -1
+	for i, matchPos := range logSearchMatches {
+		if logSearchMatchJobs != nil && logSearchMatchJobs[i] != currentLogJobID {
+			continue
+		}
+		if i == logSearchCurrentIdx {
+			current = len(matches)
+		}
+		matches = append(matches, matchPos)
+	}
+	return highlightLogText(text, matches, len(query), current)
+}
+
 // scrollToLogSearchMatch scrolls the log view to show the current search match
 func scrollToLogSearchMatch(app *tview.Application) {
 	if logViewTextView == nil || logSearchCurrentIdx < 0 || logSearchCurrentIdx >= len(logSearchMatches) {
@@ -2884,7 +3541,7 @@ func scrollToLogSearchMatch(app *tview.Application) {
 	}
 
 	// Count newlines before the match position
-	lineNum := strings.Count(text[:matchPos], "\n")
+	lineNum := strings.Count(logPlainText(text)[:matchPos], "\n")
 
 	// Scroll to the line (tview uses 0-based line numbers)
 	logViewTextView.ScrollTo(lineNum, 0)
@@ -2897,7 +3554,7 @@ func nextLogSearchMatch(app *tview.Application) {
 	}
 
 	logSearchCurrentIdx = (logSearchCurrentIdx + 1) % len(logSearchMatches)
-	highlightLogSearchMatches(logOriginalText, logSearchQuery, app)
+	showLogSearchMatch(app)
 	updateLogSearchStatusBar()
 }
 
@@ -2911,7 +3568,7 @@ func prevLogSearchMatch(app *tview.Application) {
 	if logSearchCurrentIdx < 0 {
 		logSearchCurrentIdx = len(logSearchMatches) - 1
 	}
-	highlightLogSearchMatches(logOriginalText, logSearchQuery, app)
+	showLogSearchMatch(app)
 	updateLogSearchStatusBar()
 }
 
@@ -2920,6 +3577,7 @@ func exitLogSearch(app *tview.Application) {
 	logSearchActive = false
 	logSearchQuery = ""
 	logSearchMatches = []int{}
+	logSearchMatchJobs = nil
 	logSearchCurrentIdx = -1
 
 	// Clear the search input field when exiting search mode
@@ -2935,7 +3593,7 @@ func exitLogSearch(app *tview.Application) {
 
 	// Restore original log page layout
 	logPage.Clear()
-	logPage.AddItem(logViewTextView, 0, 1, true)
+	logPage.AddItem(logBody, 0, 1, true)
 	logPage.AddItem(logStatusBar, 1, 1, false)
 
 	app.SetFocus(logViewTextView)
@@ -2948,6 +3606,7 @@ func clearLogSearchHighlighting() {
 		logViewTextView.SetText(logOriginalText)
 	}
 	logSearchMatches = []int{}
+	logSearchMatchJobs = nil
 	logSearchCurrentIdx = -1
 	updateLogSearchStatusBar()
 }
@@ -2959,12 +3618,16 @@ func updateLogSearchStatusBar() {
 	}
 
 	if logSearchActive {
+		scope := "this job"
+		if logSearchAllJobs || currentLogJobID == 0 {
+			scope = "all jobs"
+		}
 		var searchInfo string
 		if len(logSearchMatches) > 0 {
-			searchInfo = fmt.Sprintf("Search: '%s' (%d/%d matches) | 'n' next, 'N' prev, '/' search, Esc/q to exit",
-				logSearchQuery, logSearchCurrentIdx+1, len(logSearchMatches))
+			searchInfo = fmt.Sprintf("Search %s: '%s' (%d/%d matches) | 'n' next, 'N' prev, '/' search, 'S' scope, Esc/q to exit",
+				scope, logSearchQuery, logSearchCurrentIdx+1, len(logSearchMatches))
 		} else if logSearchQuery != "" {
-			searchInfo = fmt.Sprintf("Search: '%s' (no matches) | Esc to exit", logSearchQuery)
+			searchInfo = fmt.Sprintf("Search %s: '%s' (no matches) | 'S' scope, Esc to exit", scope, logSearchQuery)
 		} else {
 			searchInfo = "Search mode | Enter search term, Esc to exit"
 		}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestUpdateRunHistoryTableWithFake(t *testing.T) {
+	service := fake.NewDemo()
+	pipelineID, ok := service.PipelineID("order-service")
+	if !ok {
+		t.Fatal("demo pipeline order-service not found")
+	}
+
+	currentRunHistoryPage = 1
+	table := tview.NewTable()
+	updateRunHistoryTable(table, tview.NewApplication(), service, fake.DemoOrganizationID, pipelineID, "order-service")
+
+	if table.GetRowCount() < 2 {
+		t.Fatalf("expected runs in the table, got %d rows", table.GetRowCount())
+	}
+	if len(runHistoryRowMap) != table.GetRowCount()-1 {
+		t.Errorf("runHistoryRowMap has %d entries for %d rows", len(runHistoryRowMap), table.GetRowCount()-1)
+	}
+	// The demo's newest run of order-service is still running and listed first
+	if status := table.GetCell(1, 1).Text; !strings.Contains(status, "RUNNING") {
+		t.Errorf("first row status = %q, want RUNNING", status)
+	}
+}
+
+func TestUpdateRunHistoryTableShowsErrorHint(t *testing.T) {
+	service := fake.NewDemo()
+	pipelineID, _ := service.PipelineID("order-service")
+	service.SetError("ListPipelineRuns", &api.APIError{Kind: api.ErrUnauthorized, StatusCode: 401, Method: "GET", Endpoint: "/runs"})
+
+	table := tview.NewTable()
+	updateRunHistoryTable(table, tview.NewApplication(), service, fake.DemoOrganizationID, pipelineID, "order-service")
+
+	text := table.GetCell(1, 0).Text
+	if !strings.Contains(text, "personal_access_token") {
+		t.Errorf("error cell %q lacks the authentication hint", text)
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	if got := describeError(errors.New("plain")); got != "plain" {
+		t.Errorf("describeError(plain) = %q", got)
+	}
+	err := &api.APIError{Kind: api.ErrNotFound, StatusCode: 404, Method: "GET", Endpoint: "/x"}
+	if got := describeError(err); !strings.Contains(got, "organization_id") {
+		t.Errorf("describeError(not found) = %q, want a hint", got)
+	}
+}
+
+func TestLoadRunForm(t *testing.T) {
+	service := fake.New()
+	p := service.AddPipeline("svc", []fake.StageSpec{{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: time.Minute}}}},
+		map[string]string{"https://example.com/order.git": "master", "https://example.com/payment.git": "master"})
+	service.SetVariables(p.PipelineID,
+		api.PipelineVariable{Name: "DEPLOY_ENV", Value: "staging", Runtime: true},
+		api.PipelineVariable{Name: "REGISTRY", Value: "registry.example.com"})
+	service.AddRun(p.PipelineID, time.Now().Add(-time.Hour), fake.RunOptions{Branches: map[string]string{"https://example.com/order.git": "release/1.8"}})
+
+	// The branches of the latest run are the defaults
+	repos, vars := loadRunForm(context.Background(), service, "org", p.PipelineID)
+	if len(repos) != 2 || repos[0].name != "order" || repos[0].branch != "release/1.8" || repos[1].name != "payment" || repos[1].branch != "master" {
+		t.Errorf("repos = %+v", repos)
+	}
+	if len(vars) != 1 || vars[0].Name != "DEPLOY_ENV" {
+		t.Errorf("vars = %+v", vars)
+	}
+
+	// Without the definition the repositories of the latest run are built
+	service.SetError("GetPipeline", errors.New("boom"))
+	repos, vars = loadRunForm(context.Background(), service, "org", p.PipelineID)
+	if len(repos) != 1 || repos[0].repo != "https://example.com/order.git" || repos[0].branch != "release/1.8" || len(vars) != 0 {
+		t.Errorf("without a definition: repos %+v, vars %+v", repos, vars)
+	}
+}
+
+func TestNewPreset(t *testing.T) {
+	p := newPreset(map[string]string{"a.git": "release/1.9", "b.git": "release/1.9"}, map[string]string{"HOTFIX": "true"})
+	if p.Branch != "release/1.9" || p.Branches != nil || p.Variables["HOTFIX"] != "true" {
+		t.Errorf("shared branch: %+v", p)
+	}
+	p = newPreset(map[string]string{"a.git": "release/1.9", "b.git": "main"}, nil)
+	if p.Branch != "" || p.Branches["b.git"] != "main" || p.Variables != nil {
+		t.Errorf("branches per repository: %+v", p)
+	}
+}
+
+// onUI runs fn on the goroutine of the running app and waits for it
+func onUI(app *tview.Application, fn func()) {
+	done := make(chan struct{})
+	app.QueueUpdate(func() {
+		fn()
+		close(done)
+	})
+	<-done
+}
+
+func TestProgressiveLogLoading(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	app := tview.NewApplication().SetScreen(screen)
+	go app.Run()
+	defer app.Stop()
+
+	service := fake.NewDemo()
+	pipelineID, _ := service.PipelineID("order-service")
+	runs, _ := service.ListPipelineRuns(fake.DemoOrganizationID, pipelineID)
+	onUI(app, func() {
+		logViewTextView = tview.NewTextView().SetDynamicColors(true)
+		resetLogJobs(0)
+		currentPipelineIDForRun, currentRunID = pipelineID, runs[0].RunID
+		preserveOriginalStatus = true
+		isLogViewActive = true
+		resetLogViewContext()
+	})
+	defer onUI(app, func() {
+		isLogViewActive = false
+		cancelLogViewRequests()
+		resetLogJobs(0)
+		logViewTextView, logStepLoader = nil, nil
+	})
+
+	// A refresh while the logs are loading takes over from the first load.
+	// Loads are started off the UI goroutine, as the auto-refresh does.
+	for i := 0; i < 5; i++ {
+		startProgressiveLogLoading(app, service, fake.DemoOrganizationID, "order-service", "master", "")
+		time.Sleep(50 * time.Millisecond)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var loaded, total int
+		onUI(app, func() {
+			total = len(logJobSections)
+			for _, section := range logJobSections {
+				if section.loaded {
+					loaded++
+				}
+			}
+		})
+		if total > 0 && loaded == total {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d jobs loaded", loaded, total)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
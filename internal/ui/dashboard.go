@@ -0,0 +1,340 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Refresh of the dashboard: every refresh lists the active pipelines and
+// loads the latest run and its details of each, so it is slower than the
+// refresh of the views of a single run and spreads the requests over a few
+// goroutines
+const (
+	dashboardInterval = 10 * time.Second
+	dashboardWorkers  = 4
+)
+
+var (
+	// State of the dashboard of active runs
+	isDashboardActive bool
+	dashboardTable    *tview.Table
+	dashboardHeader   *tview.TextView
+	dashboardCancel   context.CancelFunc // Stops the live refresh of the dashboard
+	dashboardRows     []dashboardRow
+)
+
+// dashboardRow is a run going on in the organization
+type dashboardRow struct {
+	pipeline api.Pipeline
+	run      api.PipelineRun
+	details  *api.PipelineRunDetails // nil if they could not be loaded
+	err      error                   // Why the run or its details could not be loaded
+}
+
+// runProgress counts the finished jobs of a run and all its jobs
+func runProgress(details *api.PipelineRunDetails) (done, total int) {
+	if details == nil {
+		return 0, 0
+	}
+	for _, stage := range details.Stages {
+		for _, job := range stage.Jobs {
+			total++
+			if runwatch.IsFinished(job.Status) {
+				done++
+			}
+		}
+	}
+	return done, total
+}
+
+// progressBar draws the share of finished jobs, e.g. "███░░░░░░░ 3/10"
+func progressBar(done, total, width int) string {
+	if total == 0 {
+		return "-"
+	}
+	filled := done * width / total
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + fmt.Sprintf(" %d/%d", done, total)
+}
+
+// currentStage names the stage a run has got to and the job running in it,
+// e.g. "Deploy › deploy-prod", marking jobs waiting for manual validation
+func currentStage(details *api.PipelineRunDetails) string {
+	if details == nil {
+		return "-"
+	}
+	for _, stage := range details.Stages {
+		if runwatch.StageStatus(stage) == "SUCCESS" {
+			continue
+		}
+		for _, job := range stage.Jobs {
+			if _, ok := job.ValidationGate(); ok {
+				return stage.Name + " › ⏸ " + job.Name
+			}
+		}
+		for _, job := range stage.Jobs {
+			if strings.EqualFold(job.Status, "RUNNING") {
+				return stage.Name + " › " + job.Name
+			}
+		}
+		return stage.Name
+	}
+	return "-"
+}
+
+// runStart returns when a run started, from its details if the run does not say
+func runStart(row dashboardRow) time.Time {
+	if !row.run.StartTime.IsZero() {
+		return row.run.StartTime
+	}
+	if row.details != nil && row.details.CreateTime != 0 {
+		return time.UnixMilli(row.details.CreateTime)
+	}
+	return time.Time{}
+}
+
+// loadDashboard finds the runs going on in the organization: the latest run
+// of each RUNNING or WAITING pipeline with its stages, oldest first. Older
+// runs of a pipeline that are still going on are not listed, as finding them
+// would take listing every run of the pipeline on every refresh.
+func loadDashboard(ctx context.Context, apiClient api.PipelineService, orgId string) ([]dashboardRow, error) {
+	pipelines, err := apiClient.ListPipelinesWithStatusContext(ctx, orgId, []string{"RUNNING", "WAITING"})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]dashboardRow, len(pipelines))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < dashboardWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				row := dashboardRow{pipeline: pipelines[i]}
+				run, err := apiClient.GetLatestPipelineRunContext(ctx, orgId, pipelines[i].PipelineID)
+				if err != nil {
+					row.err = err
+				} else {
+					row.run = *run
+					row.details, row.err = apiClient.GetPipelineRunDetailsContext(ctx, orgId, run.PipelineID, run.RunID)
+				}
+				rows[i] = row
+			}
+		}()
+	}
+	for i := range pipelines {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// The run may have finished since the pipelines were listed
+	active := rows[:0]
+	for _, row := range rows {
+		if row.err != nil || !runwatch.IsFinished(row.run.Status) {
+			active = append(active, row)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return runStart(active[i]).Before(runStart(active[j]))
+	})
+	return active, nil
+}
+
+// fillDashboardTable shows the active runs, a row per run, keeping the
+// selected run selected
+func fillDashboardTable(table *tview.Table, rows []dashboardRow) {
+	selected := ""
+	if row, _ := table.GetSelection(); row >= 1 && row <= len(dashboardRows) {
+		selected = dashboardRows[row-1].pipeline.PipelineID
+	}
+	table.Clear()
+	for col, title := range []string{"Pipeline", "Run", "Status", "Stage", "Progress", "Elapsed", "Trigger"} {
+		table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+	if len(rows) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("No runs are going on.").SetTextColor(tcell.ColorGray).SetSelectable(false))
+		return
+	}
+
+	selectRow := 1
+	for i, r := range rows {
+		status, stage := valueOr(r.run.Status, r.pipeline.Status), currentStage(r.details)
+		done, total := runProgress(r.details)
+		progress := progressBar(done, total, 10)
+		if r.err != nil {
+			stage = describeError(r.err)
+		}
+		cells := []string{r.pipeline.Name, valueOr(r.run.RunID, "-"), status, stage, progress, valueOr(elapsed(runStart(r), time.Time{}), "-"), valueOr(r.run.TriggerMode, "-")}
+		for col, text := range cells {
+			cell := tview.NewTableCell(tview.Escape(text)).SetExpansion(1)
+			switch {
+			case col == 2:
+				cell.SetTextColor(getStatusColor(status))
+			case col == 3 && r.err != nil:
+				cell.SetTextColor(tcell.ColorRed)
+			case col == 3 && strings.Contains(text, "⏸"):
+				cell.SetTextColor(tcell.ColorYellow)
+			}
+			table.SetCell(i+1, col, cell)
+		}
+		if r.pipeline.PipelineID == selected {
+			selectRow = i + 1
+		}
+	}
+	table.Select(selectRow, 0)
+}
+
+// updateDashboardHeader shows how many runs are going on and when they were
+// last loaded. Only the latest run of each pipeline is loaded, which the
+// header says, as older runs of a pipeline may still be going on too.
+func updateDashboardHeader(err error) {
+	if dashboardHeader == nil {
+		return
+	}
+	counts := make(map[string]int)
+	for _, r := range dashboardRows {
+		counts[strings.ToUpper(valueOr(r.run.Status, r.pipeline.Status))]++
+	}
+	text := fmt.Sprintf("Active runs: %d running, %d waiting (latest run of each pipeline) | Refresh every %s | Updated: %s",
+		counts["RUNNING"], counts["WAITING"], dashboardInterval, time.Now().Format("15:04:05"))
+	if err != nil {
+		text += " | [red]" + tview.Escape(describeError(err)) + "[-]"
+	}
+	dashboardHeader.SetText(text)
+}
+
+// showDashboard opens the dashboard of the runs going on in the organization,
+// refreshed until it is closed
+func showDashboard(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	dashboardRows = nil
+
+	dashboardHeader = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignLeft)
+	dashboardHeader.SetBackgroundColor(tcell.ColorDefault)
+	dashboardHeader.SetText("Loading active runs...")
+
+	dashboardTable = tview.NewTable().SetBorders(false).SetFixed(1, 0).SetSelectable(true, false)
+	dashboardTable.SetBorder(true).SetTitle("Active Runs").SetBackgroundColor(tcell.ColorDefault)
+	dashboardTable.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+
+	help := tview.NewTextView().
+		SetText("Keys: j/k=move, Enter=stage graph, R=refresh, q=back to pipelines, Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	help.SetBackgroundColor(tcell.ColorDefault)
+
+	page := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(dashboardHeader, 1, 1, false).
+		AddItem(dashboardTable, 0, 1, true).
+		AddItem(help, 1, 1, false)
+
+	closeView := func() {
+		isDashboardActive = false
+		stopDashboardRefresh()
+		mainPagesGlobal.RemovePage("dashboard")
+		mainPagesGlobal.SwitchToPage("pipelines")
+		app.SetFocus(pipelineTableGlobal)
+	}
+	dashboardTable.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(dashboardRows) || dashboardRows[row-1].run.RunID == "" {
+			return
+		}
+		// Follow the run in the stage graph, coming back here when it is closed
+		r := dashboardRows[row-1]
+		currentPipelineIDForRun, currentPipelineName = r.pipeline.PipelineID, r.pipeline.Name
+		currentRunID, currentRunStatus = r.run.RunID, r.run.Status
+		isRunGraphActive = true
+		runGraphBack = func() {
+			mainPagesGlobal.SwitchToPage("dashboard")
+			app.SetFocus(dashboardTable)
+			startDashboardRefresh(app, apiClient, orgId)
+		}
+		stopDashboardRefresh()
+		runGraphView.SetDetails(r.details)
+		updateRunGraphHeader(r.details, nil)
+		mainPagesGlobal.SwitchToPage("run_graph")
+		app.SetFocus(runGraphView)
+		startRunGraphRefresh(app, apiClient, orgId)
+	})
+	dashboardTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			closeView()
+			return nil
+		case 'R':
+			startDashboardRefresh(app, apiClient, orgId)
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	fillDashboardTable(dashboardTable, nil)
+	dashboardTable.SetCell(1, 0, tview.NewTableCell("Loading...").SetTextColor(tcell.ColorGray).SetSelectable(false))
+	isDashboardActive = true
+	mainPagesGlobal.AddPage("dashboard", page, true, false)
+	mainPagesGlobal.SwitchToPage("dashboard")
+	app.SetFocus(dashboardTable)
+	startDashboardRefresh(app, apiClient, orgId)
+}
+
+// startDashboardRefresh reloads the active runs now and then every
+// dashboardInterval until the dashboard is closed or left for a run
+func startDashboardRefresh(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	stopDashboardRefresh()
+	ctx, cancel := context.WithCancel(context.Background())
+	dashboardCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(dashboardInterval)
+		defer ticker.Stop()
+		for {
+			rows, err := loadDashboard(ctx, apiClient, orgId)
+			if ctx.Err() != nil {
+				return
+			}
+			app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || dashboardTable == nil {
+					return
+				}
+				if err == nil {
+					fillDashboardTable(dashboardTable, rows)
+					dashboardRows = rows
+				}
+				updateDashboardHeader(err)
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopDashboardRefresh stops the live refresh of the dashboard
+func stopDashboardRefresh() {
+	if dashboardCancel != nil {
+		dashboardCancel()
+		dashboardCancel = nil
+	}
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+func TestLoadDashboard(t *testing.T) {
+	service, c := fake.NewWithClock()
+	now := c.Now()
+	stages := []fake.StageSpec{
+		{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: time.Minute}, {Name: "test", Duration: time.Minute}}},
+		{Name: "Deploy", Jobs: []fake.JobSpec{{Name: "deploy", Duration: 10 * time.Minute}}},
+	}
+	building := service.AddPipeline("building", stages, nil)
+	deploying := service.AddPipeline("deploying", stages, nil)
+	done := service.AddPipeline("done", stages, nil)
+	service.AddPipeline("never-run", stages, nil)
+	service.AddRun(building.PipelineID, now.Add(-30*time.Second), fake.RunOptions{TriggerMode: "PUSH"})
+	service.AddRun(deploying.PipelineID, now.Add(-5*time.Minute), fake.RunOptions{})
+	service.AddRun(done.PipelineID, now.Add(-time.Hour), fake.RunOptions{})
+
+	rows, err := loadDashboard(context.Background(), service, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d active runs, want 2", len(rows))
+	}
+	// Oldest first
+	if rows[0].pipeline.Name != "deploying" || rows[1].pipeline.Name != "building" || rows[1].run.TriggerMode != "PUSH" {
+		t.Errorf("rows = %s (%s), %s", rows[0].pipeline.Name, rows[1].run.TriggerMode, rows[1].pipeline.Name)
+	}
+	if stage := currentStage(rows[0].details); stage != "Deploy › deploy" {
+		t.Errorf("current stage = %q", stage)
+	}
+	if d, total := runProgress(rows[0].details); d != 2 || total != 3 {
+		t.Errorf("progress = %d/%d", d, total)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	if bar := progressBar(3, 10, 10); bar != "███░░░░░░░ 3/10" {
+		t.Errorf("bar = %q", bar)
+	}
+	if bar := progressBar(0, 0, 10); bar != "-" {
+		t.Errorf("bar without jobs = %q", bar)
+	}
+	if stage := currentStage(&api.PipelineRunDetails{Stages: []api.Stage{{Name: "Build", Jobs: []api.Job{{Name: "build", Status: "SUCCESS"}}}}}); stage != "-" {
+		t.Errorf("stage of a finished run = %q", stage)
+	}
+	if !strings.HasPrefix(progressBar(1, 3, 10), "███░") {
+		t.Errorf("bar of 1/3 = %q", progressBar(1, 3, 10))
+	}
+}
@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var (
+	// State of the deployment view of a VM deploy job
+	isDeployViewActive bool
+	deployTable        *tview.Table
+	deployHeader       *tview.TextView
+	deployLogView      *tview.TextView
+	deployCancel       context.CancelFunc // Stops the live refresh of the deploy order
+	deployJob          api.Job            // The deploy job shown
+	deployOrderID      string             // ID of the VM deploy order the job started
+	deployMachines     []api.VMDeployMachine
+	deployLogCancel    context.CancelFunc // Stops loading the machine log shown
+	deployLogMachine   string             // Serial number of the machine whose log is shown
+)
+
+// deployMachineRows returns the machines of a deploy order in table order: by
+// batch, then by IP
+func deployMachineRows(order *api.VMDeployOrder) []api.VMDeployMachine {
+	machines := append([]api.VMDeployMachine(nil), order.DeployMachineInfo.DeployMachines...)
+	sort.SliceStable(machines, func(i, j int) bool {
+		if machines[i].BatchNum != machines[j].BatchNum {
+			return machines[i].BatchNum < machines[j].BatchNum
+		}
+		return machines[i].IP < machines[j].IP
+	})
+	return machines
+}
+
+// deploySummary counts the machines of a deploy order by status, e.g.
+// "2 SUCCESS, 1 FAILED, 3 WAITING"
+func deploySummary(order *api.VMDeployOrder) string {
+	counts := make(map[string]int)
+	var statuses []string
+	for _, m := range deployMachineRows(order) {
+		if counts[m.Status] == 0 {
+			statuses = append(statuses, m.Status)
+		}
+		counts[m.Status]++
+	}
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("[%s]%d %s[-]", machineStatusColor(status).String(), counts[status], status)
+	}
+	if len(parts) == 0 {
+		return "no machines"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// machineStatusColor colors the status of a deployment or of one of its
+// machines; failures stand out in red
+func machineStatusColor(status string) tcell.Color {
+	switch strings.ToUpper(status) {
+	case "PAUSE", "WAITING":
+		return tcell.ColorYellow
+	case "ROLLBACK":
+		return tcell.ColorGray
+	}
+	return getStatusColor(status)
+}
+
+// isDeployFinished reports whether a deploy order is final, so that it no
+// longer needs refreshing
+func isDeployFinished(status string) bool {
+	switch strings.ToUpper(status) {
+	case "SUCCESS", "FAILED", "CANCELED", "ROLLBACK":
+		return true
+	}
+	return false
+}
+
+// fillDeployTable shows the machines of a deploy order, a row per machine,
+// keeping the selected row
+func fillDeployTable(table *tview.Table, order *api.VMDeployOrder) {
+	row, _ := table.GetSelection()
+	table.Clear()
+	for col, title := range []string{"Batch", "IP", "Status", "Client Status", "Updated"} {
+		table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+
+	deployMachines = deployMachineRows(order)
+	for i, m := range deployMachines {
+		batch := fmt.Sprintf("  %d", m.BatchNum)
+		if m.BatchNum == order.CurrentBatch && !isDeployFinished(order.Status) {
+			batch = fmt.Sprintf("▶ %d", m.BatchNum) // The batch being deployed
+		}
+		updated := "-"
+		if m.UpdateTime > 0 {
+			updated = time.UnixMilli(m.UpdateTime).Format("15:04:05")
+		}
+		cells := []string{batch, m.IP, m.Status, m.ClientStatus, updated}
+		for col, text := range cells {
+			cell := tview.NewTableCell(tview.Escape(text)).SetExpansion(1)
+			if col == 2 {
+				cell.SetTextColor(machineStatusColor(m.Status))
+			}
+			table.SetCell(i+1, col, cell)
+		}
+	}
+
+	if row < 1 {
+		row = 1
+	}
+	if row > len(deployMachines) {
+		row = len(deployMachines)
+	}
+	table.Select(row, 0)
+}
+
+// updateDeployHeader shows the deploy order and how far it has got
+func updateDeployHeader(order *api.VMDeployOrder, err error) {
+	if deployHeader == nil {
+		return
+	}
+	text := fmt.Sprintf("Job: %s | Deploy order #%s", tview.Escape(deployJob.Name), deployOrderID)
+	if order != nil {
+		text += fmt.Sprintf(" | Status: [%s]%s[-] | Batch %d/%d | Machines: %s",
+			machineStatusColor(order.Status).String(), order.Status, order.CurrentBatch, order.TotalBatch, deploySummary(order))
+	}
+	text += " | Updated: " + time.Now().Format("15:04:05")
+	if err != nil {
+		text += fmt.Sprintf(" | [red]%s[-]", tview.Escape(describeError(err)))
+	}
+	deployHeader.SetText(text)
+}
+
+// showDeployView opens the deployment of a VM deploy job of the current run:
+// its batches and a table of its machines, refreshed while it deploys, with
+// the log of each machine a key away
+func showDeployView(app *tview.Application, apiClient api.PipelineService, orgId string, job api.Job) {
+	id, err := api.DeployOrderID(job)
+	if err != nil {
+		ShowModal("Not a Deployment",
+			fmt.Sprintf("Job %s is not a VM deployment, or has not started deploying yet.\nCurrent status: %s", job.Name, job.Status),
+			[]string{"OK"}, nil)
+		return
+	}
+	deployJob, deployOrderID, deployMachines = job, id, nil
+
+	deployHeader = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignLeft)
+	deployHeader.SetBackgroundColor(tcell.ColorDefault)
+	deployHeader.SetText(fmt.Sprintf("Job: %s | Deploy order #%s | Loading...", tview.Escape(job.Name), id))
+
+	deployTable = tview.NewTable().SetBorders(false).SetFixed(1, 0).SetSelectable(true, false)
+	deployTable.SetBorder(true).SetTitle("Machines").SetBackgroundColor(tcell.ColorDefault)
+	deployTable.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+
+	deployLogView = tview.NewTextView().SetDynamicColors(false).SetScrollable(true).SetWrap(true)
+	deployLogView.SetBorder(true).SetBackgroundColor(tcell.ColorDefault)
+
+	help := tview.NewTextView().
+		SetText("Keys: j/k=move, Enter=machine log, R=refresh, q=back to stages, Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	help.SetBackgroundColor(tcell.ColorDefault)
+
+	page := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(deployHeader, 1, 1, false).
+		AddItem(deployTable, 0, 1, true).
+		AddItem(help, 1, 1, false)
+
+	logHelp := tview.NewTextView().
+		SetText("Keys: j/k=scroll, r=refresh, q=back to machines, Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	logHelp.SetBackgroundColor(tcell.ColorDefault)
+	logPage := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(deployLogView, 0, 1, true).
+		AddItem(logHelp, 1, 1, false)
+
+	closeView := func() {
+		isDeployViewActive = false
+		stopDeployRefresh()
+		stopMachineLog()
+		mainPagesGlobal.RemovePage("deploy_machine_log")
+		mainPagesGlobal.RemovePage("deploy")
+		mainPagesGlobal.SwitchToPage("run_graph")
+		app.SetFocus(runGraphView)
+		startRunGraphRefresh(app, apiClient, orgId)
+	}
+	deployTable.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(deployMachines) {
+			return
+		}
+		machine := deployMachines[row-1]
+		mainPagesGlobal.SwitchToPage("deploy_machine_log")
+		app.SetFocus(deployLogView)
+		loadMachineLog(app, apiClient, orgId, machine)
+	})
+	deployTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			closeView()
+			return nil
+		case 'R':
+			startDeployRefresh(app, apiClient, orgId)
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			closeView()
+			return nil
+		}
+		return event
+	})
+	backToMachines := func() {
+		stopMachineLog()
+		mainPagesGlobal.SwitchToPage("deploy")
+		app.SetFocus(deployTable)
+	}
+	deployLogView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			backToMachines()
+			return nil
+		case 'r':
+			if row, _ := deployTable.GetSelection(); row >= 1 && row <= len(deployMachines) {
+				loadMachineLog(app, apiClient, orgId, deployMachines[row-1])
+			}
+			return nil
+		}
+		if event.Key() == tcell.KeyEscape {
+			backToMachines()
+			return nil
+		}
+		return event
+	})
+
+	stopRunGraphRefresh()
+	isDeployViewActive = true
+	mainPagesGlobal.AddPage("deploy_machine_log", logPage, true, false)
+	mainPagesGlobal.AddPage("deploy", page, true, false)
+	mainPagesGlobal.SwitchToPage("deploy")
+	app.SetFocus(deployTable)
+	startDeployRefresh(app, apiClient, orgId)
+}
+
+// loadMachineLog shows the deployment log of a machine of the deploy order.
+// A log still loading for another machine is dropped.
+func loadMachineLog(app *tview.Application, apiClient api.PipelineService, orgId string, machine api.VMDeployMachine) {
+	stopMachineLog()
+	ctx, cancel := context.WithCancel(context.Background())
+	deployLogCancel = cancel
+	pipelineID, id := currentPipelineIDForRun, deployOrderID
+	deployLogMachine = machine.MachineSn
+	deployLogView.SetTitle(fmt.Sprintf("Machine %s (%s)", machine.IP, machine.Status))
+	deployLogView.SetText(fmt.Sprintf("Fetching the deployment log of %s...", machine.IP))
+
+	go func() {
+		log, err := apiClient.GetVMDeployMachineLogContext(ctx, orgId, pipelineID, id, machine.MachineSn)
+		if ctx.Err() != nil {
+			return
+		}
+		app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || deployLogView == nil || id != deployOrderID || machine.MachineSn != deployLogMachine {
+				return
+			}
+			if err != nil {
+				deployLogView.SetText(fmt.Sprintf("Failed to fetch the deployment log of %s: %s", machine.IP, describeError(err)))
+				return
+			}
+			text := log.DeployLog
+			if text == "" {
+				text = "(no log yet)"
+			}
+			if log.DeployBeginTime != "" {
+				text = fmt.Sprintf("Started: %s  Ended: %s\n\n%s", log.DeployBeginTime, valueOr(log.DeployEndTime, "-"), text)
+			}
+			deployLogView.SetText(text)
+			deployLogView.ScrollToEnd()
+		})
+	}()
+}
+
+// stopMachineLog stops loading the machine log shown
+func stopMachineLog() {
+	if deployLogCancel != nil {
+		deployLogCancel()
+		deployLogCancel = nil
+	}
+	deployLogMachine = ""
+}
+
+// valueOr returns s, or fallback if s is empty
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// startDeployRefresh loads the deploy order into the deployment view and keeps
+// reloading it every 5 seconds until the deployment finishes or the view is
+// closed
+func startDeployRefresh(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	stopDeployRefresh()
+	ctx, cancel := context.WithCancel(context.Background())
+	deployCancel = cancel
+	pipelineID, id := currentPipelineIDForRun, deployOrderID
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			order, err := apiClient.GetVMDeployOrderContext(ctx, orgId, pipelineID, id)
+			if ctx.Err() != nil {
+				return
+			}
+			app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || deployTable == nil {
+					return
+				}
+				if err == nil {
+					fillDeployTable(deployTable, order)
+				}
+				updateDeployHeader(order, err)
+			})
+			if err == nil && isDeployFinished(order.Status) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopDeployRefresh stops the live refresh of the deployment view
+func stopDeployRefresh() {
+	if deployCancel != nil {
+		deployCancel()
+		deployCancel = nil
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"aliyun-pipelines-tui/internal/api"
+
+	"github.com/rivo/tview"
+)
+
+func testDeployOrder() *api.VMDeployOrder {
+	return &api.VMDeployOrder{
+		DeployOrderId: 7,
+		Status:        "RUNNING",
+		CurrentBatch:  2,
+		TotalBatch:    2,
+		DeployMachineInfo: api.VMDeployMachineInfo{DeployMachines: []api.VMDeployMachine{
+			{IP: "10.0.0.4", MachineSn: "sn-4", Status: "FAILED", ClientStatus: "ONLINE", BatchNum: 2},
+			{IP: "10.0.0.2", MachineSn: "sn-2", Status: "SUCCESS", ClientStatus: "ONLINE", BatchNum: 1},
+			{IP: "10.0.0.3", MachineSn: "sn-3", Status: "RUNNING", ClientStatus: "ONLINE", BatchNum: 2},
+			{IP: "10.0.0.1", MachineSn: "sn-1", Status: "SUCCESS", ClientStatus: "OFFLINE", BatchNum: 1},
+		}},
+	}
+}
+
+func TestFillDeployTable(t *testing.T) {
+	table := tview.NewTable().SetSelectable(true, false)
+	fillDeployTable(table, testDeployOrder())
+
+	var rows []string
+	for row := 1; row < table.GetRowCount(); row++ {
+		var cells []string
+		for col := 0; col < table.GetColumnCount(); col++ {
+			cells = append(cells, strings.TrimSpace(table.GetCell(row, col).Text))
+		}
+		rows = append(rows, strings.Join(cells[:4], " "))
+	}
+	want := []string{
+		"1 10.0.0.1 SUCCESS OFFLINE",
+		"1 10.0.0.2 SUCCESS ONLINE",
+		"▶ 2 10.0.0.3 RUNNING ONLINE",
+		"▶ 2 10.0.0.4 FAILED ONLINE",
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("machine rows:\n%s\nwant:\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"))
+	}
+	if row, _ := table.GetSelection(); row != 1 {
+		t.Errorf("selected row = %d, want the first machine", row)
+	}
+}
+
+func TestDeploySummary(t *testing.T) {
+	got := deploySummary(testDeployOrder())
+	for _, want := range []string{"2 SUCCESS", "1 RUNNING", "1 FAILED"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary %q lacks %q", got, want)
+		}
+	}
+	if isDeployFinished("PAUSE") || !isDeployFinished("ROLLBACK") {
+		t.Error("a paused deployment is still going, a rolled back one is over")
+	}
+}
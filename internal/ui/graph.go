@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Layout of the run graph: stages are columns of job boxes
+const (
+	graphColumnWidth = 26 // Width of a stage column, including its job boxes
+	graphColumnGap   = 4  // Space between columns, where the arrow is drawn
+	graphBoxHeight   = 4  // Height of a job box: border, name, status line, border
+)
+
+var (
+	// State of the run graph view
+	isRunGraphActive bool
+	runGraphView     *runGraph
+	runGraphHeader   *tview.TextView
+	runGraphCancel   context.CancelFunc // Stops the live refresh of the graph
+	runGraphBack     func()             // Returns to the view the graph was opened from, if not the run history
+)
+
+// runGraph draws the stages of a pipeline run as columns of job boxes colored
+// by status, like the Yunxiao web UI, and lets the user select a job
+type runGraph struct {
+	*tview.Box
+
+	details  *api.PipelineRunDetails
+	stage    int // Selected stage
+	job      int // Selected job in the selected stage
+	offset   int // First stage drawn, to keep the selection on screen
+	selected func(stage api.Stage, job api.Job)
+	changed  func(stage api.Stage, job api.Job)
+}
+
+// newRunGraph returns an empty run graph
+func newRunGraph() *runGraph {
+	return &runGraph{Box: tview.NewBox()}
+}
+
+// SetDetails replaces the run shown, keeping the selected job where possible
+func (g *runGraph) SetDetails(details *api.PipelineRunDetails) {
+	g.details = details
+	g.clampSelection()
+}
+
+// SetSelectedFunc sets the function called when Enter is pressed on a job
+func (g *runGraph) SetSelectedFunc(handler func(stage api.Stage, job api.Job)) {
+	g.selected = handler
+}
+
+// SetChangedFunc sets the function called when the selection moves to
+// another job
+func (g *runGraph) SetChangedFunc(handler func(stage api.Stage, job api.Job)) {
+	g.changed = handler
+}
+
+// Selection returns the selected stage and job, if any
+func (g *runGraph) Selection() (api.Stage, api.Job, bool) {
+	if g.details == nil || g.stage >= len(g.details.Stages) {
+		return api.Stage{}, api.Job{}, false
+	}
+	stage := g.details.Stages[g.stage]
+	if g.job >= len(stage.Jobs) {
+		return stage, api.Job{}, false
+	}
+	return stage, stage.Jobs[g.job], true
+}
+
+func (g *runGraph) clampSelection() {
+	if g.details == nil || len(g.details.Stages) == 0 {
+		g.stage, g.job = 0, 0
+		return
+	}
+	if g.stage >= len(g.details.Stages) {
+		g.stage = len(g.details.Stages) - 1
+	}
+	if jobs := len(g.details.Stages[g.stage].Jobs); g.job >= jobs {
+		g.job = jobs - 1
+	}
+	if g.job < 0 {
+		g.job = 0
+	}
+}
+
+// Draw draws the stage columns, the arrows between them and the job boxes
+func (g *runGraph) Draw(screen tcell.Screen) {
+	g.Box.DrawForSubclass(screen, g)
+	x, y, width, height := g.GetInnerRect()
+	if g.details == nil {
+		tview.Print(screen, "Loading run details...", x+1, y, width-1, tview.AlignLeft, tcell.ColorGray)
+		return
+	}
+	if len(g.details.Stages) == 0 {
+		tview.Print(screen, "No stages found in this pipeline run.", x+1, y, width-1, tview.AlignLeft, tcell.ColorGray)
+		return
+	}
+
+	// Scroll horizontally so that the selected stage is visible
+	visible := (width - 1 + graphColumnGap) / (graphColumnWidth + graphColumnGap)
+	if visible < 1 {
+		visible = 1
+	}
+	if g.stage < g.offset {
+		g.offset = g.stage
+	} else if g.stage >= g.offset+visible {
+		g.offset = g.stage - visible + 1
+	}
+
+	for i := g.offset; i < len(g.details.Stages) && i < g.offset+visible; i++ {
+		stage := g.details.Stages[i]
+		left := x + 1 + (i-g.offset)*(graphColumnWidth+graphColumnGap)
+
+		// Stage header, colored by the status derived from its jobs
+		status := runwatch.StageStatus(stage)
+		tview.Print(screen, truncate(stage.Name, graphColumnWidth), left, y, graphColumnWidth, tview.AlignLeft, getStatusColor(status))
+		tview.Print(screen, status, left, y+1, graphColumnWidth, tview.AlignLeft, tcell.ColorGray)
+		if i < len(g.details.Stages)-1 {
+			tview.Print(screen, " ─▶", left+graphColumnWidth, y, graphColumnGap, tview.AlignLeft, tcell.ColorGray)
+		}
+
+		for j, job := range stage.Jobs {
+			top := y + 2 + j*graphBoxHeight
+			if top+graphBoxHeight > y+height {
+				tview.Print(screen, fmt.Sprintf("… %d more", len(stage.Jobs)-j), left, y+height-1, graphColumnWidth, tview.AlignLeft, tcell.ColorGray)
+				break
+			}
+			g.drawJob(screen, job, left, top, i == g.stage && j == g.job)
+		}
+	}
+
+	if g.offset > 0 {
+		tview.Print(screen, "◀", x, y, 1, tview.AlignLeft, tcell.ColorYellow)
+	}
+	if g.offset+visible < len(g.details.Stages) {
+		tview.Print(screen, "▶", x+width-1, y, 1, tview.AlignLeft, tcell.ColorYellow)
+	}
+}
+
+// drawJob draws the box of one job: its name, status and duration
+func (g *runGraph) drawJob(screen tcell.Screen, job api.Job, left, top int, selected bool) {
+	color := getStatusColor(job.Status)
+	style := tcell.StyleDefault.Foreground(color)
+	horizontal, vertical := tview.BoxDrawingsLightHorizontal, tview.BoxDrawingsLightVertical
+	corners := [4]rune{tview.BoxDrawingsLightDownAndRight, tview.BoxDrawingsLightDownAndLeft, tview.BoxDrawingsLightUpAndRight, tview.BoxDrawingsLightUpAndLeft}
+	if selected {
+		style = style.Bold(true)
+		horizontal, vertical = tview.BoxDrawingsDoubleHorizontal, tview.BoxDrawingsDoubleVertical
+		corners = [4]rune{tview.BoxDrawingsDoubleDownAndRight, tview.BoxDrawingsDoubleDownAndLeft, tview.BoxDrawingsDoubleUpAndRight, tview.BoxDrawingsDoubleUpAndLeft}
+	}
+
+	right, bottom := left+graphColumnWidth-1, top+graphBoxHeight-2
+	for cx := left + 1; cx < right; cx++ {
+		screen.SetContent(cx, top, horizontal, nil, style)
+		screen.SetContent(cx, bottom, horizontal, nil, style)
+	}
+	for cy := top + 1; cy < bottom; cy++ {
+		screen.SetContent(left, cy, vertical, nil, style)
+		screen.SetContent(right, cy, vertical, nil, style)
+	}
+	screen.SetContent(left, top, corners[0], nil, style)
+	screen.SetContent(right, top, corners[1], nil, style)
+	screen.SetContent(left, bottom, corners[2], nil, style)
+	screen.SetContent(right, bottom, corners[3], nil, style)
+
+	inner := graphColumnWidth - 4
+	nameColor := tcell.ColorWhite
+	if selected {
+		nameColor = tcell.ColorYellow
+	}
+	tview.Print(screen, truncate(job.Name, inner), left+2, top+1, inner, tview.AlignLeft, nameColor)
+	statusLine := job.Status
+	if _, ok := job.ValidationGate(); ok {
+		// A manual validation gate: 'a' approves or rejects it
+		statusLine, color = "⏸ awaiting approval", tcell.ColorYellow
+	} else if duration := jobDuration(job); duration != "" {
+		statusLine += " " + duration
+	}
+	tview.Print(screen, truncate(statusLine, inner), left+2, top+2, inner, tview.AlignLeft, color)
+}
+
+// InputHandler moves the selection with h/j/k/l or the arrow keys and selects
+// a job with Enter
+func (g *runGraph) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return g.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if g.details == nil || len(g.details.Stages) == 0 {
+			return
+		}
+		stageBefore, jobBefore := g.stage, g.job
+		switch {
+		case event.Key() == tcell.KeyLeft || event.Rune() == 'h':
+			if g.stage > 0 {
+				g.stage--
+			}
+		case event.Key() == tcell.KeyRight || event.Rune() == 'l':
+			if g.stage < len(g.details.Stages)-1 {
+				g.stage++
+			}
+		case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+			if g.job > 0 {
+				g.job--
+			}
+		case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+			g.job++
+		case event.Key() == tcell.KeyEnter:
+			if stage, job, ok := g.Selection(); ok && g.selected != nil {
+				g.selected(stage, job)
+			}
+		}
+		g.clampSelection()
+		if g.changed != nil && (g.stage != stageBefore || g.job != jobBefore) {
+			if stage, job, ok := g.Selection(); ok {
+				g.changed(stage, job)
+			}
+		}
+	})
+}
+
+// jobDuration formats how long a job ran, or has been running
+func jobDuration(job api.Job) string {
+	return elapsed(job.StartTime, job.EndTime)
+}
+
+// elapsed formats the time from start to end, or to now if end is not set yet
+func elapsed(start, end time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
+
+// truncate shortens text to width characters, marking the cut with an ellipsis
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// updateRunGraphHeader shows the run the graph is for and when it was updated
+func updateRunGraphHeader(details *api.PipelineRunDetails, err error) {
+	if runGraphHeader == nil {
+		return
+	}
+	status := currentRunStatus
+	if details != nil {
+		status = details.Status
+	}
+	text := fmt.Sprintf("Pipeline: %s | Run #%s | Status: [%s]%s[-] | Updated: %s",
+		tview.Escape(currentPipelineName), currentRunID, getStatusColor(status).String(), status, time.Now().Format("15:04:05"))
+	if err != nil {
+		text += fmt.Sprintf(" | [red]%s[-]", tview.Escape(describeError(err)))
+	}
+	runGraphHeader.SetText(text)
+}
+
+// startRunGraphRefresh loads the details of the current run into the graph and
+// keeps reloading them every 5 seconds until the run finishes or the graph is
+// closed
+func startRunGraphRefresh(app *tview.Application, apiClient api.PipelineService, orgId string) {
+	stopRunGraphRefresh()
+	ctx, cancel := context.WithCancel(context.Background())
+	runGraphCancel = cancel
+	pipelineID, runID := currentPipelineIDForRun, currentRunID
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			details, err := apiClient.GetPipelineRunDetailsContext(ctx, orgId, pipelineID, runID)
+			if ctx.Err() != nil {
+				return
+			}
+			app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || runGraphView == nil {
+					return
+				}
+				if err == nil {
+					runGraphView.SetDetails(details)
+				}
+				updateRunGraphHeader(details, err)
+			})
+			if err == nil && runwatch.IsFinished(details.Status) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopRunGraphRefresh stops the live refresh of the run graph
+func stopRunGraphRefresh() {
+	if runGraphCancel != nil {
+		runGraphCancel()
+		runGraphCancel = nil
+	}
+}
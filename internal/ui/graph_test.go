@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func testRunDetails() *api.PipelineRunDetails {
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	return &api.PipelineRunDetails{
+		Status: "RUNNING",
+		Stages: []api.Stage{
+			{Name: "Build", Jobs: []api.Job{
+				{ID: 1, Name: "compile", Status: "SUCCESS", StartTime: start, EndTime: start.Add(90 * time.Second)},
+				{ID: 2, Name: "unit tests", Status: "FAILED", StartTime: start, EndTime: start.Add(time.Minute)},
+			}},
+			{Name: "Deploy", Jobs: []api.Job{
+				{ID: 3, Name: "deploy", Status: "INIT"},
+			}},
+		},
+	}
+}
+
+// screenText returns the simulated screen's content, one string per row
+func screenText(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	rows := make([]string, height)
+	for y := 0; y < height; y++ {
+		var row strings.Builder
+		for x := 0; x < width; x++ {
+			if runes := cells[y*width+x].Runes; len(runes) > 0 {
+				row.WriteRune(runes[0])
+			} else {
+				row.WriteRune(' ')
+			}
+		}
+		rows[y] = row.String()
+	}
+	return rows
+}
+
+func TestRunGraphDraw(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 20)
+
+	graph := newRunGraph()
+	graph.SetRect(0, 0, 80, 20)
+	details := testRunDetails()
+	details.Stages[1].Jobs = append(details.Stages[1].Jobs, api.Job{ID: 4, Name: "approval", Status: "WAITING",
+		Actions: []api.JobAction{{Type: "PassPipelineValidate", Title: "Ship it?"}}})
+	graph.SetDetails(details)
+	graph.Draw(screen)
+	screen.Show()
+
+	text := strings.Join(screenText(screen), "\n")
+	for _, want := range []string{"Build", "Deploy", "compile", "unit tests", "SUCCESS 1m30s", "FAILED 1m0s", "INIT", "─▶", "awaiting approval"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("graph lacks %q:\n%s", want, text)
+		}
+	}
+	// The selected job's box is drawn with double lines
+	if !strings.Contains(text, "╔") {
+		t.Errorf("no job is drawn as selected:\n%s", text)
+	}
+}
+
+func TestRunGraphNavigation(t *testing.T) {
+	graph := newRunGraph()
+	graph.SetDetails(testRunDetails())
+	var opened []string
+	graph.SetSelectedFunc(func(stage api.Stage, job api.Job) {
+		opened = append(opened, stage.Name+"/"+job.Name)
+	})
+
+	press := func(keys ...interface{}) {
+		for _, key := range keys {
+			var event *tcell.EventKey
+			switch k := key.(type) {
+			case rune:
+				event = tcell.NewEventKey(tcell.KeyRune, k, tcell.ModNone)
+			case tcell.Key:
+				event = tcell.NewEventKey(k, 0, tcell.ModNone)
+			}
+			graph.InputHandler()(event, func(p tview.Primitive) {})
+		}
+	}
+
+	press('j', 'j', tcell.KeyEnter) // Stays on the last job of the stage
+	press('l', tcell.KeyEnter)      // The deploy stage has one job
+	press('h', 'k', tcell.KeyEnter)
+	want := []string{"Build/unit tests", "Deploy/deploy", "Build/compile"}
+	if strings.Join(opened, ",") != strings.Join(want, ",") {
+		t.Errorf("opened %v, want %v", opened, want)
+	}
+
+	// A refresh with fewer stages keeps the selection valid
+	graph.SetDetails(&api.PipelineRunDetails{Stages: []api.Stage{{Name: "Only"}}})
+	if _, _, ok := graph.Selection(); ok {
+		t.Error("expected no job selected in a stage without jobs")
+	}
+}
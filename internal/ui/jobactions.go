@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// jobAction is an action on a single job of a run, such as retrying it
+type jobAction struct {
+	title    string   // Title word of the dialogs, e.g. "Retry"
+	verb     string   // What the action does to the job, e.g. "retried"
+	statuses []string // Job statuses the action applies to
+	perform  func(apiClient api.PipelineService, orgId, pipelineId, runId, jobId string) error
+}
+
+// jobActions are the actions on the selected job of the run graph, by key
+var jobActions = map[rune]jobAction{
+	'r': {
+		title:    "Retry",
+		verb:     "retried",
+		statuses: []string{"FAILED", "CANCELED"},
+		perform: func(apiClient api.PipelineService, orgId, pipelineId, runId, jobId string) error {
+			return apiClient.RetryPipelineJobRun(orgId, pipelineId, runId, jobId)
+		},
+	},
+	's': {
+		title:    "Skip",
+		verb:     "skipped",
+		statuses: []string{"FAILED", "CANCELED"},
+		perform: func(apiClient api.PipelineService, orgId, pipelineId, runId, jobId string) error {
+			return apiClient.SkipPipelineJobRun(orgId, pipelineId, runId, jobId)
+		},
+	},
+	'x': {
+		title:    "Stop",
+		verb:     "stopped",
+		statuses: []string{"RUNNING"},
+		perform: func(apiClient api.PipelineService, orgId, pipelineId, runId, jobId string) error {
+			return apiClient.StopPipelineJobRun(orgId, pipelineId, runId, jobId)
+		},
+	},
+}
+
+// appliesTo reports whether the action can be taken on a job with the status
+func (a jobAction) appliesTo(status string) bool {
+	for _, s := range a.statuses {
+		if strings.EqualFold(s, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmJobAction asks for confirmation, then takes the action on the job of
+// the current run. done is called once the action has been taken.
+func confirmJobAction(app *tview.Application, apiClient api.PipelineService, orgId string, action jobAction, stage api.Stage, job api.Job, done func()) {
+	if !action.appliesTo(job.Status) {
+		ShowModal("Cannot "+action.title,
+			fmt.Sprintf("Job %s cannot be %s.\nCurrent status: %s\n\nOnly jobs with status %s can be %s.",
+				job.Name, action.verb, job.Status, strings.Join(action.statuses, " or "), action.verb),
+			[]string{"OK"}, nil)
+		return
+	}
+
+	pipelineID, runID := currentPipelineIDForRun, currentRunID
+	ShowModal("Confirm "+action.title,
+		fmt.Sprintf("Are you sure you want to %s job %s?\nStage: %s\nStatus: %s", strings.ToLower(action.title), job.Name, stage.Name, job.Status),
+		[]string{"Yes", "No"},
+		func(buttonIndex int, buttonLabel string) {
+			if buttonIndex != 0 { // No
+				return
+			}
+			go func() {
+				err := action.perform(apiClient, orgId, pipelineID, runID, strconv.FormatInt(job.ID, 10))
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						ShowModal("Error", fmt.Sprintf("Failed to %s job %s: %s", strings.ToLower(action.title), job.Name, describeError(err)), []string{"OK"}, nil)
+						return
+					}
+					ShowModal("Success", fmt.Sprintf("Sent %s for job %s.", strings.ToLower(action.title), job.Name), []string{"OK"}, func(buttonIndex int, buttonLabel string) {
+						if done != nil {
+							done()
+						}
+					})
+				})
+			}()
+		})
+}
+
+// menuActions returns the actions the job offers that can be executed from
+// its actions menu: those not disabled and not merely links to other views
+func menuActions(job api.Job) []api.JobAction {
+	var actions []api.JobAction
+	for _, action := range job.Actions {
+		if action.Disable || action.Type == "" || strings.EqualFold(action.DisplayType, "LINK") {
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// actionLabel names an action in the actions menu, e.g. "继续部署 - Deploy the next batch"
+func actionLabel(action api.JobAction) string {
+	name := action.Name
+	if name == "" {
+		name = action.Type
+	}
+	if action.Title != "" && action.Title != name {
+		name += " - " + action.Title
+	}
+	return name
+}
+
+// showJobActionsMenu lists the actions the job of the current run offers, such
+// as resuming or rolling back a VM deployment, and executes the selected one
+// after confirmation. done is called once the action has been executed.
+func showJobActionsMenu(app *tview.Application, apiClient api.PipelineService, orgId string, stage api.Stage, job api.Job, done func()) {
+	actions := menuActions(job)
+	if len(actions) == 0 {
+		ShowModal("No Actions",
+			fmt.Sprintf("Job %s offers no actions right now.\nCurrent status: %s", job.Name, job.Status),
+			[]string{"OK"}, nil)
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf("Actions: %s", job.Name)).SetBackgroundColor(tcell.ColorDefault)
+	list.SetMainTextColor(tcell.ColorWhite)
+	list.SetSelectedBackgroundColor(tcell.ColorGray)
+	width := 40
+	for _, action := range actions {
+		action := action
+		label := actionLabel(action)
+		if w := tview.TaggedStringWidth(label) + 4; w > width {
+			width = w
+		}
+		list.AddItem(label, "", 0, func() {
+			HideModal()
+			switch action.Type {
+			case "PassPipelineValidate", "RefusePipelineValidate":
+				// A decision on a validation gate takes a comment
+				showValidationDialog(app, apiClient, orgId, job, done)
+			default:
+				confirmExecuteAction(app, apiClient, orgId, stage, job, action, done)
+			}
+		})
+	}
+	list.SetDoneFunc(HideModal)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case 'q':
+			HideModal()
+			return nil
+		}
+		return event
+	})
+
+	// Center the list over the stage graph
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(list, len(actions)+2, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	mainPagesGlobal.AddPage("modal", modal, true, true)
+	app.SetFocus(list)
+}
+
+// confirmExecuteAction asks for confirmation, then executes the action on the
+// job of the current run
+func confirmExecuteAction(app *tview.Application, apiClient api.PipelineService, orgId string, stage api.Stage, job api.Job, action api.JobAction, done func()) {
+	pipelineID, runID := currentPipelineIDForRun, currentRunID
+	label := actionLabel(action)
+	ShowModal("Confirm Action",
+		fmt.Sprintf("Are you sure you want to execute %s on job %s?\nStage: %s\nStatus: %s", label, job.Name, stage.Name, job.Status),
+		[]string{"Yes", "No"},
+		func(buttonIndex int, buttonLabel string) {
+			if buttonIndex != 0 { // No
+				return
+			}
+			go func() {
+				err := apiClient.ExecutePipelineJobAction(orgId, pipelineID, runID, strconv.FormatInt(job.ID, 10), action)
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						ShowModal("Error", fmt.Sprintf("Failed to execute %s on job %s: %s", label, job.Name, describeError(err)), []string{"OK"}, nil)
+						return
+					}
+					ShowModal("Success", fmt.Sprintf("Sent %s for job %s.", label, job.Name), []string{"OK"}, func(buttonIndex int, buttonLabel string) {
+						if done != nil {
+							done()
+						}
+					})
+				})
+			}()
+		})
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"strings"
+	"testing"
+)
+
+func TestJobActionsApply(t *testing.T) {
+	tests := []struct {
+		key    rune
+		status string
+		want   bool
+	}{
+		{'r', "FAILED", true},
+		{'r', "canceled", true},
+		{'r', "RUNNING", false},
+		{'s', "FAILED", true},
+		{'s', "SUCCESS", false},
+		{'x', "RUNNING", true},
+		{'x', "FAILED", false},
+	}
+	for _, tt := range tests {
+		if got := jobActions[tt.key].appliesTo(tt.status); got != tt.want {
+			t.Errorf("%q on a %s job: appliesTo = %v, want %v", tt.key, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestMenuActions(t *testing.T) {
+	job := api.Job{Actions: []api.JobAction{
+		{Type: "GetVMDeployOrder", DisplayType: "LINK", Name: "查看部署单"},
+		{Type: "ResumeVMDeployOrder", DisplayType: "BUTTON", Name: "继续部署", Title: "Deploy the next batch"},
+		{Type: "RollbackVMDeployOrder", DisplayType: "BUTTON", Name: "回滚", Disable: true},
+		{Type: "RetryVMDeployMachine", DisplayType: "BUTTON"},
+	}}
+	var labels []string
+	for _, action := range menuActions(job) {
+		labels = append(labels, actionLabel(action))
+	}
+	if got, want := strings.Join(labels, ", "), "继续部署 - Deploy the next batch, RetryVMDeployMachine"; got != want {
+		t.Errorf("menu = %q, want %q", got, want)
+	}
+}
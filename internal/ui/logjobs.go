@@ -0,0 +1,397 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// logJobSection is the log of one job of the run shown in the log view
+type logJobSection struct {
+	stage    api.Stage
+	job      api.Job
+	number   int    // Position of the job in the run, from 1
+	log      string // Log received so far
+	err      error  // Error of the last attempt to fetch the log
+	loaded   bool   // Whether the log has been fetched at least once
+	complete bool   // Whether the log was fetched after the job finished, so it cannot change
+	markup   bool   // Whether the log holds style tags rather than ANSI colors
+
+	steps       []logJobStep // Steps of the job, shown instead of the whole log when there are any
+	stepsLoaded bool         // Whether the steps have been fetched
+	noSteps     bool         // Whether the API does not know the job's steps, so its whole log is shown
+}
+
+// text returns the job's header and log as shown in the log view. The log
+// comes last, so that new lines only add to the end of the text.
+func (s logJobSection) text() string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[yellow]Job #%d: %s (ID: %d)[-]\n", s.number, s.job.Name, s.job.ID))
+	text.WriteString(fmt.Sprintf("[yellow]Job Sign: %s[-]\n", s.job.JobSign))
+	text.WriteString(fmt.Sprintf("[yellow]Status: %s[-]\n", s.job.Status))
+	if !s.job.StartTime.IsZero() {
+		text.WriteString(fmt.Sprintf("[yellow]Start Time: %s[-]\n", s.job.StartTime.Format("2006-01-02 15:04:05")))
+	}
+	if !s.job.EndTime.IsZero() {
+		text.WriteString(fmt.Sprintf("[yellow]End Time: %s[-]\n", s.job.EndTime.Format("2006-01-02 15:04:05")))
+	}
+	text.WriteString("[yellow]" + strings.Repeat("=", 50) + "[-]\n")
+
+	switch {
+	case len(s.steps) > 0:
+		for _, step := range s.steps {
+			text.WriteString(step.text())
+		}
+	case s.err != nil && s.log == "":
+		text.WriteString(fmt.Sprintf("Error fetching logs for job %d: %v\n", s.job.ID, s.err))
+	case s.log == "" && !runwatch.HasStarted(s.job.Status):
+		text.WriteString("Waiting for the job to start...\n")
+	case s.log == "":
+		text.WriteString("No logs available for this job.\n")
+	default:
+		text.WriteString(formatJobLog(s.log, s.markup))
+		if !strings.HasSuffix(s.log, "\n") {
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
+
+var (
+	// Job list sidebar of the log view
+	logJobTable         *tview.Table // Lists "All jobs" and every job of the run
+	logBody             *tview.Flex  // The job list and the log text side by side
+	logJobRowMap        = make(map[int]int64)
+	updatingLogJobTable bool // Set while the table is refilled, to ignore selection events
+
+	// Parts of the log view's text
+	logHeaderText  string               // Pipeline and run summary above the logs
+	logFooterText  string               // Progress or error message below the logs
+	logJobSections []logJobSection      // Every job of the run, in pipeline order
+	logJobChosen   bool                 // Whether the user chose the job shown, so failures are not auto-selected
+	logTail        = runwatch.NewTail() // How much of each job's log has been received
+
+	// Search across every job rather than only the one shown
+	logSearchAllJobs   bool
+	logSearchMatchJobs []int64 // Job of each of logSearchMatches when searching every job
+)
+
+// newLogJobTable creates the job list sidebar of the log view
+func newLogJobTable() *tview.Table {
+	table := tview.NewTable().SetBorders(false).SetSelectable(true, false)
+	table.SetBorder(true).SetTitle("Jobs").SetBackgroundColor(tcell.ColorDefault)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if updatingLogJobTable {
+			return
+		}
+		if jobID, ok := logJobRowMap[row]; ok {
+			logJobChosen = true
+			selectLogJob(jobID)
+		} else if index, ok := logStepRowMap[row]; ok {
+			scrollToLogStep(index)
+		}
+	})
+	return table
+}
+
+// resetLogJobs forgets the jobs of the previous run before a log view opens.
+// jobID is the job to show, or 0 to show every job unless one has failed.
+func resetLogJobs(jobID int64) {
+	currentLogJobID = jobID
+	logJobChosen = jobID != 0
+	logHeaderText = ""
+	logFooterText = ""
+	logUpdatedAt = time.Time{}
+	logJobSections = nil
+	logTail = runwatch.NewTail()
+	if logViewTextView != nil {
+		// A new log view follows the end of the log until the user scrolls up
+		logViewTextView.SetTitle("Logs")
+		logViewTextView.ScrollToEnd()
+	}
+	updateLogJobTable()
+}
+
+// findLogJobSection returns the section of the job, if the run has it
+func findLogJobSection(jobID int64) (logJobSection, bool) {
+	for _, section := range logJobSections {
+		if section.job.ID == jobID {
+			return section, true
+		}
+	}
+	return logJobSection{}, false
+}
+
+// firstFailedLogJob returns the first failed job of the run, or 0
+func firstFailedLogJob(sections []logJobSection) int64 {
+	for _, section := range sections {
+		if strings.ToUpper(section.job.Status) == "FAILED" {
+			return section.job.ID
+		}
+	}
+	return 0
+}
+
+// logJobFetch is what a round of loading logs needs to know of a job. It is
+// taken on the UI goroutine, so that the loader never reads the sections the
+// UI goroutine updates.
+type logJobFetch struct {
+	index    int // Of the job's section
+	job      api.Job
+	loaded   bool
+	complete bool
+}
+
+// logJobFetches returns the jobs of the sections in the order their logs are
+// loaded: the selected job first, then the others in run order
+func logJobFetches(sections []logJobSection, selectedJob int64) []logJobFetch {
+	fetches := make([]logJobFetch, 0, len(sections))
+	for i, section := range sections {
+		fetch := logJobFetch{index: i, job: section.job, loaded: section.loaded, complete: section.complete}
+		if section.job.ID == selectedJob {
+			fetches = append([]logJobFetch{fetch}, fetches...)
+		} else {
+			fetches = append(fetches, fetch)
+		}
+	}
+	return fetches
+}
+
+// logJobText returns the text of the log view for the job, or for every job
+// of the run that has started if jobID is 0. While the job shown, or the last
+// job started, is running, the text only grows at the end, so that
+// renderLogView can add the new lines instead of replacing the text.
+func logJobText(jobID int64) string {
+	var text strings.Builder
+	text.WriteString(logHeaderText)
+
+	if jobID != 0 {
+		if section, ok := findLogJobSection(jobID); ok {
+			text.WriteString(fmt.Sprintf("[yellow]Stage: %s (%s)[-]\n", section.stage.Name, section.stage.Index))
+			text.WriteString("-" + strings.Repeat("-", 60) + "\n\n")
+			if !section.loaded {
+				text.WriteString("Loading logs...\n")
+			} else {
+				text.WriteString(section.text())
+			}
+			return text.String()
+		}
+		// The job is not part of the run (yet): show the progress instead
+	}
+
+	lastStage := ""
+	shown := 0
+	for _, section := range logJobSections {
+		// Jobs waiting to start are listed in the job list only
+		if !section.loaded || !runwatch.HasStarted(section.job.Status) {
+			continue
+		}
+		if shown > 0 {
+			text.WriteString("\n" + strings.Repeat("=", 80) + "\n\n")
+		}
+		if shown == 0 || section.stage.Name != lastStage {
+			text.WriteString(fmt.Sprintf("[yellow]Stage: %s (%s)[-]\n", section.stage.Name, section.stage.Index))
+			text.WriteString("-" + strings.Repeat("-", 60) + "\n\n")
+			lastStage = section.stage.Name
+		}
+		text.WriteString(section.text())
+		shown++
+	}
+	text.WriteString(logFooterText)
+	return text.String()
+}
+
+// renderLogView shows the selected job's log, or every job's, and updates the
+// job list. When the text only has new lines at the end, they are added to
+// the view rather than replacing its text. The scroll position is kept, so
+// the view follows new lines only while it is scrolled to the end.
+func renderLogView() {
+	if logViewTextView == nil {
+		return
+	}
+	text := logJobText(currentLogJobID)
+	if logSearchActive && logSearchQuery != "" {
+		// The text changed, so the matches have to be found again, without
+		// jumping away from the match being looked at
+		current := logSearchCurrentIdx
+		logOriginalText = text
+		findLogSearchMatches(logSearchQuery)
+		if current >= len(logSearchMatches) {
+			current = len(logSearchMatches) - 1
+		}
+		logSearchCurrentIdx = current
+		logViewTextView.SetText(highlightedLogText(text, logSearchQuery))
+		updateLogSearchStatusBar()
+	} else if shown := logViewTextView.GetText(false); shown != "" && strings.HasPrefix(text, shown) {
+		fmt.Fprint(logViewTextView, text[len(shown):])
+	} else {
+		logViewTextView.SetText(text)
+	}
+	renderLogViewTitle()
+	updateLogJobTable()
+}
+
+// selectLogJob shows the log of the job, or of every job if jobID is 0. An
+// active search is repeated on the newly shown text.
+func selectLogJob(jobID int64) {
+	currentLogJobID = jobID
+	if logSearchActive && logSearchQuery != "" {
+		logOriginalText = logJobText(jobID)
+		performLogSearch(logSearchQuery, appGlobal)
+		renderLogViewTitle()
+		updateLogJobTable()
+		requestLogJobSteps()
+		return
+	}
+	renderLogView()
+	if logViewTextView != nil {
+		logViewTextView.ScrollToEnd()
+	}
+	requestLogJobSteps()
+}
+
+// renderLogViewTitle names the job shown in the title of the log view
+func renderLogViewTitle() {
+	title := "Logs"
+	if section, ok := findLogJobSection(currentLogJobID); ok {
+		title = fmt.Sprintf("Logs - %s / %s", section.stage.Name, section.job.Name)
+	}
+	logViewTextView.SetTitle(title)
+}
+
+// moveLogJob shows the next (delta 1) or previous (delta -1) job in the list
+func moveLogJob(delta int) {
+	ids := []int64{0}
+	for _, section := range logJobSections {
+		ids = append(ids, section.job.ID)
+	}
+	current := 0
+	for i, id := range ids {
+		if id == currentLogJobID {
+			current = i
+		}
+	}
+	next := (current + delta + len(ids)) % len(ids)
+	logJobChosen = true
+	selectLogJob(ids[next])
+}
+
+// updateLogJobTable lists the jobs of the run, grouped by stage and colored by
+// status, and selects the job shown
+func updateLogJobTable() {
+	if logJobTable == nil {
+		return
+	}
+	updatingLogJobTable = true
+	defer func() { updatingLogJobTable = false }()
+
+	// Stay on the step selected, as long as its job is still the one shown
+	selectedStep := -1
+	if row, _ := logJobTable.GetSelection(); logStepRowJob == currentLogJobID {
+		if index, ok := logStepRowMap[row]; ok {
+			selectedStep = index
+		}
+	}
+
+	logJobTable.Clear()
+	logJobRowMap = make(map[int]int64)
+	logStepRowMap = make(map[int]int)
+	logStepRowJob = currentLogJobID
+
+	logJobTable.SetCell(0, 0, tview.NewTableCell(fmt.Sprintf("All jobs (%d)", len(logJobSections))).
+		SetTextColor(tcell.ColorWhite).
+		SetExpansion(1))
+	logJobRowMap[0] = 0
+	selectedRow := 0
+
+	row := 1
+	lastStage := ""
+	for i, section := range logJobSections {
+		if i == 0 || section.stage.Name != lastStage {
+			logJobTable.SetCell(row, 0, tview.NewTableCell(section.stage.Name).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+			row++
+			lastStage = section.stage.Name
+		}
+		logJobTable.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf(" %s %s", logJobStatusIcon(section.job.Status), section.job.Name)).
+			SetTextColor(getStatusColor(section.job.Status)))
+		logJobRowMap[row] = section.job.ID
+		if section.job.ID == currentLogJobID {
+			selectedRow = row
+			// The steps of the job shown, folded ones marked
+			for j, step := range section.steps {
+				row++
+				marker := "▼"
+				if step.folded {
+					marker = "▶"
+				}
+				logJobTable.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("   %s %s %s %s", marker, logJobStatusIcon(step.step.Status), step.step.Name, elapsed(step.step.StartTime, step.step.EndTime))).
+					SetTextColor(getStatusColor(step.step.Status)))
+				logStepRowMap[row] = j
+				if j == selectedStep {
+					selectedRow = row
+				}
+			}
+		}
+		row++
+	}
+	logJobTable.Select(selectedRow, 0)
+}
+
+// logJobStatusIcon returns a one character summary of a job status
+func logJobStatusIcon(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUCCESS":
+		return "✓"
+	case "FAILED", "FAIL":
+		return "✗"
+	case "RUNNING":
+		return "▶"
+	case "CANCELED":
+		return "■"
+	case "SKIPPED":
+		return "»"
+	default:
+		return "○"
+	}
+}
+
+// logSearchTargets returns the texts that a search covers and the job each
+// belongs to: the text shown, or the text of every job when searching all
+// jobs while one job is shown
+func logSearchTargets() ([]string, []int64) {
+	if !logSearchAllJobs || currentLogJobID == 0 || len(logJobSections) == 0 {
+		return nil, nil
+	}
+	texts := make([]string, 0, len(logJobSections))
+	jobs := make([]int64, 0, len(logJobSections))
+	for _, section := range logJobSections {
+		texts = append(texts, logJobText(section.job.ID))
+		jobs = append(jobs, section.job.ID)
+	}
+	return texts, jobs
+}
+
+// showLogSearchMatch shows the job of the current search match, switching the
+// job shown if needed, and highlights the match
+func showLogSearchMatch(app *tview.Application) {
+	if logSearchCurrentIdx < 0 || logSearchCurrentIdx >= len(logSearchMatches) {
+		return
+	}
+	if logSearchMatchJobs != nil {
+		if jobID := logSearchMatchJobs[logSearchCurrentIdx]; jobID != currentLogJobID {
+			currentLogJobID = jobID
+			logOriginalText = logJobText(jobID)
+			renderLogViewTitle()
+			updateLogJobTable()
+		}
+	}
+	highlightLogSearchMatches(logOriginalText, logSearchQuery, app)
+}
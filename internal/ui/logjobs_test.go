@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"aliyun-pipelines-tui/internal/api"
+
+	"github.com/rivo/tview"
+)
+
+// setUpLogJobs fills the log view with a run of three jobs, the second failed
+func setUpLogJobs(t *testing.T) {
+	t.Helper()
+	logViewTextView = tview.NewTextView().SetDynamicColors(true)
+	logJobTable = newLogJobTable()
+	t.Cleanup(func() {
+		resetLogJobs(0)
+		logViewTextView, logJobTable = nil, nil
+		logSearchActive, logSearchAllJobs = false, false
+		logSearchQuery, logOriginalText = "", ""
+		logSearchMatches, logSearchMatchJobs = nil, nil
+	})
+
+	build := api.Stage{Name: "Build", Index: "0"}
+	deploy := api.Stage{Name: "Deploy", Index: "1"}
+	resetLogJobs(0)
+	logHeaderText = "Pipeline: svc\n"
+	logJobSections = []logJobSection{
+		{stage: build, job: api.Job{ID: 1, Name: "compile", Status: "SUCCESS"}, number: 1, log: "compile ok\n", loaded: true},
+		{stage: build, job: api.Job{ID: 2, Name: "test", Status: "FAILED"}, number: 2, log: "assert failed\n", loaded: true},
+		{stage: deploy, job: api.Job{ID: 3, Name: "deploy", Status: "SUCCESS"}, number: 3, log: "deploy ok\n", loaded: true},
+	}
+}
+
+func TestLogJobText(t *testing.T) {
+	setUpLogJobs(t)
+
+	all := logJobText(0)
+	for _, want := range []string{"Pipeline: svc", "compile ok", "assert failed", "deploy ok"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("all jobs text lacks %q:\n%s", want, all)
+		}
+	}
+	if strings.Count(all, "Stage: Build") != 1 || strings.Count(all, "Stage: Deploy") != 1 {
+		t.Errorf("expected one header per stage:\n%s", all)
+	}
+
+	if got := firstFailedLogJob(logJobSections); got != 2 {
+		t.Fatalf("firstFailedLogJob = %d, want 2", got)
+	}
+	selectLogJob(2)
+	text := logViewTextView.GetText(false)
+	if !strings.Contains(text, "assert failed") || strings.Contains(text, "compile ok") {
+		t.Errorf("the test job's log should be shown alone:\n%s", text)
+	}
+	if title := logViewTextView.GetTitle(); title != "Logs - Build / test" {
+		t.Errorf("title = %q", title)
+	}
+
+	// All jobs, two stage headers and three jobs; the failed job is selected
+	if rows := logJobTable.GetRowCount(); rows != 6 {
+		t.Errorf("job list has %d rows, want 6", rows)
+	}
+	if row, _ := logJobTable.GetSelection(); logJobRowMap[row] != 2 {
+		t.Errorf("selected row %d shows job %d, want 2", row, logJobRowMap[row])
+	}
+
+	moveLogJob(1)
+	if currentLogJobID != 3 {
+		t.Errorf("next job = %d, want 3", currentLogJobID)
+	}
+	moveLogJob(1)
+	if currentLogJobID != 0 {
+		t.Errorf("the job after the last is %d, want 0 for all jobs", currentLogJobID)
+	}
+}
+
+func TestLogJobTextGrowsAtTheEnd(t *testing.T) {
+	setUpLogJobs(t)
+	logJobSections[2].job.Status = "RUNNING"
+	logJobSections = append(logJobSections, logJobSection{stage: logJobSections[2].stage, job: api.Job{ID: 4, Name: "notify", Status: "INIT"}, number: 4, loaded: true})
+
+	// New lines of the running job only add to the text, so that they are
+	// added to the view rather than replacing its text
+	for _, jobID := range []int64{0, 3} {
+		before := logJobText(jobID)
+		logJobSections[2].log += "still deploying\n"
+		if after := logJobText(jobID); !strings.HasPrefix(after, before) {
+			t.Errorf("text of job %d changed before its end:\n%s", jobID, after)
+		}
+	}
+	if strings.Contains(logJobText(0), "notify") {
+		t.Errorf("a job that has not started is shown among every job's logs")
+	}
+
+	selectLogJob(3)
+	logJobSections[2].log += "deployed\n"
+	renderLogView()
+	if text := logViewTextView.GetText(false); text != logJobText(3) {
+		t.Errorf("view shows:\n%s\nwant:\n%s", text, logJobText(3))
+	}
+}
+
+func TestLogSearchScope(t *testing.T) {
+	setUpLogJobs(t)
+	selectLogJob(2)
+	logSearchActive = true
+	logOriginalText = logViewTextView.GetText(false)
+
+	performLogSearch(" ok", nil)
+	if len(logSearchMatches) != 0 {
+		t.Errorf("searching the failed job found %d matches, want 0", len(logSearchMatches))
+	}
+
+	logSearchAllJobs = true
+	performLogSearch(" ok", nil)
+	if len(logSearchMatches) != 2 {
+		t.Fatalf("searching every job found %d matches, want 2", len(logSearchMatches))
+	}
+	if currentLogJobID != 1 {
+		t.Errorf("the first match should show job 1, shown %d", currentLogJobID)
+	}
+	nextLogSearchMatch(nil)
+	if currentLogJobID != 3 {
+		t.Errorf("the next match should show job 3, shown %d", currentLogJobID)
+	}
+	if text := logViewTextView.GetText(true); !strings.Contains(text, "deploy ok") {
+		t.Errorf("the deploy job's log is not shown:\n%s", text)
+	}
+}
+
+func TestRenderLogViewKeepsPosition(t *testing.T) {
+	setUpLogJobs(t)
+	selectLogJob(0)
+	logSearchActive = true
+	logOriginalText = logViewTextView.GetText(false)
+	performLogSearch(" ok", nil)
+	nextLogSearchMatch(nil)
+	logViewTextView.ScrollTo(2, 0)
+
+	// New lines arrive for the running deploy job
+	logJobSections[2].job.Status = "RUNNING"
+	logJobSections[2].log += "still ok\n"
+	renderLogView()
+
+	if row, _ := logViewTextView.GetScrollOffset(); row != 2 {
+		t.Errorf("scroll offset = %d after new lines, want 2", row)
+	}
+	if len(logSearchMatches) != 3 || logSearchCurrentIdx != 1 {
+		t.Errorf("got match %d of %d, want the second of 3", logSearchCurrentIdx, len(logSearchMatches))
+	}
+	if text := logViewTextView.GetText(true); !strings.Contains(text, "still ok") || !strings.Contains(text, "Status: RUNNING") {
+		t.Errorf("the new lines are not shown:\n%s", text)
+	}
+
+	// A job that has not started waits for its log instead of lacking one
+	section := logJobSection{job: api.Job{ID: 4, Name: "notify", Status: "INIT"}, loaded: true}
+	if text := section.text(); !strings.Contains(text, "Waiting for the job to start") {
+		t.Errorf("unexpected text for a job that has not started:\n%s", text)
+	}
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/runwatch"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// logJobStep is one step of a job shown in the log view, as a section that
+// can be folded down to its header
+type logJobStep struct {
+	step     api.JobStep
+	log      string // Log of the step
+	err      error  // Error of the last attempt to fetch the log
+	folded   bool   // Whether only the step's header is shown
+	complete bool   // Whether the log was fetched after the step finished, so it cannot change
+}
+
+var (
+	// logStepRowMap maps the step rows of the job list to the step's position
+	// in the steps of the job shown
+	logStepRowMap = make(map[int]int)
+	logStepRowJob int64 // Job whose steps logStepRowMap lists
+
+	// logStepLoader fetches the steps of a job in the background; it is set
+	// while a log view is open
+	logStepLoader func(section logJobSection)
+)
+
+// header returns the step's title line: fold marker, name, status and duration
+func (s logJobStep) header() string {
+	marker := "▼"
+	if s.folded {
+		marker = "▶"
+	}
+	header := fmt.Sprintf("[aqua]%s Step %s: %s[-]  [%s]%s[-]", marker, s.step.Index, tview.Escape(s.step.Name), logStatusColor(s.step.Status), s.step.Status)
+	if duration := elapsed(s.step.StartTime, s.step.EndTime); duration != "" {
+		header += "  " + duration
+	}
+	return header + "\n"
+}
+
+// text returns the step's header and, unless it is folded, its log
+func (s logJobStep) text() string {
+	if s.folded {
+		return s.header()
+	}
+	var text strings.Builder
+	text.WriteString(s.header())
+	switch {
+	case s.err != nil && s.log == "":
+		text.WriteString(fmt.Sprintf("Error fetching logs for step %s: %v\n", s.step.Index, s.err))
+	case s.log == "" && !runwatch.HasStarted(s.step.Status):
+		text.WriteString("Waiting for the step to start...\n")
+	case s.log == "":
+		text.WriteString("No logs available for this step.\n")
+	default:
+		text.WriteString(formatJobLog(s.log, false))
+		if !strings.HasSuffix(s.log, "\n") {
+			text.WriteString("\n")
+		}
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// logStatusColor returns the color the status is shown in, as in the status bar
+func logStatusColor(status string) string {
+	switch strings.ToUpper(status) {
+	case "RUNNING":
+		return "green"
+	case "FAILED", "FAIL":
+		return "red"
+	case "CANCELED":
+		return "gray"
+	default:
+		return "white"
+	}
+}
+
+// mergeLogJobSteps combines freshly fetched steps with the ones shown before,
+// keeping what the user folded. Steps seen for the first time are folded when
+// they passed in a job that has a failed step, leaving the failure in view.
+func mergeLogJobSteps(previous, steps []logJobStep) []logJobStep {
+	folded := make(map[string]bool)
+	for _, step := range previous {
+		folded[step.step.Index] = step.folded
+	}
+	failed := false
+	for _, step := range steps {
+		if s := strings.ToUpper(step.step.Status); s == "FAILED" || s == "FAIL" {
+			failed = true
+		}
+	}
+	merged := make([]logJobStep, len(steps))
+	for i, step := range steps {
+		if wasFolded, ok := folded[step.step.Index]; ok {
+			step.folded = wasFolded
+		} else {
+			step.folded = failed && strings.ToUpper(step.step.Status) == "SUCCESS"
+		}
+		merged[i] = step
+	}
+	return merged
+}
+
+// loadLogJobSteps fetches the steps of the job and shows them. A job without
+// steps, or whose steps cannot be fetched, keeps showing its whole log.
+func loadLogJobSteps(ctx context.Context, app *tview.Application, apiClient api.PipelineService, orgId string, section logJobSection) {
+	jobID := section.job.ID
+	steps, noSteps := fetchLogJobSteps(ctx, apiClient, orgId, currentPipelineIDForRun, currentRunID, section)
+	if ctx.Err() != nil {
+		return
+	}
+
+	app.QueueUpdateDraw(func() {
+		if ctx.Err() != nil || !isLogViewActive || logViewTextView == nil {
+			return
+		}
+		for i := range logJobSections {
+			if logJobSections[i].job.ID == jobID {
+				logJobSections[i].steps = mergeLogJobSteps(logJobSections[i].steps, steps)
+				logJobSections[i].stepsLoaded = true
+				logJobSections[i].noSteps = noSteps
+			}
+		}
+		renderLogView()
+	})
+}
+
+// fetchLogJobSteps fetches the steps of the job and the logs of the steps that
+// may have changed since they were fetched. The steps shown are kept when
+// they cannot be fetched this time. noSteps is set when the API does not
+// know the steps of the job or the log of one of them, answering 404: the
+// step endpoints are not documented, so the job's whole log is shown instead
+// and its steps are not asked for again.
+func fetchLogJobSteps(ctx context.Context, apiClient api.PipelineService, orgId, pipelineID, runID string, section logJobSection) (steps []logJobStep, noSteps bool) {
+	jobIdStr := strconv.FormatInt(section.job.ID, 10)
+	fetched, err := apiClient.GetPipelineJobStepsContext(ctx, orgId, pipelineID, runID, jobIdStr)
+	if errors.Is(err, api.ErrNotFound) {
+		return nil, true
+	}
+	if err != nil {
+		return section.steps, false
+	}
+
+	previous := make(map[string]logJobStep)
+	for _, step := range section.steps {
+		previous[step.step.Index] = step
+	}
+	for _, step := range fetched {
+		item := previous[step.Index]
+		item.step = step
+		if runwatch.HasStarted(step.Status) && !item.complete {
+			log, err := apiClient.GetPipelineJobStepLogContext(ctx, orgId, pipelineID, runID, jobIdStr, step.Index)
+			if errors.Is(err, api.ErrNotFound) {
+				return nil, true
+			}
+			if ctx.Err() != nil {
+				return section.steps, false
+			}
+			item.err = err
+			if err == nil {
+				item.log = log
+				item.complete = runwatch.IsFinished(step.Status)
+			}
+		}
+		steps = append(steps, item)
+	}
+	return steps, false
+}
+
+// stepsSettled reports whether the job's steps cannot change any more, so
+// there is no need to fetch them again
+func (s logJobSection) stepsSettled() bool {
+	if !s.stepsLoaded || !s.complete {
+		return false
+	}
+	for _, step := range s.steps {
+		if !step.complete && runwatch.HasStarted(step.step.Status) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogJobSteps fetches the steps of the job shown unless they cannot
+// have changed since they were last fetched
+func requestLogJobSteps() {
+	section, ok := findLogJobSection(currentLogJobID)
+	if !ok || logStepLoader == nil || !runwatch.HasStarted(section.job.Status) || isVMDeployJob(section.job) || section.noSteps || section.stepsSettled() {
+		return
+	}
+	logStepLoader(section)
+}
+
+// toggleLogStep folds or unfolds a step of the job shown
+func toggleLogStep(index int) {
+	for i := range logJobSections {
+		if logJobSections[i].job.ID == currentLogJobID && index < len(logJobSections[i].steps) {
+			logJobSections[i].steps[index].folded = !logJobSections[i].steps[index].folded
+			renderLogView()
+			return
+		}
+	}
+}
+
+// toggleLogStepsFolded folds every step of the job shown that passed, or
+// unfolds every step if any is folded already
+func toggleLogStepsFolded() {
+	for i := range logJobSections {
+		if logJobSections[i].job.ID != currentLogJobID {
+			continue
+		}
+		steps := logJobSections[i].steps
+		anyFolded := false
+		for _, step := range steps {
+			anyFolded = anyFolded || step.folded
+		}
+		for j := range steps {
+			steps[j].folded = !anyFolded && strings.ToUpper(steps[j].step.Status) == "SUCCESS"
+		}
+		renderLogView()
+		return
+	}
+}
+
+// scrollToLogStep scrolls the log view to the header of a step of the job shown
+func scrollToLogStep(index int) {
+	section, ok := findLogJobSection(currentLogJobID)
+	if !ok || index >= len(section.steps) || logViewTextView == nil {
+		return
+	}
+	step := section.steps[index].step
+	title := fmt.Sprintf(" Step %s: %s  ", step.Index, step.Name)
+	text := logPlainText(logViewTextView.GetText(false))
+	if pos := strings.Index(text, title); pos >= 0 {
+		logViewTextView.ScrollTo(strings.Count(text[:pos], "\n"), 0)
+	}
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/api/fake"
+)
+
+func TestLogJobSteps(t *testing.T) {
+	setUpLogJobs(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	fetched := []logJobStep{
+		{step: api.JobStep{Index: "1", Name: "checkout", Status: "SUCCESS", StartTime: start, EndTime: start.Add(5 * time.Second)}, log: "cloned\n", complete: true},
+		{step: api.JobStep{Index: "2", Name: "unit tests", Status: "FAILED", StartTime: start.Add(5 * time.Second), EndTime: start.Add(65 * time.Second)}, log: "assert failed\n", complete: true},
+	}
+	logJobSections[1].steps = mergeLogJobSteps(nil, fetched)
+	logJobSections[1].stepsLoaded = true
+	selectLogJob(2)
+
+	// The step that passed is folded, leaving the failure in view
+	text := logViewTextView.GetText(false)
+	if !strings.Contains(text, "▶ Step 1: checkout[-]  [white]SUCCESS[-]  5s\n") || strings.Contains(text, "cloned") {
+		t.Errorf("the passed step should be folded:\n%s", text)
+	}
+	if !strings.Contains(text, "▼ Step 2: unit tests[-]  [red]FAILED[-]  1m0s\nassert failed\n") {
+		t.Errorf("the failed step should be unfolded:\n%s", text)
+	}
+
+	// The steps are listed under the job in the sidebar and fold from there
+	row := -1
+	for r, index := range logStepRowMap {
+		if index == 0 {
+			row = r
+		}
+	}
+	if row < 0 || !strings.Contains(logJobTable.GetCell(row, 0).Text, "▶ ✓ checkout 5s") {
+		t.Fatalf("the steps are not listed in the sidebar: %v", logStepRowMap)
+	}
+	logJobTable.Select(row, 0)
+	toggleLogStep(0)
+	if text := logViewTextView.GetText(false); !strings.Contains(text, "▼ Step 1: checkout") || !strings.Contains(text, "cloned") {
+		t.Errorf("the step should be unfolded:\n%s", text)
+	}
+	if selected, _ := logJobTable.GetSelection(); selected != row {
+		t.Errorf("selected row = %d, want the step's row %d", selected, row)
+	}
+
+	// A refresh keeps what the user folded
+	logJobSections[1].steps = mergeLogJobSteps(logJobSections[1].steps, fetched)
+	if logJobSections[1].steps[0].folded {
+		t.Error("a refresh folded the step again")
+	}
+
+	// 'z' folds the passed steps, then unfolds everything
+	toggleLogStepsFolded()
+	if steps := logJobSections[1].steps; !steps[0].folded || steps[1].folded {
+		t.Errorf("fold state after folding = %v, %v", steps[0].folded, steps[1].folded)
+	}
+	toggleLogStepsFolded()
+	if steps := logJobSections[1].steps; steps[0].folded || steps[1].folded {
+		t.Errorf("fold state after unfolding = %v, %v", steps[0].folded, steps[1].folded)
+	}
+}
+
+func TestFetchLogJobStepsFallsBackToTheJobLog(t *testing.T) {
+	service, c := fake.NewWithClock()
+	now := c.Now()
+	p := service.AddPipeline("svc", []fake.StageSpec{
+		{Name: "Build", Jobs: []fake.JobSpec{{Name: "build", Duration: time.Minute, Steps: []fake.StepSpec{{Name: "checkout"}, {Name: "compile"}}}}},
+	}, nil)
+	runID, err := service.AddRun(p.PipelineID, now.Add(-time.Hour), fake.RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	details, err := service.GetPipelineRunDetails("org", p.PipelineID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := logJobSection{job: details.Stages[0].Jobs[0]}
+
+	ctx := context.Background()
+	if steps, noSteps := fetchLogJobSteps(ctx, service, "org", p.PipelineID, runID, section); len(steps) != 2 || noSteps {
+		t.Fatalf("got %d steps, noSteps %v", len(steps), noSteps)
+	}
+
+	// A 404 from the step endpoints leaves the whole log of the job
+	notFound := &api.APIError{Kind: api.ErrNotFound, StatusCode: 404, Method: "GET", Endpoint: "/steps"}
+	service.SetError("GetPipelineJobStepLog", notFound)
+	if steps, noSteps := fetchLogJobSteps(ctx, service, "org", p.PipelineID, runID, section); len(steps) != 0 || !noSteps {
+		t.Errorf("step log not found: got %d steps, noSteps %v", len(steps), noSteps)
+	}
+	service.SetError("GetPipelineJobSteps", notFound)
+	if steps, noSteps := fetchLogJobSteps(ctx, service, "org", p.PipelineID, runID, section); len(steps) != 0 || !noSteps {
+		t.Errorf("steps not found: got %d steps, noSteps %v", len(steps), noSteps)
+	}
+
+	// Other failures keep the steps shown and try again later
+	section.steps = []logJobStep{{step: api.JobStep{Index: "1", Name: "checkout"}}}
+	service.SetError("GetPipelineJobSteps", &api.APIError{Kind: api.ErrServer, StatusCode: 500, Method: "GET", Endpoint: "/steps"})
+	if steps, noSteps := fetchLogJobSteps(ctx, service, "org", p.PipelineID, runID, section); len(steps) != 1 || noSteps {
+		t.Errorf("server error: got %d steps, noSteps %v", len(steps), noSteps)
+	}
+}
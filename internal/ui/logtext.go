@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/ansi"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var (
+	// logShowColors shows the ANSI colors of job logs; plain text otherwise
+	logShowColors = true
+
+	// Style tags and escaped brackets, as tview reads them in the log view
+	logTagPattern        = regexp.MustCompile(`^\[(-|#[0-9a-fA-F]{6}|[a-zA-Z][a-zA-Z0-9]*)?(:(-|#[0-9a-fA-F]{6}|[a-zA-Z][a-zA-Z0-9]*)?(:(-|[buildsrBUILDSR]*))?)?\]`)
+	logEscapedTagPattern = regexp.MustCompile(`^\[[^\[\]]+\[+\]`)
+
+	// Styles of search matches: the current one and the others
+	logCurrentMatchStyle = ansi.Style{Foreground: "gold", Background: "gray"}
+	logOtherMatchStyle   = ansi.Style{Foreground: "white", Background: "gray"}
+)
+
+// formatJobLog prepares a job's log for the log view: its ANSI colors become
+// style tags, or are dropped when plain text is shown. markup is set for logs
+// that already hold style tags rather than ANSI colors.
+func formatJobLog(log string, markup bool) string {
+	switch {
+	case markup && logShowColors:
+		return log
+	case markup:
+		return tview.Escape(logPlainText(log))
+	case logShowColors:
+		return ansi.ToTview(log)
+	default:
+		return tview.Escape(ansi.Strip(log))
+	}
+}
+
+// parseLogText splits the text of the log view into runs of one style, the
+// way tview shows it: style tags are applied and escaped brackets unescaped
+func parseLogText(text string) []ansi.Span {
+	var (
+		spans []ansi.Span
+		style ansi.Style
+		run   strings.Builder
+	)
+	flush := func() {
+		if run.Len() > 0 {
+			spans = append(spans, ansi.Span{Text: run.String(), Style: style})
+			run.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if text[i] == '[' {
+			if tag := logTagPattern.FindString(text[i:]); len(tag) > 2 {
+				flush()
+				applyLogTag(&style, tag[1:len(tag)-1])
+				i += len(tag)
+				continue
+			}
+			if escaped := logEscapedTagPattern.FindString(text[i:]); escaped != "" {
+				// "[red[]" shows as "[red]"
+				run.WriteString(escaped[:len(escaped)-2] + "]")
+				i += len(escaped)
+				continue
+			}
+		}
+		run.WriteByte(text[i])
+		i++
+	}
+	flush()
+	return spans
+}
+
+// applyLogTag updates the style with a "foreground:background:attributes"
+// tag, where an empty field keeps and "-" resets the current value
+func applyLogTag(style *ansi.Style, tag string) {
+	fields := strings.SplitN(tag, ":", 3)
+	set := func(value string, field *string) {
+		switch value {
+		case "":
+		case "-":
+			*field = ""
+		default:
+			*field = value
+		}
+	}
+	set(fields[0], &style.Foreground)
+	if len(fields) > 1 {
+		set(fields[1], &style.Background)
+	}
+	if len(fields) > 2 {
+		if fields[2] == "-" {
+			style.Attributes = ""
+		}
+		for _, letter := range []byte(fields[2]) {
+			if letter >= 'a' && letter <= 'z' {
+				style.SetAttribute(letter, true)
+			} else if letter >= 'A' && letter <= 'Z' {
+				style.SetAttribute(letter-'A'+'a', false)
+			}
+		}
+	}
+}
+
+// logPlainText returns the text of the log view as it reads on screen
+func logPlainText(text string) string {
+	var plain strings.Builder
+	for _, span := range parseLogText(text) {
+		plain.WriteString(span.Text)
+	}
+	return plain.String()
+}
+
+// highlightLogText returns the text of the log view with the matches, given as
+// start offsets into its plain text, shown in the match styles on top of the
+// text's own colors
+func highlightLogText(text string, matches []int, length, current int) string {
+	var result []ansi.Span
+	pos, next := 0, 0 // Offset into the plain text and the next match to show
+	for _, span := range parseLogText(text) {
+		for span.Text != "" {
+			for next < len(matches) && matches[next]+length <= pos {
+				next++
+			}
+			end := pos + len(span.Text)
+			style := span.Style
+			if next < len(matches) && matches[next] <= pos {
+				// Inside a match
+				end = min(end, matches[next]+length)
+				style = logOtherMatchStyle
+				if next == current {
+					style = logCurrentMatchStyle
+				}
+			} else if next < len(matches) && matches[next] < end {
+				end = matches[next]
+			}
+			result = append(result, ansi.Span{Text: span.Text[:end-pos], Style: style})
+			span.Text = span.Text[end-pos:]
+			pos = end
+		}
+	}
+	return ansi.Tview(result)
+}
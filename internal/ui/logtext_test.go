@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"aliyun-pipelines-tui/internal/api"
+)
+
+func TestLogTextColorsAndSearch(t *testing.T) {
+	setUpLogJobs(t)
+	logJobSections[0].log = "\x1b[32m[INFO] build ok\x1b[0m\nplain [x] line\n"
+	selectLogJob(1)
+
+	shown := logViewTextView.GetText(false)
+	if !strings.Contains(shown, "[green:-:-][INFO[] build ok[-:-:-]") {
+		t.Errorf("the ANSI colors were not translated:\n%s", shown)
+	}
+	if plain := logPlainText(shown); !strings.Contains(plain, "[INFO] build ok\nplain [x] line") {
+		t.Errorf("the plain text lost its brackets:\n%s", plain)
+	}
+
+	// Search the text as it reads, even where a match spans a color change
+	logSearchActive = true
+	logOriginalText = shown
+	performLogSearch("ok\npLAIN [", nil)
+	if len(logSearchMatches) != 1 {
+		t.Fatalf("found %d matches, want 1", len(logSearchMatches))
+	}
+	highlighted := logViewTextView.GetText(false)
+	if !strings.Contains(highlighted, "[green:-:-][INFO[] build [gold:gray:-]ok\nplain [[-:-:-]x] line") {
+		t.Errorf("the match is not highlighted on top of the colors:\n%s", highlighted)
+	}
+	if plain := logPlainText(highlighted); plain != logPlainText(shown) {
+		t.Errorf("highlighting changed the text:\n%s", plain)
+	}
+
+	// Plain text drops the colors but keeps the brackets
+	logShowColors = false
+	defer func() { logShowColors = true }()
+	section := logJobSection{job: api.Job{ID: 4, Status: "SUCCESS"}, log: "\x1b[31m[ERROR]\x1b[0m\n", loaded: true}
+	if text := section.text(); !strings.Contains(text, "[ERROR[]\n") || strings.Contains(text, "maroon") {
+		t.Errorf("unexpected plain text:\n%s", text)
+	}
+}
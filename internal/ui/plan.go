@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"aliyun-pipelines-tui/internal/plan"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var (
+	// The run plan given with -plan, nil if none
+	activePlan *planRun
+
+	// State of the plan view
+	isPlanViewActive bool
+	planGraph        *runGraph
+	planHeader       *tview.TextView
+	planDetail       *tview.TextView
+)
+
+// planRun is a run plan being executed in the background and what the UI
+// knows of its steps. The executor owns the plan's state; the UI keeps the
+// copies it reports, updated on the UI goroutine.
+type planRun struct {
+	apiClient api.PipelineService // The plan keeps the organization it was started in
+	orgId     string
+	path      string
+	plan      *plan.Plan
+	steps     map[string]plan.StepState
+	started   time.Time
+	done      bool
+	err       error // Why the plan did not succeed, once done
+}
+
+// planDetails lays a plan out as a run for the run graph: a column per level
+// of needs and a box per step
+func planDetails(p *plan.Plan, steps map[string]plan.StepState) *api.PipelineRunDetails {
+	details := &api.PipelineRunDetails{}
+	for i, level := range p.Levels() {
+		stage := api.Stage{Name: fmt.Sprintf("Level %d", i+1)}
+		for _, step := range level {
+			s := steps[step.Name]
+			stage.Jobs = append(stage.Jobs, api.Job{
+				Name:      step.Name,
+				Status:    valueOr(s.Status, plan.StatusPending),
+				StartTime: s.StartedAt,
+				EndTime:   s.FinishedAt,
+			})
+		}
+		details.Stages = append(details.Stages, stage)
+	}
+	return details
+}
+
+// planSummary counts the steps by status, e.g. "2 SUCCESS, 1 RUNNING, 3 PENDING"
+func planSummary(p *plan.Plan, steps map[string]plan.StepState) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, step := range p.Steps {
+		status := valueOr(steps[step.Name].Status, plan.StatusPending)
+		if counts[status] == 0 {
+			order = append(order, status)
+		}
+		counts[status]++
+	}
+	parts := make([]string, len(order))
+	for i, status := range order {
+		parts[i] = fmt.Sprintf("%d %s", counts[status], status)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeStep is the detail line of a step of the plan
+func describeStep(step plan.Step, s plan.StepState) string {
+	text := fmt.Sprintf("[yellow]%s[-]: pipeline %s", tview.Escape(step.Name), tview.Escape(step.Pipeline))
+	if len(step.Needs) > 0 {
+		text += ", needs " + tview.Escape(strings.Join(step.Needs, ", "))
+	}
+	if s.RunID != "" {
+		text += ", run " + s.RunID
+	}
+	if s.Error != "" {
+		text += " | [red]" + tview.Escape(s.Error) + "[-]"
+	}
+	return text
+}
+
+// StartPlan runs the plan in a file in the background and opens its view.
+// Progress is saved next to the plan as it goes, so that starting flowt with
+// the same plan again resumes it.
+func StartPlan(app *tview.Application, apiClient api.PipelineService, orgId, path string, p *plan.Plan, state *plan.State) {
+	run := &planRun{apiClient: apiClient, orgId: orgId, path: path, plan: p, steps: make(map[string]plan.StepState), started: time.Now()}
+	for _, step := range p.Steps {
+		run.steps[step.Name] = state.Step(step.Name)
+	}
+	activePlan = run
+
+	executor := &plan.Executor{
+		Service:        apiClient,
+		OrganizationID: orgId,
+		Save: func(state *plan.State) error {
+			return plan.SaveState(plan.StatePath(path), state)
+		},
+		OnChange: func(step string, s plan.StepState) {
+			app.QueueUpdateDraw(func() {
+				run.steps[step] = s
+				refreshPlanView()
+			})
+		},
+	}
+	go func() {
+		err := executor.Run(context.Background(), p, state)
+		app.QueueUpdateDraw(func() {
+			run.done, run.err = true, err
+			refreshPlanView()
+		})
+	}()
+
+	showPlanView(app)
+}
+
+// refreshPlanView redraws the plan view with what is known of the steps
+func refreshPlanView() {
+	if activePlan == nil || planGraph == nil {
+		return
+	}
+	planGraph.SetDetails(planDetails(activePlan.plan, activePlan.steps))
+
+	state := "running"
+	switch {
+	case activePlan.done && activePlan.err != nil:
+		state = "[red]" + tview.Escape(describeError(activePlan.err)) + "[-]"
+	case activePlan.done:
+		state = "[green]succeeded[-]"
+	}
+	planHeader.SetText(fmt.Sprintf("Plan %s | onFailure: %s | %s | %s | Started: %s",
+		tview.Escape(activePlan.plan.Name), activePlan.plan.OnFailure, planSummary(activePlan.plan, activePlan.steps),
+		state, activePlan.started.Format("15:04:05")))
+
+	if _, job, ok := planGraph.Selection(); ok {
+		step, _ := activePlan.plan.Step(job.Name)
+		planDetail.SetText(describeStep(step, activePlan.steps[job.Name]))
+	}
+}
+
+// showPlanView opens the view of the plan: its steps laid out by needs and
+// colored by status. Closing it leaves the plan running.
+func showPlanView(app *tview.Application) {
+	if activePlan == nil {
+		return
+	}
+	apiClient, orgId := activePlan.apiClient, activePlan.orgId
+
+	planHeader = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignLeft)
+	planHeader.SetBackgroundColor(tcell.ColorDefault)
+	planDetail = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignLeft)
+	planDetail.SetBackgroundColor(tcell.ColorDefault)
+
+	planGraph = newRunGraph()
+	planGraph.SetBorder(true).SetTitle(fmt.Sprintf("Plan: %s", activePlan.path)).SetBackgroundColor(tcell.ColorDefault)
+
+	help := tview.NewTextView().
+		SetText("Keys: h/l=level, j/k=step, Enter=stage graph of the step's run, q=back to pipelines (the plan keeps running), Q=quit").
+		SetTextAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorGray)
+	help.SetBackgroundColor(tcell.ColorDefault)
+
+	page := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(planHeader, 1, 1, false).
+		AddItem(planGraph, 0, 1, true).
+		AddItem(planDetail, 1, 1, false).
+		AddItem(help, 1, 1, false)
+
+	closeView := func() {
+		isPlanViewActive = false
+		mainPagesGlobal.RemovePage("plan")
+		mainPagesGlobal.SwitchToPage("pipelines")
+		app.SetFocus(pipelineTableGlobal)
+	}
+	planGraph.SetChangedFunc(func(stage api.Stage, job api.Job) {
+		step, _ := activePlan.plan.Step(job.Name)
+		planDetail.SetText(describeStep(step, activePlan.steps[job.Name]))
+	})
+	planGraph.SetSelectedFunc(func(stage api.Stage, job api.Job) {
+		s := activePlan.steps[job.Name]
+		if s.RunID == "" {
+			return
+		}
+		// Follow the step's run in the stage graph, coming back here when it is closed
+		step, _ := activePlan.plan.Step(job.Name)
+		currentPipelineIDForRun, currentPipelineName = s.PipelineID, step.Pipeline
+		currentRunID, currentRunStatus = s.RunID, s.Status
+		isRunGraphActive = true
+		runGraphBack = func() {
+			mainPagesGlobal.SwitchToPage("plan")
+			app.SetFocus(planGraph)
+		}
+		runGraphView.SetDetails(nil)
+		updateRunGraphHeader(nil, nil)
+		mainPagesGlobal.SwitchToPage("run_graph")
+		app.SetFocus(runGraphView)
+		startRunGraphRefresh(app, apiClient, orgId)
+	})
+	planGraph.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'q' || event.Key() == tcell.KeyEscape {
+			closeView()
+			return nil
+		}
+		return event
+	})
+
+	refreshPlanView()
+	isPlanViewActive = true
+	mainPagesGlobal.AddPage("plan", page, true, false)
+	mainPagesGlobal.SwitchToPage("plan")
+	app.SetFocus(planGraph)
+}
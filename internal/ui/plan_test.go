@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"testing"
+
+	"aliyun-pipelines-tui/internal/plan"
+)
+
+func TestPlanDetails(t *testing.T) {
+	p, err := plan.Parse([]byte(`
+steps:
+  - pipeline: lib
+  - pipeline: order
+    needs: [lib]
+  - pipeline: pay
+    needs: [lib]
+  - pipeline: release
+    needs: [order, pay]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := map[string]plan.StepState{
+		"lib":   {Status: "SUCCESS"},
+		"order": {Status: "RUNNING", RunID: "7"},
+	}
+
+	details := planDetails(p, steps)
+	if len(details.Stages) != 3 || len(details.Stages[1].Jobs) != 2 || details.Stages[2].Jobs[0].Name != "release" {
+		t.Fatalf("stages = %+v", details.Stages)
+	}
+	if status := details.Stages[1].Jobs[1].Status; status != plan.StatusPending {
+		t.Errorf("step without state: status %s", status)
+	}
+	if summary := planSummary(p, steps); summary != "1 SUCCESS, 1 RUNNING, 2 PENDING" {
+		t.Errorf("summary = %q", summary)
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"aliyun-pipelines-tui/internal/api"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showValidationDialog shows the manual validation (人工卡点) a job of the
+// current run waits on and lets a validator approve or reject it with a
+// comment. done is called once the decision has been sent.
+func showValidationDialog(app *tview.Application, apiClient api.PipelineService, orgId string, job api.Job, done func()) {
+	gate, ok := job.ValidationGate()
+	if !ok {
+		ShowModal("No Validation",
+			fmt.Sprintf("Job %s is not waiting for a manual validation.\nCurrent status: %s", job.Name, job.Status),
+			[]string{"OK"}, nil)
+		return
+	}
+	validators := strings.Join(gate.Validators, ", ")
+	if validators == "" {
+		validators = "-"
+	}
+	if !gate.Allowed {
+		ShowModal("Not a Validator",
+			fmt.Sprintf("You cannot approve or reject job %s.\n\nValidators: %s", job.Name, validators),
+			[]string{"OK"}, nil)
+		return
+	}
+
+	pipelineID, runID := currentPipelineIDForRun, currentRunID
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Manual Validation: %s", job.Name))
+	form.SetBackgroundColor(tcell.ColorDefault)
+
+	description := gate.Description
+	if description == "" {
+		description = "-"
+	}
+	form.AddTextView("Validation:", description, 50, 2, false, false)
+	form.AddTextView("Validators:", validators, 50, 1, false, false)
+	comment := ""
+	form.AddInputField("Comment:", "", 50, nil, func(text string) {
+		comment = text
+	})
+
+	closeForm := func() {
+		mainPagesGlobal.RemovePage("validate")
+		app.SetFocus(runGraphView)
+	}
+	// decide sends the decision, pass or refuse, and reports how it went
+	decide := func(verb string, send func(organizationId, pipelineId, pipelineRunId, jobId, comment string) error) {
+		closeForm()
+		go func() {
+			err := send(orgId, pipelineID, runID, strconv.FormatInt(job.ID, 10), comment)
+			app.QueueUpdateDraw(func() {
+				if err != nil {
+					ShowModal("Error", fmt.Sprintf("Failed to %s job %s: %s", verb, job.Name, describeError(err)), []string{"OK"}, nil)
+					return
+				}
+				ShowModal("Success", fmt.Sprintf("Sent %s for job %s.", verb, job.Name), []string{"OK"}, func(buttonIndex int, buttonLabel string) {
+					if done != nil {
+						done()
+					}
+				})
+			})
+		}()
+	}
+	form.AddButton("Approve", func() {
+		decide("approve", apiClient.PassPipelineValidate)
+	})
+	form.AddButton("Reject", func() {
+		decide("reject", apiClient.RefusePipelineValidate)
+	})
+	form.AddButton("Cancel", closeForm)
+	form.SetCancelFunc(closeForm)
+
+	// Set form styling
+	form.SetButtonBackgroundColor(tcell.ColorDefault)
+	form.SetButtonTextColor(tcell.ColorWhite)
+	form.SetFieldBackgroundColor(tcell.ColorDefault)
+	form.SetFieldTextColor(tcell.ColorWhite)
+	form.SetLabelColor(tcell.ColorWhite)
+
+	mainPagesGlobal.AddPage("validate", form, true, true)
+	app.SetFocus(form)
+}